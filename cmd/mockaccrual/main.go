@@ -1,7 +1,15 @@
 package main
 
-import "github.com/msmkdenis/yap-gophermart/internal/app/accrual"
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/msmkdenis/yap-gophermart/internal/app/accrual"
+)
 
 func main() {
-	accrual.Run()
+	quitSignal := make(chan os.Signal, 1)
+	signal.Notify(quitSignal, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	accrual.Run(quitSignal)
 }
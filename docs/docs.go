@@ -15,75 +15,128 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/user/balance": {
+        "/api/admin/balance/consistency": {
             "get": {
                 "security": [
                     {
-                        "JWT": []
+                        "AdminToken": []
                     }
                 ],
-                "description": "Get the current balance of the user's loyalty points account.",
+                "description": "Compare every materialized balance with the sum of its ledger postings and check that every ledger transaction sums to zero.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Balance API"
+                    "Admin API"
                 ],
-                "summary": "Get user balance",
+                "summary": "Check balance consistency",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.BalanceResponse"
+                            "$ref": "#/definitions/dto.ConsistencyResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
+                    "403": {
+                        "description": "Forbidden"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/api/user/balance/withdraw": {
+        "/api/admin/balance/tiers/recalculation": {
             "post": {
                 "security": [
                     {
-                        "JWT": []
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Place every user into the loyalty tier matching the accruals of the last 12 months without waiting for the nightly recalculation.",
+                "tags": [
+                    "Admin API"
+                ],
+                "summary": "Recalculate loyalty tiers",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/admin/balance/withdrawals/{order}/reversal": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
                     }
                 ],
-                "description": "Withdraw points from the loyalty points account to pay for a new order.",
+                "description": "Refund the withdrawal made for the order back to the user balance, e.g. when the order is cancelled. A withdrawal is reversed at most once.",
                 "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Balance API"
+                    "Admin API"
                 ],
-                "summary": "Withdrawal request",
+                "summary": "Reverse withdrawal",
                 "parameters": [
                     {
-                        "description": "Order number and withdrawal sum.",
-                        "name": "withdrawal",
+                        "type": "string",
+                        "description": "Order number of the withdrawal.",
+                        "name": "order",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason of the reversal.",
+                        "name": "reversal",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.BalanceWithdrawRequest"
+                            "$ref": "#/definitions/dto.WithdrawalReversalRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WithdrawalReversalResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
-                    "402": {
-                        "description": "Payment Required"
+                    "403": {
+                        "description": "Forbidden"
                     },
-                    "422": {
-                        "description": "Unprocessable Entity"
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type"
                     },
                     "500": {
                         "description": "Internal Server Error"
@@ -91,65 +144,148 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user/login": {
-            "post": {
-                "description": "User authorization by login and password.",
-                "consumes": [
+        "/api/admin/orders/dead-letter": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Get a list of orders whose accrual polling attempts were exhausted.",
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User API"
+                    "Admin API"
                 ],
-                "summary": "User authorization",
-                "parameters": [
+                "summary": "Get dead-lettered orders",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.DeadLetteredOrderResponse"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/admin/orders/leased": {
+            "get": {
+                "security": [
                     {
-                        "description": "User login and password.",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Get a list of orders currently claimed by the accrual worker with their lease age.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin API"
+                ],
+                "summary": "Get leased orders",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.UserLoginRequest"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.LeasedOrderResponse"
+                            }
                         }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/admin/orders/{number}/requeue": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Return a dead-lettered order to accrual polling with a fresh attempt count.",
+                "tags": [
+                    "Admin API"
+                ],
+                "summary": "Requeue dead-lettered order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order number.",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK"
                     },
-                    "400": {
-                        "description": "Bad Request"
-                    },
                     "401": {
                         "description": "Unauthorized"
                     },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/api/user/orders": {
+        "/api/admin/promo": {
             "get": {
                 "security": [
                     {
-                        "JWT": []
+                        "AdminToken": []
                     }
                 ],
-                "description": "Get a list of order numbers uploaded by the user,\ntheir processing statuses and information about accruals.",
+                "description": "Get all promo codes with the number of their redemptions, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Order API"
+                    "Admin API"
                 ],
-                "summary": "Get uploaded orders",
+                "summary": "Get promo codes",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/dto.OrderResponse"
+                                "$ref": "#/definitions/dto.PromoCodeResponse"
                             }
                         }
                     },
@@ -159,6 +295,9 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized"
                     },
+                    "403": {
+                        "description": "Forbidden"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
@@ -167,34 +306,37 @@ const docTemplate = `{
             "post": {
                 "security": [
                     {
-                        "JWT": []
+                        "AdminToken": []
                     }
                 ],
-                "description": "Loading by the user of the order number.",
+                "description": "Create a promo code crediting the sum to the balance of a user who redeems it within the validity window. Codes are case-insensitive, per_user_limit defaults to 1.",
                 "consumes": [
-                    "text/plain"
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
                 ],
                 "tags": [
-                    "Order API"
+                    "Admin API"
                 ],
-                "summary": "Add new order",
+                "summary": "Create promo code",
                 "parameters": [
                     {
-                        "description": "Order number.",
-                        "name": "order",
+                        "description": "Promo code, sum, limits and validity window.",
+                        "name": "promo",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/dto.PromoCodeRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
-                    },
-                    "202": {
-                        "description": "Accepted"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PromoCodeResponse"
+                        }
                     },
                     "400": {
                         "description": "Bad Request"
@@ -202,11 +344,14 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized"
                     },
+                    "403": {
+                        "description": "Forbidden"
+                    },
                     "409": {
                         "description": "Conflict"
                     },
-                    "422": {
-                        "description": "Unprocessable Entity"
+                    "415": {
+                        "description": "Unsupported Media Type"
                     },
                     "500": {
                         "description": "Internal Server Error"
@@ -214,36 +359,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user/register": {
-            "post": {
-                "description": "User registration by login and password.",
-                "consumes": [
+        "/api/health": {
+            "get": {
+                "description": "Get the service health including the accrual system circuit breaker state. Degraded accrual does not fail the check.",
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User API"
+                    "Health API"
                 ],
-                "summary": "User registration",
-                "parameters": [
-                    {
-                        "description": "User login and password.",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                "summary": "Get service health",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.UserRegisterRequest"
+                            "$ref": "#/definitions/dto.HealthResponse"
                         }
                     }
+                }
+            }
+        },
+        "/api/user/balance": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get the balance of the user's loyalty points account: current is available for withdrawal, held is reserved by active holds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Balance API"
                 ],
+                "summary": "Get user balance",
                 "responses": {
                     "200": {
-                        "description": "OK"
-                    },
-                    "400": {
-                        "description": "Bad Request"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BalanceResponse"
+                        }
                     },
-                    "409": {
-                        "description": "Conflict"
+                    "401": {
+                        "description": "Unauthorized"
                     },
                     "500": {
                         "description": "Internal Server Error"
@@ -251,28 +410,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user/withdrawals": {
+        "/api/user/balance/holds": {
             "get": {
                 "security": [
                     {
                         "JWT": []
                     }
                 ],
-                "description": "Get a list of withdrawals from a user's loyalty points account.",
+                "description": "Get the holds of the user's loyalty points account, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Balance API"
                 ],
-                "summary": "Get withdrawals list",
+                "summary": "Get holds list",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/dto.WithdrawalResponse"
+                                "$ref": "#/definitions/dto.HoldResponse"
                             }
                         }
                     },
@@ -286,45 +445,971 @@ const docTemplate = `{
                         "description": "Internal Server Error"
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "dto.BalanceResponse": {
-            "type": "object",
-            "properties": {
-                "current": {
-                    "type": "number"
-                },
-                "withdrawn": {
-                    "type": "number"
-                }
-            }
-        },
-        "dto.BalanceWithdrawRequest": {
-            "type": "object",
-            "required": [
-                "order",
-                "sum"
-            ],
-            "properties": {
-                "order": {
-                    "type": "string"
-                },
-                "sum": {
-                    "type": "number"
-                }
-            }
-        },
-        "dto.OrderResponse": {
-            "type": "object",
-            "properties": {
-                "accrual": {
-                    "type": "number"
-                },
-                "number": {
-                    "type": "string"
-                },
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Reserve points of the loyalty points account for a new order. Held points are not available until the hold is captured or released,\na hold that is neither captured nor released in time is released automatically.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Balance API"
+                ],
+                "summary": "Hold points",
+                "parameters": [
+                    {
+                        "description": "Order number and sum to hold.",
+                        "name": "hold",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.HoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HoldResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "402": {
+                        "description": "Payment Required"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/balance/holds/{id}/capture": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Withdraw the held points for the order of the hold.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Balance API"
+                ],
+                "summary": "Capture hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold id.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HoldResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/balance/holds/{id}/release": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Return the held points to the available balance.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Balance API"
+                ],
+                "summary": "Release hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold id.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HoldResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/balance/ledger": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get the postings of the user's loyalty points account, oldest first, with the balance after each posting.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Balance API"
+                ],
+                "summary": "Get balance ledger",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.LedgerEntryResponse"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/balance/transfer": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Transfer points of the loyalty points account to another user. The sum and the number of transfers per day are limited.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Balance API"
+                ],
+                "summary": "Transfer points",
+                "parameters": [
+                    {
+                        "description": "Recipient login and sum to transfer.",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TransferResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "402": {
+                        "description": "Payment Required"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/balance/transfers": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get the transfers sent (direction out) and received (direction in) by the user, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Balance API"
+                ],
+                "summary": "Get transfers list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.TransferResponse"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/balance/withdraw": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Withdraw points from the loyalty points account to pay for a new order.\nA request repeated with the same Idempotency-Key and body returns the result of the first request without a second debit.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Balance API"
+                ],
+                "summary": "Withdrawal request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unique key of the withdrawal request.",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Order number and withdrawal sum.",
+                        "name": "withdrawal",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.BalanceWithdrawRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "402": {
+                        "description": "Payment Required"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/login": {
+            "post": {
+                "description": "User authorization by login and password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User API"
+                ],
+                "summary": "User authorization",
+                "parameters": [
+                    {
+                        "description": "User login and password.",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/orders": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get a list of order numbers uploaded by the user,\ntheir processing statuses and information about accruals.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order API"
+                ],
+                "summary": "Get uploaded orders",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.OrderResponse"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Loading by the user of the order number.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Order API"
+                ],
+                "summary": "Add new order",
+                "parameters": [
+                    {
+                        "description": "Order number.",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/orders/{number}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get the order uploaded by the user with its full status transition history.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order API"
+                ],
+                "summary": "Get uploaded order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order number.",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderDetailsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/promo": {
+            "post": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Credit the points of the promo code to the user's loyalty points account. The redemption is posted to the ledger with the reason \"promo\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Promo API"
+                ],
+                "summary": "Redeem promo code",
+                "parameters": [
+                    {
+                        "description": "Promo code to redeem.",
+                        "name": "promo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RedeemPromoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RedemptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "410": {
+                        "description": "Gone"
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/referrals": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get the personal referral code of the user and the users registered with it, oldest first. A referral is PENDING until the first order of the referee is processed, then both users are credited the bonus and it becomes CREDITED.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Referral API"
+                ],
+                "summary": "Get user referrals",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReferralsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/register": {
+            "post": {
+                "description": "User registration by login and password. The optional referral code of another user makes the user a referee of its owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User API"
+                ],
+                "summary": "User registration",
+                "parameters": [
+                    {
+                        "description": "User login, password and optional referral code.",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserRegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/withdrawals": {
+            "get": {
+                "security": [
+                    {
+                        "JWT": []
+                    }
+                ],
+                "description": "Get a list of withdrawals from a user's loyalty points account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Balance API"
+                ],
+                "summary": "Get withdrawals list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WithdrawalResponse"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.AccrualHealthResponse": {
+            "type": "object",
+            "properties": {
+                "circuit_breaker": {
+                    "type": "string"
+                },
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "open_until": {
+                    "type": "string"
+                },
+                "rejected_transitions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BalanceDriftResponse": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "number"
+                },
+                "ledger_current": {
+                    "type": "number"
+                },
+                "ledger_withdrawn": {
+                    "type": "number"
+                },
+                "user_login": {
+                    "type": "string"
+                },
+                "withdrawn": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.BalanceResponse": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "number"
+                },
+                "expirations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ExpirationResponse"
+                    }
+                },
+                "held": {
+                    "type": "number"
+                },
+                "multiplier": {
+                    "type": "number"
+                },
+                "tier": {
+                    "type": "string"
+                },
+                "withdrawn": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.BalanceWithdrawRequest": {
+            "type": "object",
+            "required": [
+                "order",
+                "sum"
+            ],
+            "properties": {
+                "order": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.ConsistencyResponse": {
+            "type": "object",
+            "properties": {
+                "consistent": {
+                    "type": "boolean"
+                },
+                "drifts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BalanceDriftResponse"
+                    }
+                },
+                "unbalanced_transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.UnbalancedTransactionResponse"
+                    }
+                }
+            }
+        },
+        "dto.DeadLetteredOrderResponse": {
+            "type": "object",
+            "properties": {
+                "accrual_count": {
+                    "type": "integer"
+                },
+                "dead_lettered_at": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                },
+                "user_login": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ExpirationResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "accrual": {
+                    "$ref": "#/definitions/dto.AccrualHealthResponse"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.HoldRequest": {
+            "type": "object",
+            "required": [
+                "order",
+                "sum"
+            ],
+            "properties": {
+                "order": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.HoldResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.LeasedOrderResponse": {
+            "type": "object",
+            "properties": {
+                "accrual_count": {
+                    "type": "integer"
+                },
+                "expired": {
+                    "type": "boolean"
+                },
+                "lease_age_seconds": {
+                    "type": "integer"
+                },
+                "lease_expires_at": {
+                    "type": "string"
+                },
+                "leased_at": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "user_login": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LedgerEntryResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "balance": {
+                    "type": "number"
+                },
+                "posted_at": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "transaction_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OrderDetailsResponse": {
+            "type": "object",
+            "properties": {
+                "accrual": {
+                    "type": "number"
+                },
+                "accrual_count": {
+                    "type": "integer"
+                },
+                "accrual_finished_at": {
+                    "type": "string"
+                },
+                "accrual_started_at": {
+                    "type": "string"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderStatusHistoryResponse"
+                    }
+                },
+                "number": {
+                    "type": "string"
+                },
+                "raw_accrual": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "accrual": {
+                    "type": "number"
+                },
+                "number": {
+                    "type": "string"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -333,6 +1418,199 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.OrderStatusHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "accrual": {
+                    "type": "number"
+                },
+                "attempt": {
+                    "type": "integer"
+                },
+                "observed_at": {
+                    "type": "string"
+                },
+                "previous_status": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PromoCodeRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "max_redemptions",
+                "sum",
+                "valid_from",
+                "valid_until"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "max_redemptions": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "per_user_limit": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "sum": {
+                    "type": "number"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PromoCodeResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "max_redemptions": {
+                    "type": "integer"
+                },
+                "per_user_limit": {
+                    "type": "integer"
+                },
+                "redemptions": {
+                    "type": "integer"
+                },
+                "sum": {
+                    "type": "number"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RedeemPromoRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RedemptionResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "redeemed_at": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.ReferralResponse": {
+            "type": "object",
+            "properties": {
+                "bonus": {
+                    "type": "number"
+                },
+                "credited_at": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "registered_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ReferralsResponse": {
+            "type": "object",
+            "properties": {
+                "referral_code": {
+                    "type": "string"
+                },
+                "referrals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ReferralResponse"
+                    }
+                }
+            }
+        },
+        "dto.TransferRequest": {
+            "type": "object",
+            "required": [
+                "recipient",
+                "sum"
+            ],
+            "properties": {
+                "recipient": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.TransferResponse": {
+            "type": "object",
+            "properties": {
+                "direction": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "sender": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "number"
+                },
+                "transferred_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.UnbalancedTransactionResponse": {
+            "type": "object",
+            "properties": {
+                "sum": {
+                    "type": "number"
+                },
+                "transaction_id": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.UserLoginRequest": {
             "type": "object",
             "required": [
@@ -360,6 +1638,10 @@ const docTemplate = `{
                 },
                 "password": {
                     "type": "string"
+                },
+                "referral_code": {
+                    "type": "string",
+                    "maxLength": 64
                 }
             }
         },
@@ -372,8 +1654,43 @@ const docTemplate = `{
                 "processed_at": {
                     "type": "string"
                 },
+                "reversed_at": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.WithdrawalReversalRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.WithdrawalReversalResponse": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reversed_at": {
+                    "type": "string"
+                },
                 "sum": {
                     "type": "number"
+                },
+                "user_login": {
+                    "type": "string"
                 }
             }
         }
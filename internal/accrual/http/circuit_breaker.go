@@ -0,0 +1,163 @@
+package http
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+)
+
+// probeRetryAfter is how long requests rejected in the half-open state wait for the probes to finish.
+const probeRetryAfter = time.Second
+
+type BreakerState string
+
+const (
+	StateClosed   BreakerState = "closed"
+	StateOpen     BreakerState = "open"
+	StateHalfOpen BreakerState = "half-open"
+)
+
+type BreakerConfig struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	HalfOpenRequests int
+}
+
+type BreakerSnapshot struct {
+	State     BreakerState
+	Failures  int
+	OpenUntil time.Time
+}
+
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	config    BreakerConfig
+	state     BreakerState
+	failures  int
+	probes    int
+	successes int
+	openUntil time.Time
+	now       func() time.Time
+	logger    *zap.Logger
+}
+
+func NewCircuitBreaker(config BreakerConfig, logger *zap.Logger) *CircuitBreaker {
+	config.FailureThreshold = max(config.FailureThreshold, 1)
+	config.HalfOpenRequests = max(config.HalfOpenRequests, 1)
+
+	return &CircuitBreaker{
+		config: config,
+		state:  StateClosed,
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+// Allow reports whether a request may be sent to the accrual system.
+// Once the open timeout has passed the breaker lets HalfOpenRequests probes through,
+// the rest are rejected until the probes report back.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+
+	if b.state == StateOpen {
+		if now.Before(b.openUntil) {
+			return apperrors.NewCircuitOpenError(b.openUntil.Sub(now))
+		}
+		b.transition(StateHalfOpen)
+	}
+
+	if b.state == StateHalfOpen {
+		if b.probes >= b.config.HalfOpenRequests {
+			return apperrors.NewCircuitOpenError(probeRetryAfter)
+		}
+		b.probes++
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateHalfOpen:
+		b.successes++
+		if b.successes >= b.config.HalfOpenRequests {
+			b.transition(StateClosed)
+		}
+	case StateClosed:
+		b.failures = 0
+	}
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateHalfOpen:
+		b.trip()
+	case StateClosed:
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.trip()
+		}
+	}
+}
+
+// Cancel gives back the probe of a request abandoned by the caller: the cancellation tells nothing
+// about the accrual system, so it counts neither as a success nor as a failure.
+func (b *CircuitBreaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return BreakerSnapshot{
+		State:     b.state,
+		Failures:  b.failures,
+		OpenUntil: b.openUntil,
+	}
+}
+
+func (b *CircuitBreaker) trip() {
+	b.openUntil = b.now().Add(b.config.OpenTimeout)
+	b.transition(StateOpen)
+}
+
+func (b *CircuitBreaker) transition(state BreakerState) {
+	from := b.state
+	b.state = state
+	b.probes = 0
+	b.successes = 0
+
+	fields := []zap.Field{
+		zap.String("from", string(from)),
+		zap.String("to", string(state)),
+		zap.Int("failures", b.failures),
+	}
+
+	switch state {
+	case StateOpen:
+		b.logger.Warn("accrual circuit breaker opened", append(fields, zap.Time("until", b.openUntil))...)
+	case StateHalfOpen:
+		b.logger.Info("accrual circuit breaker half-open", fields...)
+	case StateClosed:
+		b.failures = 0
+		b.logger.Info("accrual circuit breaker closed", fields...)
+	}
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+)
+
+func newTestBreaker(now *time.Time) *CircuitBreaker {
+	breaker := NewCircuitBreaker(BreakerConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenRequests: 1,
+	}, zap.NewNop())
+	breaker.now = func() time.Time { return *now }
+
+	return breaker
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	now := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)
+	breaker := newTestBreaker(&now)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, breaker.Allow())
+		breaker.Failure()
+	}
+	require.NoError(t, breaker.Allow())
+	breaker.Success()
+	assert.Equal(t, 0, breaker.Snapshot().Failures)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, breaker.Allow())
+		breaker.Failure()
+	}
+	assert.Equal(t, StateOpen, breaker.Snapshot().State)
+
+	now = now.Add(10 * time.Second)
+	err := breaker.Allow()
+	assert.True(t, errors.Is(err, apperrors.ErrCircuitOpen))
+
+	var circuitOpenErr *apperrors.CircuitOpenError
+	require.True(t, errors.As(err, &circuitOpenErr))
+	assert.Equal(t, 20*time.Second, circuitOpenErr.RetryAfter)
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	testCases := []struct {
+		name          string
+		probeSucceeds bool
+		expectedState BreakerState
+	}{
+		{
+			name:          "Probe succeeds - closed",
+			probeSucceeds: true,
+			expectedState: StateClosed,
+		},
+		{
+			name:          "Probe fails - open",
+			probeSucceeds: false,
+			expectedState: StateOpen,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)
+			breaker := newTestBreaker(&now)
+			for i := 0; i < 3; i++ {
+				breaker.Failure()
+			}
+
+			now = now.Add(30 * time.Second)
+			require.NoError(t, breaker.Allow())
+			assert.Equal(t, StateHalfOpen, breaker.Snapshot().State)
+			assert.True(t, errors.Is(breaker.Allow(), apperrors.ErrCircuitOpen))
+
+			if test.probeSucceeds {
+				breaker.Success()
+			} else {
+				breaker.Failure()
+			}
+			assert.Equal(t, test.expectedState, breaker.Snapshot().State)
+		})
+	}
+}
+
+func TestCircuitBreakerCancelReturnsProbe(t *testing.T) {
+	now := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)
+	breaker := newTestBreaker(&now)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, breaker.Allow())
+		breaker.Failure()
+	}
+	now = now.Add(30 * time.Second)
+
+	require.NoError(t, breaker.Allow())
+	assert.True(t, errors.Is(breaker.Allow(), apperrors.ErrCircuitOpen))
+	breaker.Cancel()
+	assert.Equal(t, StateHalfOpen, breaker.Snapshot().State)
+
+	require.NoError(t, breaker.Allow(), "cancelled probe must be given back")
+	breaker.Success()
+	assert.Equal(t, StateClosed, breaker.Snapshot().State)
+}
@@ -0,0 +1,11 @@
+package dto
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+type OrderAccrualResponse struct {
+	Order   string          `json:"order"`
+	Status  string          `json:"status"`
+	Accrual decimal.Decimal `json:"accrual"`
+}
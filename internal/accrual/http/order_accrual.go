@@ -1,44 +1,158 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"go.uber.org/zap"
 
+	"github.com/msmkdenis/yap-gophermart/internal/accrual/http/dto"
 	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
 	"github.com/msmkdenis/yap-gophermart/internal/order/model"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
 )
 
+const defaultRetryAfter = 60 * time.Second
+
+var requestsPerMinute = regexp.MustCompile(`No more than (\d+) requests per minute allowed`)
+
+var accrualStatuses = map[string]bool{
+	model.StatusRegistered: true,
+	model.StatusInvalid:    true,
+	model.StatusProcessing: true,
+	model.StatusProcessed:  true,
+}
+
 type OrderAccrual struct {
 	*resty.Client
-	logger *zap.Logger
+	breaker *CircuitBreaker
+	logger  *zap.Logger
 }
 
-func NewOrderAccrual(accrualEndpoint string, logger *zap.Logger) *OrderAccrual {
-	orderAccrual := &OrderAccrual{resty.New(), logger}
+func NewOrderAccrual(accrualEndpoint string, timeout time.Duration, breaker *CircuitBreaker, logger *zap.Logger) *OrderAccrual {
+	orderAccrual := &OrderAccrual{resty.New(), breaker, logger}
 	orderAccrual.SetBaseURL(accrualEndpoint)
+	orderAccrual.SetTimeout(timeout)
 
 	return orderAccrual
 }
 
-func (o *OrderAccrual) QueryUpdateOrder(orderNumber string) (*model.Order, error) {
-	var order model.Order
-	r, err := o.R().SetResult(&order).Get("/api/orders/" + orderNumber)
-	if err != nil {
-		o.logger.Error("error while processing http", zap.Error(err))
+func (o *OrderAccrual) QueryUpdateOrder(ctx context.Context, orderNumber string) (*dto.OrderAccrualResponse, error) {
+	if err := o.breaker.Allow(); err != nil {
 		return nil, err
 	}
 
-	if r.StatusCode() == http.StatusNoContent {
-		o.logger.Info("error while processing http", zap.Error(err))
-		return nil, apperrors.ErrOrderNotFound
+	r, err := o.R().SetContext(ctx).Get("/api/orders/" + orderNumber)
+	if err != nil && ctx.Err() != nil {
+		o.breaker.Cancel()
+		return nil, apperrors.NewValueError("accrual request cancelled", utils.Caller(), err)
+	}
+	if err != nil {
+		o.breaker.Failure()
+		o.logger.Error("error while processing http", zap.String("order", orderNumber), zap.Error(err))
+		return nil, apperrors.NewValueError("accrual request failed", utils.Caller(), err)
+	}
+
+	switch {
+	case r.StatusCode() == http.StatusOK:
+		order, errDecode := decodeOrderAccrual(r.Body(), orderNumber)
+		if errDecode != nil {
+			o.breaker.Failure()
+			o.logger.Error("invalid accrual system response", zap.String("order", orderNumber), zap.Error(errDecode))
+			return nil, errDecode
+		}
+		o.breaker.Success()
+		return order, nil
+
+	case r.StatusCode() == http.StatusNoContent:
+		o.breaker.Success()
+		o.logger.Info("order not registered in accrual system", zap.String("order", orderNumber))
+		return nil, apperrors.NewAccrualResponseError(r.StatusCode(), "no content", apperrors.ErrAccrualOrderNotRegistered)
+
+	case r.StatusCode() == http.StatusTooManyRequests:
+		o.breaker.Success()
+		rateLimitErr := apperrors.NewRateLimitError(
+			parseRetryAfter(r.Header().Get("Retry-After"), time.Now()),
+			parseRequestsPerMinute(r.String()),
+		)
+		o.logger.Warn("accrual system rate limit exceeded", zap.Error(rateLimitErr))
+		return nil, rateLimitErr
+
+	case r.StatusCode() >= http.StatusInternalServerError:
+		o.breaker.Failure()
+		o.logger.Error("accrual system responded with server error", zap.String("order", orderNumber), zap.Int("status", r.StatusCode()))
+		return nil, apperrors.NewAccrualResponseError(r.StatusCode(), r.Status(), apperrors.ErrAccrualUnavailable)
+
+	default:
+		o.breaker.Success()
+		o.logger.Error("unexpected accrual system response", zap.String("order", orderNumber), zap.Int("status", r.StatusCode()))
+		return nil, apperrors.NewAccrualResponseError(r.StatusCode(), r.Status(), apperrors.ErrAccrualUnexpectedStatus)
+	}
+}
+
+// decodeOrderAccrual rejects responses that do not describe the requested order or carry a status the worker cannot apply.
+func decodeOrderAccrual(body []byte, orderNumber string) (*dto.OrderAccrualResponse, error) {
+	var order dto.OrderAccrualResponse
+	if err := json.Unmarshal(body, &order); err != nil {
+		return nil, apperrors.NewAccrualResponseError(http.StatusOK, err.Error(), apperrors.ErrAccrualInvalidResponse)
+	}
+
+	if order.Order != orderNumber {
+		return nil, apperrors.NewAccrualResponseError(http.StatusOK, fmt.Sprintf("order number %q does not match %q", order.Order, orderNumber), apperrors.ErrAccrualInvalidResponse)
 	}
 
-	if r.StatusCode() == http.StatusTooManyRequests {
-		o.logger.Error("error while processing http", zap.Error(err))
-		return nil, apperrors.ErrRateLimit
+	if !accrualStatuses[order.Status] {
+		return nil, apperrors.NewAccrualResponseError(http.StatusOK, fmt.Sprintf("unknown status %q", order.Status), apperrors.ErrAccrualInvalidResponse)
+	}
+
+	if order.Accrual.IsNegative() {
+		return nil, apperrors.NewAccrualResponseError(http.StatusOK, fmt.Sprintf("negative accrual %s", order.Accrual), apperrors.ErrAccrualInvalidResponse)
 	}
 
 	return &order, nil
 }
+
+// parseRetryAfter supports both the delay-seconds and the HTTP-date forms of the Retry-After header.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultRetryAfter
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return defaultRetryAfter
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+		return 0
+	}
+
+	return defaultRetryAfter
+}
+
+func parseRequestsPerMinute(body string) int {
+	match := requestsPerMinute.FindStringSubmatch(body)
+	if match == nil {
+		return 0
+	}
+
+	n, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
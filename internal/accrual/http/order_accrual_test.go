@@ -0,0 +1,200 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/accrual/http/dto"
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+)
+
+func TestQueryUpdateOrder(t *testing.T) {
+	orderNumber := "12345678903"
+
+	testCases := []struct {
+		name          string
+		statusCode    int
+		body          string
+		delay         time.Duration
+		expectedOrder *dto.OrderAccrualResponse
+		expectedErr   error
+	}{
+		{
+			name:       "Processed - 200",
+			statusCode: http.StatusOK,
+			body:       `{"order":"12345678903","status":"PROCESSED","accrual":500.5}`,
+			expectedOrder: &dto.OrderAccrualResponse{
+				Order:   orderNumber,
+				Status:  "PROCESSED",
+				Accrual: decimal.NewFromFloat(500.5),
+			},
+		},
+		{
+			name:       "Registered without accrual - 200",
+			statusCode: http.StatusOK,
+			body:       `{"order":"12345678903","status":"REGISTERED"}`,
+			expectedOrder: &dto.OrderAccrualResponse{
+				Order:  orderNumber,
+				Status: "REGISTERED",
+			},
+		},
+		{
+			name:        "Order number mismatch - 200",
+			statusCode:  http.StatusOK,
+			body:        `{"order":"79927398713","status":"PROCESSED","accrual":10}`,
+			expectedErr: apperrors.ErrAccrualInvalidResponse,
+		},
+		{
+			name:        "Unknown status - 200",
+			statusCode:  http.StatusOK,
+			body:        `{"order":"12345678903","status":"DONE"}`,
+			expectedErr: apperrors.ErrAccrualInvalidResponse,
+		},
+		{
+			name:        "Malformed body - 200",
+			statusCode:  http.StatusOK,
+			body:        `{"order":`,
+			expectedErr: apperrors.ErrAccrualInvalidResponse,
+		},
+		{
+			name:        "Not registered - 204",
+			statusCode:  http.StatusNoContent,
+			expectedErr: apperrors.ErrAccrualOrderNotRegistered,
+		},
+		{
+			name:        "Server error - 500",
+			statusCode:  http.StatusInternalServerError,
+			body:        `{"order":"12345678903","status":"PROCESSED","accrual":10}`,
+			expectedErr: apperrors.ErrAccrualUnavailable,
+		},
+		{
+			name:        "Unexpected status - 404",
+			statusCode:  http.StatusNotFound,
+			expectedErr: apperrors.ErrAccrualUnexpectedStatus,
+		},
+		{
+			name:        "Timeout",
+			statusCode:  http.StatusOK,
+			delay:       200 * time.Millisecond,
+			body:        `{"order":"12345678903","status":"PROCESSED","accrual":10}`,
+			expectedErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(test.delay)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			orderAccrual := NewOrderAccrual(server.URL, 100*time.Millisecond, NewCircuitBreaker(BreakerConfig{}, zap.NewNop()), zap.NewNop())
+			order, err := orderAccrual.QueryUpdateOrder(context.Background(), orderNumber)
+
+			if test.expectedErr != nil {
+				assert.Nil(t, order)
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOrder.Order, order.Order)
+			assert.Equal(t, test.expectedOrder.Status, order.Status)
+			assert.True(t, test.expectedOrder.Accrual.Equal(order.Accrual))
+		})
+	}
+}
+
+func TestQueryUpdateOrderRateLimit(t *testing.T) {
+	testCases := []struct {
+		name                      string
+		retryAfter                string
+		body                      string
+		expectedRetryAfter        time.Duration
+		expectedRequestsPerMinute int
+	}{
+		{
+			name:                      "Retry-After in seconds",
+			retryAfter:                "60",
+			body:                      "No more than 120 requests per minute allowed",
+			expectedRetryAfter:        60 * time.Second,
+			expectedRequestsPerMinute: 120,
+		},
+		{
+			name:                      "Retry-After missing",
+			body:                      "Too Many Requests",
+			expectedRetryAfter:        defaultRetryAfter,
+			expectedRequestsPerMinute: 0,
+		},
+		{
+			name:                      "Retry-After malformed",
+			retryAfter:                "soon",
+			body:                      "No more than 5 requests per minute allowed",
+			expectedRetryAfter:        defaultRetryAfter,
+			expectedRequestsPerMinute: 5,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				if test.retryAfter != "" {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			orderAccrual := NewOrderAccrual(server.URL, time.Second, NewCircuitBreaker(BreakerConfig{}, zap.NewNop()), zap.NewNop())
+			order, err := orderAccrual.QueryUpdateOrder(context.Background(), "12345678903")
+			assert.Nil(t, order)
+			assert.True(t, errors.Is(err, apperrors.ErrRateLimit))
+
+			var rateLimitErr *apperrors.RateLimitError
+			require.True(t, errors.As(err, &rateLimitErr))
+			assert.Equal(t, test.expectedRetryAfter, rateLimitErr.RetryAfter)
+			assert.Equal(t, test.expectedRequestsPerMinute, rateLimitErr.RequestsPerMinute)
+		})
+	}
+}
+
+func TestParseRetryAfterHTTPDate(t *testing.T) {
+	now := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
+
+func TestQueryUpdateOrderCancelledSkipsBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute}, zap.NewNop())
+	orderAccrual := NewOrderAccrual(server.URL, time.Second, breaker, zap.NewNop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	order, err := orderAccrual.QueryUpdateOrder(ctx, "12345678903")
+	assert.Nil(t, order)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+
+	snapshot := breaker.Snapshot()
+	assert.Equal(t, StateClosed, snapshot.State, "shutdown of the worker must not open the breaker")
+	assert.Equal(t, 0, snapshot.Failures)
+}
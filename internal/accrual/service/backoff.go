@@ -0,0 +1,31 @@
+package service
+
+import (
+	"math/rand"
+	"time"
+)
+
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Next returns the delay before the given attempt: the exponential delay capped by Max,
+// of which the upper half is randomized to spread retries of orders that failed together.
+func (b Backoff) Next(attempt int) time.Duration {
+	if attempt < 1 || b.Base <= 0 {
+		return 0
+	}
+
+	delay := b.Max
+	if shift := attempt - 1; shift < 63 && b.Base <= b.Max>>shift {
+		delay = b.Base << shift
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+
+	return half + time.Duration(rand.Int63n(int64(half)+1)) //nolint:gosec // jitter does not need a secure source
+}
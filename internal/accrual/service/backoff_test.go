@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffNext(t *testing.T) {
+	backoff := Backoff{Base: time.Second, Max: time.Minute}
+
+	testCases := []struct {
+		name        string
+		attempt     int
+		expectedMin time.Duration
+		expectedMax time.Duration
+	}{
+		{name: "No attempts", attempt: 0, expectedMin: 0, expectedMax: 0},
+		{name: "First attempt", attempt: 1, expectedMin: 500 * time.Millisecond, expectedMax: time.Second},
+		{name: "Fourth attempt", attempt: 4, expectedMin: 4 * time.Second, expectedMax: 8 * time.Second},
+		{name: "Capped by max", attempt: 10, expectedMin: 30 * time.Second, expectedMax: time.Minute},
+		{name: "Shift overflow", attempt: 100, expectedMin: 30 * time.Second, expectedMax: time.Minute},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				delay := backoff.Next(test.attempt)
+				assert.GreaterOrEqual(t, delay, test.expectedMin)
+				assert.LessOrEqual(t, delay, test.expectedMax)
+			}
+		})
+	}
+}
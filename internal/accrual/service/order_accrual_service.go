@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/avito-tech/go-transaction-manager/pgxv5"
@@ -14,108 +15,444 @@ import (
 	"go.uber.org/ratelimit"
 	"go.uber.org/zap"
 
+	"github.com/msmkdenis/yap-gophermart/internal/accrual/http/dto"
 	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	balanceModel "github.com/msmkdenis/yap-gophermart/internal/balance/model"
 	"github.com/msmkdenis/yap-gophermart/internal/order/model"
 )
 
+const releaseTimeout = 5 * time.Second
+
 type OrderRepository interface {
-	SelectTenOrders(ctx context.Context) ([]model.Order, error)
-	UpdateOrder(ctx context.Context, order model.Order) error
+	ClaimOrders(ctx context.Context, limit int, leaseTimeout time.Duration) ([]model.Order, error)
+	UpdateOrder(ctx context.Context, order model.Order, previousStatus string, retryDelay time.Duration) error
+	ReleaseOrder(ctx context.Context, orderNumber string) error
+	RetryOrder(ctx context.Context, orderNumber string, retryDelay time.Duration) error
+	DeadLetterOrder(ctx context.Context, orderNumber string) error
 }
 
 type BalanceRepository interface {
-	UpdateBalance(ctx context.Context, userLogin string, amount decimal.Decimal) error
+	SelectAccrualMultiplier(ctx context.Context, userLogin string) (decimal.Decimal, error)
+	CreditAccrual(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal) error
+	BlockBalances(ctx context.Context, firstLogin string, secondLogin string) error
+	UpdateBalance(ctx context.Context, userLogin string, amount decimal.Decimal, reason string, reference string) error
+}
+
+type ReferralRepository interface {
+	Credit(ctx context.Context, refereeLogin string, bonus decimal.Decimal) (string, error)
 }
 
 type OrderQueryAccrual interface {
-	QueryUpdateOrder(orderNumber string) (*model.Order, error)
+	QueryUpdateOrder(ctx context.Context, orderNumber string) (*dto.OrderAccrualResponse, error)
+}
+
+type WorkerConfig struct {
+	Workers           int
+	BatchSize         int
+	PollInterval      time.Duration
+	RequestsPerSecond int
+	LeaseTimeout      time.Duration
+	Backoff           Backoff
+	MaxAttempts       int
+	// ReferralBonus is credited to the referee and the referrer once the first order of the referee is processed.
+	ReferralBonus decimal.Decimal
 }
 
 type OrderAccrualUseCase struct {
-	orderRepository   OrderRepository
-	balanceRepository BalanceRepository
-	queryAccrual      OrderQueryAccrual
-	logger            *zap.Logger
-	trManager         *manager.Manager
+	orderRepository    OrderRepository
+	balanceRepository  BalanceRepository
+	referralRepository ReferralRepository
+	queryAccrual       OrderQueryAccrual
+	logger             *zap.Logger
+	trManager          *manager.Manager
+	config             WorkerConfig
+	mu                 sync.RWMutex
+	limiter            ratelimit.Limiter
+	requestsPerMinute  int
+	pausedUntil        time.Time
+	rejected           atomic.Int64
+	cancelClaims       context.CancelFunc
+	cancelInFlight     context.CancelFunc
+	done               chan struct{}
 }
 
 func NewOrderAccrualService(
 	repository OrderRepository,
 	balanceRepository BalanceRepository,
+	referralRepository ReferralRepository,
 	queryAccrual OrderQueryAccrual,
 	logger *zap.Logger,
 	trManager *manager.Manager,
+	config WorkerConfig,
 ) *OrderAccrualUseCase {
+	config.Workers = max(config.Workers, 1)
+	config.BatchSize = max(config.BatchSize, 1)
+
+	limiter := ratelimit.NewUnlimited()
+	if config.RequestsPerSecond > 0 {
+		limiter = ratelimit.New(config.RequestsPerSecond)
+	}
+
 	return &OrderAccrualUseCase{
-		orderRepository:   repository,
-		balanceRepository: balanceRepository,
-		queryAccrual:      queryAccrual,
-		logger:            logger,
-		trManager:         trManager,
-	}
-}
-
-func (oc *OrderAccrualUseCase) Run() {
-	go func() {
-		for {
-			time.Sleep(300 * time.Millisecond)
-			tenOrders, err := oc.orderRepository.SelectTenOrders(context.Background())
-			if err != nil {
-				//oc.logger.Error("failed to select ten orders", zap.Error(err))
-				continue
-			}
+		orderRepository:    repository,
+		balanceRepository:  balanceRepository,
+		referralRepository: referralRepository,
+		queryAccrual:       queryAccrual,
+		logger:             logger,
+		trManager:          trManager,
+		config:             config,
+		limiter:            limiter,
+	}
+}
+
+// Start launches accrual polling. Claiming stops when ctx is cancelled or Stop is called,
+// orders already sent to the accrual system are processed until Stop's deadline.
+func (oc *OrderAccrualUseCase) Start(ctx context.Context) {
+	claimCtx, cancelClaims := context.WithCancel(ctx)
+	inFlightCtx, cancelInFlight := context.WithCancel(context.WithoutCancel(ctx))
+
+	oc.cancelClaims = cancelClaims
+	oc.cancelInFlight = cancelInFlight
+	oc.done = make(chan struct{})
+
+	go oc.run(claimCtx, inFlightCtx)
+}
+
+// Stop stops claiming new orders and waits for in-flight orders to be processed.
+// If ctx expires first, in-flight processing is cancelled and its transactions are rolled back.
+// Claimed orders that were not processed are released in both cases.
+func (oc *OrderAccrualUseCase) Stop(ctx context.Context) error {
+	oc.cancelClaims()
+
+	select {
+	case <-oc.done:
+		oc.cancelInFlight()
+		oc.logger.Info("accrual worker stopped")
+		return nil
+	case <-ctx.Done():
+		oc.cancelInFlight()
+		<-oc.done
+		oc.logger.Warn("accrual worker stopped before in-flight orders were drained", zap.Error(ctx.Err()))
+		return ctx.Err()
+	}
+}
+
+func (oc *OrderAccrualUseCase) run(claimCtx context.Context, inFlightCtx context.Context) {
+	defer close(oc.done)
+
+	orders := make(chan model.Order)
 
-			var wg sync.WaitGroup
-			limiter := ratelimit.New(10)
-			for _, order := range tenOrders {
-				wg.Add(1)
+	var wg sync.WaitGroup
+	for i := 0; i < oc.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for order := range orders {
 				orderToSend := order
-				go oc.updateOrderBalance(&orderToSend, limiter, &wg)
+				oc.updateOrderBalance(claimCtx, inFlightCtx, &orderToSend)
 			}
+		}()
+	}
 
-			wg.Wait()
-		}
-	}()
+	oc.dispatch(claimCtx, orders)
+	close(orders)
+	wg.Wait()
 }
 
-func (oc *OrderAccrualUseCase) updateOrderBalance(order *model.Order, rl ratelimit.Limiter, wg *sync.WaitGroup) {
-	defer func() { wg.Done() }()
+// dispatch keeps the workers fed: a new batch is claimed as soon as the previous one has been taken by the workers,
+// the database is polled every PollInterval only while there is nothing to process.
+func (oc *OrderAccrualUseCase) dispatch(ctx context.Context, orders chan<- model.Order) {
+	for {
+		if err := oc.waitPause(ctx); err != nil {
+			return
+		}
+
+		batch, err := oc.orderRepository.ClaimOrders(ctx, oc.config.BatchSize, oc.config.LeaseTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if !errors.Is(err, apperrors.ErrNoOrders) {
+				oc.logger.Error("failed to claim orders", zap.Error(err))
+			}
 
-	rl.Take()
-	updatedOrder, err := oc.queryAccrual.QueryUpdateOrder(order.Number)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(oc.config.PollInterval):
+			}
+			continue
+		}
 
-	if errors.Is(err, apperrors.ErrRateLimit) {
-		for i := 0; i < 6000; i++ {
-			rl.Take()
+		for i, order := range batch {
+			select {
+			case orders <- order:
+			case <-ctx.Done():
+				for _, unsent := range batch[i:] {
+					oc.releaseOrder(unsent.Number)
+				}
+				return
+			}
 		}
 	}
+}
+
+func (oc *OrderAccrualUseCase) updateOrderBalance(claimCtx context.Context, ctx context.Context, order *model.Order) {
+	if err := oc.take(claimCtx); err != nil {
+		oc.releaseOrder(order.Number)
+		return
+	}
+
+	updatedOrder, err := oc.queryAccrual.QueryUpdateOrder(ctx, order.Number)
+
+	var rateLimitErr *apperrors.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		oc.pauseRateLimited(rateLimitErr)
+		oc.releaseOrder(order.Number)
+		return
+	}
+
+	var circuitOpenErr *apperrors.CircuitOpenError
+	if errors.As(err, &circuitOpenErr) {
+		oc.pause(circuitOpenErr.RetryAfter, "accrual circuit breaker is open")
+		oc.releaseOrder(order.Number)
+		return
+	}
+
+	if err != nil {
+		oc.retryOrder(ctx, order)
+		return
+	}
+
+	if !model.CanTransition(order.Status, updatedOrder.Status) {
+		oc.rejectTransition(order.Number, order.Status, updatedOrder.Status)
+		oc.retryOrder(ctx, order)
+		return
+	}
+
+	previousStatus := order.Status
+	order.RawAccrual = updatedOrder.Accrual
+	order.Accrual = updatedOrder.Accrual
+	order.Status = updatedOrder.Status
 
-	if err == nil {
-		order.Accrual = updatedOrder.Accrual
-		order.Status = updatedOrder.Status
+	s := pgxv5.MustSettings(
+		settings.Must(settings.WithCancelable(true)),
+		pgxv5.WithTxOptions(pgx.TxOptions{IsoLevel: pgx.RepeatableRead}),
+	)
 
-		s := pgxv5.MustSettings(
-			settings.Must(settings.WithCancelable(true)),
-			pgxv5.WithTxOptions(pgx.TxOptions{IsoLevel: pgx.RepeatableRead}),
-		)
+	attempt := order.AccrualCount + 1
+	errTransaction := oc.trManager.DoWithSettings(ctx, s, func(ctx context.Context) error {
+		if order.Status == model.StatusProcessed && order.RawAccrual.IsPositive() {
+			multiplier, errMultiplier := oc.balanceRepository.SelectAccrualMultiplier(ctx, order.UserLogin)
+			if errMultiplier != nil {
+				oc.logger.Error("error while selecting accrual multiplier", zap.Error(errMultiplier))
+				return errMultiplier
+			}
+			order.Accrual = order.RawAccrual.Mul(multiplier).Round(2)
+		}
+
+		errOrderUpdate := oc.orderRepository.UpdateOrder(ctx, *order, previousStatus, oc.config.Backoff.Next(attempt))
+		if errors.Is(errOrderUpdate, apperrors.ErrOrderStatusConflict) {
+			oc.logger.Info("order status changed concurrently, skipping update", zap.String("order", order.Number))
+			return nil
+		}
+		if errors.Is(errOrderUpdate, apperrors.ErrIllegalStatusTransition) {
+			oc.rejectTransition(order.Number, previousStatus, order.Status)
+			return errOrderUpdate
+		}
+		if errOrderUpdate != nil {
+			oc.logger.Error("error while updating order", zap.Error(errOrderUpdate))
+			return errOrderUpdate
+		}
 
-		errTransaction := oc.trManager.DoWithSettings(context.TODO(), s, func(ctx context.Context) error {
-			errOrderUpdate := oc.orderRepository.UpdateOrder(ctx, *order)
-			if errOrderUpdate != nil {
-				oc.logger.Error("error while updating order", zap.Error(err))
-				return errOrderUpdate
+		// the referral is credited first: it locks both balances in the order of the logins,
+		// before the accrual credit locks the balance of the referee alone
+		if order.Status == model.StatusProcessed && oc.config.ReferralBonus.IsPositive() {
+			if errReferral := oc.creditReferral(ctx, order.UserLogin); errReferral != nil {
+				oc.logger.Error("error while crediting referral bonus", zap.Error(errReferral))
+				return errReferral
 			}
+		}
 
-			errBalanceUpdate := oc.balanceRepository.UpdateBalance(ctx, order.UserLogin, order.Accrual)
-			if errBalanceUpdate != nil {
-				oc.logger.Error("error while updating balance", zap.Error(err))
-				return errBalanceUpdate
+		if order.Status == model.StatusProcessed && order.Accrual.IsPositive() {
+			errCredit := oc.balanceRepository.CreditAccrual(ctx, order.Number, order.UserLogin, order.Accrual)
+			if errors.Is(errCredit, apperrors.ErrAccrualAlreadyCredited) {
+				oc.logger.Warn("accrual already credited, skipping credit", zap.String("order", order.Number))
+			} else if errCredit != nil {
+				oc.logger.Error("error while crediting accrual", zap.Error(errCredit))
+				return errCredit
 			}
+		}
+
+		if !model.IsFinal(order.Status) && oc.exhausted(attempt) {
+			return oc.deadLetterOrder(ctx, order.Number, attempt)
+		}
+		return nil
+	})
+
+	if errTransaction != nil {
+		oc.logger.Error("error while updating order balance in transaction", zap.Error(errTransaction))
+		oc.retryOrder(ctx, order)
+	}
+}
+
+// retryOrder counts the failed attempt and schedules the next one, moving the order
+// to the dead letter once the attempts are exhausted. Attempts cut off by shutdown are not counted.
+func (oc *OrderAccrualUseCase) retryOrder(ctx context.Context, order *model.Order) {
+	if ctx.Err() != nil {
+		oc.releaseOrder(order.Number)
+		return
+	}
+
+	attempt := order.AccrualCount + 1
+	err := oc.trManager.Do(ctx, func(ctx context.Context) error {
+		if err := oc.orderRepository.RetryOrder(ctx, order.Number, oc.config.Backoff.Next(attempt)); err != nil {
+			return err
+		}
+
+		if oc.exhausted(attempt) {
+			return oc.deadLetterOrder(ctx, order.Number, attempt)
+		}
+		return nil
+	})
+	if err != nil {
+		oc.logger.Error("error while scheduling order retry", zap.String("order", order.Number), zap.Error(err))
+	}
+}
+
+// creditReferral credits the referral bonus to the referee and the referrer on the first processed order of the referee.
+// Both balances are locked in the order of the logins, the same order transfers lock them in.
+func (oc *OrderAccrualUseCase) creditReferral(ctx context.Context, refereeLogin string) error {
+	referrerLogin, err := oc.referralRepository.Credit(ctx, refereeLogin, oc.config.ReferralBonus)
+	if errors.Is(err, apperrors.ErrNoPendingReferral) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = oc.balanceRepository.BlockBalances(ctx, refereeLogin, referrerLogin); err != nil {
+		return err
+	}
+
+	bonus := oc.config.ReferralBonus
+	if err = oc.balanceRepository.UpdateBalance(ctx, refereeLogin, bonus, balanceModel.ReasonReferral, referrerLogin); err != nil {
+		return err
+	}
+	if err = oc.balanceRepository.UpdateBalance(ctx, referrerLogin, bonus, balanceModel.ReasonReferral, refereeLogin); err != nil {
+		return err
+	}
+
+	oc.logger.Info("referral bonus credited", zap.String("referee", refereeLogin), zap.String("referrer", referrerLogin))
+	return nil
+}
+
+func (oc *OrderAccrualUseCase) deadLetterOrder(ctx context.Context, orderNumber string, attempt int) error {
+	if err := oc.orderRepository.DeadLetterOrder(ctx, orderNumber); err != nil {
+		return err
+	}
+
+	oc.logger.Warn("order moved to dead letter", zap.String("order", orderNumber), zap.Int("attempts", attempt))
+	return nil
+}
+
+func (oc *OrderAccrualUseCase) exhausted(attempt int) bool {
+	return oc.config.MaxAttempts > 0 && attempt >= oc.config.MaxAttempts
+}
+
+// RejectedTransitions returns the number of accrual responses rejected by the order status state machine.
+func (oc *OrderAccrualUseCase) RejectedTransitions() int64 {
+	return oc.rejected.Load()
+}
+
+func (oc *OrderAccrualUseCase) rejectTransition(orderNumber string, from string, to string) {
+	rejected := oc.rejected.Add(1)
+	oc.logger.Warn("illegal order status transition rejected",
+		zap.String("order", orderNumber),
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int64("rejected_total", rejected),
+	)
+}
+
+// releaseOrder returns the claim on the order so that it is picked up again without waiting for the lease to expire.
+// It is also used on shutdown, so it does not depend on the worker contexts.
+func (oc *OrderAccrualUseCase) releaseOrder(orderNumber string) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	if err := oc.orderRepository.ReleaseOrder(ctx, orderNumber); err != nil {
+		oc.logger.Error("error while releasing order", zap.String("order", orderNumber), zap.Error(err))
+	}
+}
+
+// take blocks until the accrual system may be queried again: the global pause set by 429 responses
+// or by the open circuit breaker has passed and the limiter has granted a slot.
+func (oc *OrderAccrualUseCase) take(ctx context.Context) error {
+	if err := oc.waitPause(ctx); err != nil {
+		return err
+	}
+
+	oc.mu.RLock()
+	limiter := oc.limiter
+	oc.mu.RUnlock()
+
+	// the limiter does not accept a context: the slot is not taken once the worker is stopping,
+	// and a slot granted during shutdown is not used
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	limiter.Take()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return oc.waitPause(ctx)
+}
+
+func (oc *OrderAccrualUseCase) waitPause(ctx context.Context) error {
+	for {
+		oc.mu.RLock()
+		delay := time.Until(oc.pausedUntil)
+		oc.mu.RUnlock()
+
+		if delay <= 0 {
 			return nil
-		})
+		}
 
-		if errTransaction != nil {
-			oc.logger.Error("error while updating order balance in transaction", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
 		}
 	}
 }
+
+func (oc *OrderAccrualUseCase) pause(delay time.Duration, reason string) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	oc.extendPause(delay, reason)
+}
+
+func (oc *OrderAccrualUseCase) extendPause(delay time.Duration, reason string) {
+	pausedUntil := time.Now().Add(delay)
+	if pausedUntil.After(oc.pausedUntil) {
+		oc.pausedUntil = pausedUntil
+		oc.logger.Warn("accrual polling paused", zap.Time("until", pausedUntil), zap.String("reason", reason))
+	}
+}
+
+func (oc *OrderAccrualUseCase) pauseRateLimited(rateLimitErr *apperrors.RateLimitError) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	oc.extendPause(rateLimitErr.RetryAfter, "accrual system rate limit exceeded")
+
+	if rateLimitErr.RequestsPerMinute > 0 && rateLimitErr.RequestsPerMinute != oc.requestsPerMinute {
+		oc.requestsPerMinute = rateLimitErr.RequestsPerMinute
+		oc.limiter = ratelimit.New(rateLimitErr.RequestsPerMinute, ratelimit.Per(time.Minute))
+		oc.logger.Warn("accrual rate limit tuned", zap.Int("requests_per_minute", rateLimitErr.RequestsPerMinute))
+	}
+}
@@ -0,0 +1,91 @@
+package accrual
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ghodss/yaml"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type AdminHandler struct {
+	scenarios *Scenarios
+	recorder  *Recorder
+	logger    *zap.Logger
+}
+
+func NewAdminHandler(e *echo.Echo, scenarios *Scenarios, recorder *Recorder, logger *zap.Logger) *AdminHandler {
+	handler := &AdminHandler{
+		scenarios: scenarios,
+		recorder:  recorder,
+		logger:    logger,
+	}
+
+	admin := e.Group("/admin")
+	admin.GET("/scenarios", handler.GetScenarios)
+	admin.PUT("/scenarios/:name", handler.PutScenario)
+	admin.POST("/scenarios/:name/activate", handler.ActivateScenario)
+	admin.POST("/scenarios/deactivate", handler.DeactivateScenario)
+	admin.GET("/requests", handler.GetRequests)
+	admin.DELETE("/requests", handler.ResetRequests)
+
+	return handler
+}
+
+func (h *AdminHandler) GetScenarios(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.scenarios.State())
+}
+
+// PutScenario accepts the scenario in YAML or JSON, JSON being a subset of YAML.
+func (h *AdminHandler) PutScenario(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	var scenario Scenario
+	if err = yaml.Unmarshal(body, &scenario); err != nil {
+		h.logger.Info("Unable to parse scenario", zap.Error(err))
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	h.scenarios.Put(c.Param("name"), scenario)
+	h.logger.Info("Scenario saved", zap.String("scenario", c.Param("name")))
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *AdminHandler) ActivateScenario(c echo.Context) error {
+	err := h.scenarios.Activate(c.Param("name"))
+	if errors.Is(err, ErrScenarioNotFound) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		h.logger.Error("Unable to activate scenario", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	h.logger.Info("Scenario activated", zap.String("scenario", c.Param("name")))
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *AdminHandler) DeactivateScenario(c echo.Context) error {
+	if err := h.scenarios.Activate(""); err != nil {
+		h.logger.Error("Unable to deactivate scenario", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	h.logger.Info("Scenario deactivated")
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *AdminHandler) GetRequests(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.recorder.Requests())
+}
+
+func (h *AdminHandler) ResetRequests(c echo.Context) error {
+	h.recorder.Reset()
+	return c.NoContent(http.StatusOK)
+}
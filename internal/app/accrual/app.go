@@ -1,13 +1,14 @@
 package accrual
 
 import (
-	"crypto/rand"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"math/big"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/caarlos0/env/v10"
 	"github.com/labstack/echo/v4"
@@ -17,13 +18,64 @@ import (
 	"github.com/msmkdenis/yap-gophermart/internal/middleware"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 type Config struct {
-	Address string `env:"ACCRUAL_RUN_ADDRESS" envDefault:"0.0.0.0:8080"`
+	Address            string        `env:"ACCRUAL_RUN_ADDRESS"`
+	ProcessingDelay    time.Duration `env:"ACCRUAL_PROCESSING_DELAY"`
+	ProcessingDuration time.Duration `env:"ACCRUAL_PROCESSING_DURATION"`
+	ScenarioFile       string        `env:"ACCRUAL_SCENARIO_FILE"`
+	RateLimit          int           `env:"ACCRUAL_RATE_LIMIT"`
+}
+
+// Server is the accrual mock. It is an http.Handler, so tests can serve it with httptest
+// and drive the store, the scenarios and the recorder directly.
+type Server struct {
+	*echo.Echo
+	Store     *Store
+	Scenarios *Scenarios
+	Recorder  *Recorder
 }
 
-func Run() {
+func NewServer(config Config, logger *zap.Logger) (*Server, error) {
+	decimal.MarshalJSONWithoutQuotes = true
+	e := echo.New()
+
+	requestLogger := middleware.InitRequestLogger(logger)
+	e.Use(requestLogger.RequestLogger())
+
+	scenarios := NewScenarios()
+	if config.ScenarioFile != "" {
+		var err error
+		scenarios, err = LoadScenarios(config.ScenarioFile)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Scenarios loaded", zap.Any("scenarios", scenarios.State()))
+	}
+
+	store := NewStore(config.ProcessingDelay, config.ProcessingDuration)
+	recorder := NewRecorder()
+	limiter := NewRateLimiter(config.RateLimit)
+	NewHandler(e, store, scenarios, recorder, limiter, logger)
+	NewAdminHandler(e, scenarios, recorder, logger)
+
+	return &Server{
+		Echo:      e,
+		Store:     store,
+		Scenarios: scenarios,
+		Recorder:  recorder,
+	}, nil
+}
+
+func Run(quitSignal chan os.Signal) {
 	config := &Config{}
 	flag.StringVar(&config.Address, "a", "0.0.0.0:8080", "Адрес и порт запуска сервиса")
+	flag.DurationVar(&config.ProcessingDelay, "processing-delay", time.Second, "Время, через которое зарегистрированный заказ берется в расчет")
+	flag.DurationVar(&config.ProcessingDuration, "processing-duration", 2*time.Second, "Время расчета начисления по заказу")
+	flag.StringVar(&config.ScenarioFile, "scenario", "", "Файл сценариев ответов (YAML/JSON)")
+	flag.IntVar(&config.RateLimit, "rate-limit", 0, "Максимальное количество запросов информации о начислении в минуту, 0 - без ограничений")
+	flag.Parse()
 
 	if err := env.Parse(config); err != nil {
 		fmt.Printf("%+v\n", err)
@@ -34,43 +86,31 @@ func Run() {
 		log.Fatal("Unable to initialize zap logger", err)
 	}
 
-	decimal.MarshalJSONWithoutQuotes = true
-	e := echo.New()
+	server, err := NewServer(*config, logger)
+	if err != nil {
+		logger.Fatal("Unable to initialize accrual mock", zap.Error(err))
+	}
+	e := server.Echo
 
-	requestLogger := middleware.InitRequestLogger(logger)
-	e.Use(requestLogger.RequestLogger())
+	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 
-	e.GET("/api/orders/:orderNumber", func(c echo.Context) error {
-		orderNumber := c.Param("orderNumber")
-
-		status := []string{"REGISTERED", "INVALID", "PROCESSING", "PROCESSED"}
-		randomStatus, _ := rand.Int(rand.Reader, big.NewInt(4))
-		orderStatus := status[randomStatus.Int64()]
-		randomAccrual, _ := rand.Int(rand.Reader, big.NewInt(1000))
-
-		processedOrder := ProcessedOrder{
-			OrderNumber: orderNumber,
-			OrderStatus: orderStatus,
-			Accrual: func() decimal.Decimal {
-				if orderStatus != "PROCESSED" {
-					return decimal.Zero
-				}
-				return decimal.NewFromInt(randomAccrual.Int64())
-			}(),
-		}
-		logger.Info("Processed order", zap.Any("order", processedOrder))
+	go func() {
+		<-quitSignal
 
-		return c.JSON(http.StatusOK, processedOrder)
-	})
+		shutdownCtx, cancel := context.WithTimeout(serverCtx, shutdownTimeout)
+		defer cancel()
+
+		if errShutdown := e.Shutdown(shutdownCtx); errShutdown != nil {
+			logger.Error("Unable to shutdown server gracefully", zap.Error(errShutdown))
+		}
+		serverStopCtx()
+	}()
 
 	errStart := e.Start(config.Address)
 	if errStart != nil && !errors.Is(errStart, http.ErrServerClosed) {
-		log.Fatal(err)
+		log.Fatal(errStart)
 	}
-}
 
-type ProcessedOrder struct {
-	OrderNumber string          `json:"order"`
-	OrderStatus string          `json:"status"`
-	Accrual     decimal.Decimal `json:"accrual"`
+	<-serverCtx.Done()
+	logger.Info("Accrual mock stopped")
 }
@@ -0,0 +1,206 @@
+package accrual
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRetryAfter = "60"
+	slowBodyChunks    = 10
+)
+
+type Handler struct {
+	store     *Store
+	scenarios *Scenarios
+	logger    *zap.Logger
+}
+
+func NewHandler(e *echo.Echo, store *Store, scenarios *Scenarios, recorder *Recorder, limiter *RateLimiter, logger *zap.Logger) *Handler {
+	handler := &Handler{
+		store:     store,
+		scenarios: scenarios,
+		logger:    logger,
+	}
+
+	api := e.Group("/api", recorder.Middleware())
+	api.POST("/orders", handler.RegisterOrder)
+	api.POST("/goods", handler.RegisterRule)
+	api.GET("/orders/:number", handler.GetOrder, limiter.Middleware())
+
+	return handler
+}
+
+func (h *Handler) RegisterOrder(c echo.Context) error {
+	var request RegisterOrderRequest
+	if err := c.Bind(&request); err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	err := h.store.RegisterOrder(request)
+	if errors.Is(err, ErrBadRequest) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if errors.Is(err, ErrOrderAlreadyRegistered) {
+		return c.NoContent(http.StatusConflict)
+	}
+
+	if err != nil {
+		h.logger.Error("Unable to register order", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	h.logger.Info("Registered order", zap.String("order", request.Order), zap.Int("goods", len(request.Goods)))
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (h *Handler) RegisterRule(c echo.Context) error {
+	var rule RewardRule
+	if err := c.Bind(&rule); err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	err := h.store.RegisterRule(rule)
+	if errors.Is(err, ErrBadRequest) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if errors.Is(err, ErrMatchAlreadyRegistered) {
+		return c.NoContent(http.StatusConflict)
+	}
+
+	if err != nil {
+		h.logger.Error("Unable to register reward rule", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	h.logger.Info("Registered reward rule", zap.Any("rule", rule))
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) GetOrder(c echo.Context) error {
+	if step := h.scenarios.Next(c.Param("number")); step != nil {
+		played, err := h.playStep(c, step)
+		if played || err != nil {
+			return err
+		}
+	}
+
+	order, err := h.store.Order(c.Param("number"))
+	if errors.Is(err, ErrOrderNotRegistered) {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if err != nil {
+		h.logger.Error("Unable to get order", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	h.logger.Info("Processed order", zap.Any("order", order))
+	return c.JSON(http.StatusOK, order)
+}
+
+// playStep answers the request as the scenario step says. It reports false when the step
+// only delays the request and the answer is left to the store.
+func (h *Handler) playStep(c echo.Context, step *Step) (bool, error) {
+	select {
+	case <-time.After(step.Latency.Duration):
+	case <-c.Request().Context().Done():
+		return true, nil
+	}
+
+	if step.Reset {
+		h.logger.Info("Resetting connection", zap.String("order", c.Param("number")))
+		c.Set(connectionResetKey, true)
+		return true, resetConnection(c)
+	}
+
+	if step.Status == 0 {
+		return false, nil
+	}
+
+	body := []byte(step.Body)
+	contentType := step.ContentType
+	if step.Order != nil {
+		order := *step.Order
+		if order.Order == "" {
+			order.Order = c.Param("number")
+		}
+
+		var err error
+		if body, err = json.Marshal(order); err != nil {
+			return true, err
+		}
+		if contentType == "" {
+			contentType = echo.MIMEApplicationJSON
+		}
+	}
+
+	if contentType == "" {
+		contentType = echo.MIMETextPlain
+	}
+
+	if step.Status == http.StatusTooManyRequests {
+		retryAfter := step.RetryAfter
+		if retryAfter == "" {
+			retryAfter = defaultRetryAfter
+		}
+		c.Response().Header().Set("Retry-After", retryAfter)
+	}
+
+	h.logger.Info("Playing scenario step", zap.String("order", c.Param("number")), zap.Int("status", step.Status))
+	return true, writeSlowly(c, step.Status, contentType, body, step.SlowBody.Duration)
+}
+
+// writeSlowly spreads the body over the given duration, the headers are sent right away.
+func writeSlowly(c echo.Context, status int, contentType string, body []byte, duration time.Duration) error {
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, contentType)
+	response.Header().Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+	response.WriteHeader(status)
+
+	chunks := min(len(body), slowBodyChunks)
+	if duration <= 0 || chunks == 0 {
+		_, err := response.Write(body)
+		return err
+	}
+
+	chunkSize := (len(body) + chunks - 1) / chunks
+	for len(body) > 0 {
+		n := min(chunkSize, len(body))
+		if _, err := response.Write(body[:n]); err != nil {
+			return err
+		}
+		response.Flush()
+		body = body[n:]
+
+		select {
+		case <-time.After(duration / time.Duration(chunks)):
+		case <-c.Request().Context().Done():
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func resetConnection(c echo.Context) error {
+	conn, _, err := c.Response().Hijack()
+	if err != nil {
+		return err
+	}
+
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+
+	return conn.Close()
+}
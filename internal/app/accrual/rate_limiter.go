@@ -0,0 +1,64 @@
+package accrual
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const rateLimitWindow = time.Minute
+
+// RateLimiter allows Limit requests per minute window and answers the rest with 429 as the accrual spec describes.
+type RateLimiter struct {
+	mu          sync.Mutex
+	limit       int
+	windowStart time.Time
+	count       int
+	now         func() time.Time
+}
+
+func NewRateLimiter(limit int) *RateLimiter {
+	return &RateLimiter{
+		limit: limit,
+		now:   time.Now,
+	}
+}
+
+func (l *RateLimiter) Allow() bool {
+	if l.limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.windowStart) >= rateLimitWindow {
+		l.windowStart = now
+		l.count = 0
+	}
+
+	if l.count >= l.limit {
+		return false
+	}
+
+	l.count++
+	return true
+}
+
+func (l *RateLimiter) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if l.Allow() {
+				return next(c)
+			}
+
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(rateLimitWindow.Seconds())))
+			return c.String(http.StatusTooManyRequests, fmt.Sprintf("No more than %d requests per minute allowed", l.limit))
+		}
+	}
+}
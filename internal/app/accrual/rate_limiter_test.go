@@ -0,0 +1,39 @@
+package accrual
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2)
+	limiter.now = func() time.Time { return now }
+
+	e := echo.New()
+	NewHandler(e, NewStore(time.Second, time.Second), NewScenarios(), NewRecorder(), limiter, zap.NewNop())
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders/12345678903", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusNoContent, get().Code)
+	assert.Equal(t, http.StatusNoContent, get().Code)
+
+	w := get()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, "text/plain; charset=UTF-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "No more than 2 requests per minute allowed", w.Body.String())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, http.StatusNoContent, get().Code)
+}
@@ -0,0 +1,81 @@
+package accrual
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const connectionResetKey = "connectionReset"
+
+type RecordedRequest struct {
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Body       string    `json:"body,omitempty"`
+	Status     int       `json:"status"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Recorder keeps the accrual API requests received by the mock so that tests can assert on them.
+// Requests answered with a connection reset are recorded with status 0.
+type Recorder struct {
+	mu       sync.Mutex
+	requests []RecordedRequest
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := RecordedRequest{
+				Method:     c.Request().Method,
+				Path:       c.Request().URL.Path,
+				ReceivedAt: time.Now(),
+			}
+
+			if c.Request().Body != nil {
+				body, err := io.ReadAll(c.Request().Body)
+				if err != nil {
+					return err
+				}
+				c.Request().Body = io.NopCloser(bytes.NewReader(body))
+				request.Body = string(body)
+			}
+
+			err := next(c)
+
+			request.Status = c.Response().Status
+			if reset, _ := c.Get(connectionResetKey).(bool); reset {
+				request.Status = 0
+			}
+
+			r.mu.Lock()
+			r.requests = append(r.requests, request)
+			r.mu.Unlock()
+
+			return err
+		}
+	}
+}
+
+func (r *Recorder) Requests() []RecordedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	requests := make([]RecordedRequest, len(r.requests))
+	copy(requests, r.requests)
+	return requests
+}
+
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests = nil
+}
@@ -0,0 +1,183 @@
+package accrual
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+var ErrScenarioNotFound = errors.New("scenario not found")
+
+// Duration accepts time.ParseDuration strings such as "250ms" in scenario files.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"1s\": %w", err)
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	d.Duration = duration
+	return nil
+}
+
+// Step describes how the mock answers a single GET /api/orders/{number} request.
+// A step without Status only adds Latency and leaves the answer to the order store.
+type Step struct {
+	Status      int            `json:"status,omitempty"`
+	Order       *OrderResponse `json:"order,omitempty"`
+	Body        string         `json:"body,omitempty"`
+	ContentType string         `json:"content_type,omitempty"`
+	RetryAfter  string         `json:"retry_after,omitempty"`
+	Latency     Duration       `json:"latency,omitempty"`
+	SlowBody    Duration       `json:"slow_body,omitempty"`
+	Reset       bool           `json:"reset,omitempty"`
+	Repeat      int            `json:"repeat,omitempty"`
+}
+
+// Scenario lists the steps played for each order in turn, the last step is repeated once the list is exhausted.
+// Orders without their own steps play Default.
+type Scenario struct {
+	Default []Step            `json:"default,omitempty"`
+	Orders  map[string][]Step `json:"orders,omitempty"`
+}
+
+type ScenarioFile struct {
+	Active    string              `json:"active"`
+	Scenarios map[string]Scenario `json:"scenarios"`
+}
+
+type ScenarioState struct {
+	Active    string   `json:"active"`
+	Scenarios []string `json:"scenarios"`
+}
+
+type Scenarios struct {
+	mu        sync.Mutex
+	scenarios map[string]Scenario
+	active    string
+	calls     map[string]int
+}
+
+func NewScenarios() *Scenarios {
+	return &Scenarios{
+		scenarios: make(map[string]Scenario),
+		calls:     make(map[string]int),
+	}
+}
+
+// LoadScenarios reads a YAML or JSON scenario file.
+func LoadScenarios(path string) (*Scenarios, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file ScenarioFile
+	if err = yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse scenario file %s: %w", path, err)
+	}
+
+	scenarios := NewScenarios()
+	for name, scenario := range file.Scenarios {
+		scenarios.Put(name, scenario)
+	}
+
+	if file.Active != "" {
+		if err = scenarios.Activate(file.Active); err != nil {
+			return nil, err
+		}
+	}
+
+	return scenarios, nil
+}
+
+func (s *Scenarios) Put(name string, scenario Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scenarios[name] = scenario
+	if name == s.active {
+		s.calls = make(map[string]int)
+	}
+}
+
+// Activate switches to the named scenario and starts every order from its first step.
+// An empty name turns scenarios off.
+func (s *Scenarios) Activate(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.scenarios[name]; name != "" && !ok {
+		return ErrScenarioNotFound
+	}
+
+	s.active = name
+	s.calls = make(map[string]int)
+	return nil
+}
+
+func (s *Scenarios) State() ScenarioState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.scenarios))
+	for name := range s.scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return ScenarioState{
+		Active:    s.active,
+		Scenarios: names,
+	}
+}
+
+func (s *Scenarios) Next(orderNumber string) *Step {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scenario, ok := s.scenarios[s.active]
+	if !ok {
+		return nil
+	}
+
+	steps, ok := scenario.Orders[orderNumber]
+	if !ok {
+		steps = scenario.Default
+	}
+
+	if len(steps) == 0 {
+		return nil
+	}
+
+	call := s.calls[orderNumber]
+	s.calls[orderNumber]++
+
+	for _, step := range steps {
+		repeat := max(step.Repeat, 1)
+		if call < repeat {
+			return &step
+		}
+		call -= repeat
+	}
+
+	return &steps[len(steps)-1]
+}
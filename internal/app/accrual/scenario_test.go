@@ -0,0 +1,106 @@
+package accrual
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLoadScenarios(t *testing.T) {
+	scenarios, err := LoadScenarios(filepath.Join("..", "..", "..", "cmd", "mockaccrual", "scenarios.example.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, ScenarioState{Active: "flaky", Scenarios: []string{"flaky", "partner_down"}}, scenarios.State())
+
+	expectedStatuses := []int{429, 429, 429, 500, 500, 200, 200, 0, 200, 200}
+	for i, expectedStatus := range expectedStatuses {
+		step := scenarios.Next("12345678903")
+		require.NotNil(t, step, i)
+		assert.Equal(t, expectedStatus, step.Status, i)
+	}
+
+	step := scenarios.Next("79927398713")
+	require.NotNil(t, step)
+	assert.Equal(t, 200*time.Millisecond, step.Latency.Duration)
+	assert.Equal(t, 0, step.Status)
+
+	require.NoError(t, scenarios.Activate("partner_down"))
+	assert.Equal(t, http.StatusServiceUnavailable, scenarios.Next("12345678903").Status)
+
+	assert.ErrorIs(t, scenarios.Activate("unknown"), ErrScenarioNotFound)
+
+	require.NoError(t, scenarios.Activate(""))
+	assert.Nil(t, scenarios.Next("12345678903"))
+}
+
+func TestPlayScenario(t *testing.T) {
+	logger := zap.NewNop()
+	e := echo.New()
+	scenarios := NewScenarios()
+	recorder := NewRecorder()
+	NewHandler(e, NewStore(time.Hour, time.Hour), scenarios, recorder, NewRateLimiter(0), logger)
+	NewAdminHandler(e, scenarios, recorder, logger)
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	scenario := `
+default:
+  - status: 429
+  - status: 200
+    body: '{"order":'
+  - reset: true
+`
+	request, err := http.NewRequest(http.MethodPut, server.URL+"/admin/scenarios/broken", strings.NewReader(scenario))
+	require.NoError(t, err)
+	putResponse, err := client.Do(request)
+	require.NoError(t, err)
+	putResponse.Body.Close()
+	require.Equal(t, http.StatusOK, putResponse.StatusCode)
+
+	activateResponse, err := client.Post(server.URL+"/admin/scenarios/broken/activate", "", nil)
+	require.NoError(t, err)
+	activateResponse.Body.Close()
+	require.Equal(t, http.StatusOK, activateResponse.StatusCode)
+
+	rateLimited, err := client.Get(server.URL + "/api/orders/12345678903")
+	require.NoError(t, err)
+	rateLimited.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, rateLimited.StatusCode)
+	assert.Equal(t, defaultRetryAfter, rateLimited.Header.Get("Retry-After"))
+
+	malformed, err := client.Get(server.URL + "/api/orders/12345678903")
+	require.NoError(t, err)
+	body, err := io.ReadAll(malformed.Body)
+	malformed.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, malformed.StatusCode)
+	assert.False(t, json.Valid(body))
+
+	_, err = client.Get(server.URL + "/api/orders/12345678903")
+	assert.Error(t, err)
+
+	requestsResponse, err := client.Get(server.URL + "/admin/requests")
+	require.NoError(t, err)
+	defer requestsResponse.Body.Close()
+
+	var requests []RecordedRequest
+	require.NoError(t, json.NewDecoder(requestsResponse.Body).Decode(&requests))
+	require.Len(t, requests, 3)
+	assert.Equal(t, http.StatusTooManyRequests, requests[0].Status)
+	assert.Equal(t, http.StatusOK, requests[1].Status)
+	assert.Equal(t, 0, requests[2].Status)
+	assert.Equal(t, "/api/orders/12345678903", requests[2].Path)
+}
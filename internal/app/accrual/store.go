@@ -0,0 +1,189 @@
+package accrual
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ShiraazMoollatjie/goluhn"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	StatusRegistered = "REGISTERED"
+	StatusInvalid    = "INVALID"
+	StatusProcessing = "PROCESSING"
+	StatusProcessed  = "PROCESSED"
+
+	RewardPercent = "%"
+	RewardPoints  = "pt"
+)
+
+var (
+	ErrBadRequest             = errors.New("bad request")
+	ErrOrderAlreadyRegistered = errors.New("order already registered")
+	ErrMatchAlreadyRegistered = errors.New("match already registered")
+	ErrOrderNotRegistered     = errors.New("order not registered")
+)
+
+type Good struct {
+	Description string          `json:"description"`
+	Price       decimal.Decimal `json:"price"`
+}
+
+type RegisterOrderRequest struct {
+	Order string `json:"order"`
+	Goods []Good `json:"goods"`
+}
+
+type RewardRule struct {
+	Match      string          `json:"match"`
+	Reward     decimal.Decimal `json:"reward"`
+	RewardType string          `json:"reward_type"`
+}
+
+type OrderResponse struct {
+	Order   string           `json:"order"`
+	Status  string           `json:"status"`
+	Accrual *decimal.Decimal `json:"accrual,omitempty"`
+}
+
+type registeredOrder struct {
+	number       string
+	goods        []Good
+	registeredAt time.Time
+	status       string
+	accrual      decimal.Decimal
+}
+
+// Store keeps registered orders and reward rules. An order is REGISTERED until ProcessingDelay has passed,
+// PROCESSING for ProcessingDuration after that and then settles on a final status that never changes.
+type Store struct {
+	mu                 sync.Mutex
+	rules              []RewardRule
+	orders             map[string]*registeredOrder
+	processingDelay    time.Duration
+	processingDuration time.Duration
+	now                func() time.Time
+}
+
+func NewStore(processingDelay time.Duration, processingDuration time.Duration) *Store {
+	return &Store{
+		orders:             make(map[string]*registeredOrder),
+		processingDelay:    processingDelay,
+		processingDuration: processingDuration,
+		now:                time.Now,
+	}
+}
+
+func (s *Store) RegisterRule(rule RewardRule) error {
+	if rule.Match == "" || !rule.Reward.IsPositive() || (rule.RewardType != RewardPercent && rule.RewardType != RewardPoints) {
+		return ErrBadRequest
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.rules {
+		if r.Match == rule.Match {
+			return ErrMatchAlreadyRegistered
+		}
+	}
+
+	s.rules = append(s.rules, rule)
+	return nil
+}
+
+func (s *Store) RegisterOrder(request RegisterOrderRequest) error {
+	if goluhn.Validate(request.Order) != nil || len(request.Goods) == 0 {
+		return ErrBadRequest
+	}
+
+	for _, good := range request.Goods {
+		if good.Description == "" || good.Price.IsNegative() {
+			return ErrBadRequest
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.orders[request.Order]; ok {
+		return ErrOrderAlreadyRegistered
+	}
+
+	s.orders[request.Order] = &registeredOrder{
+		number:       request.Order,
+		goods:        request.Goods,
+		registeredAt: s.now(),
+		status:       StatusRegistered,
+	}
+	return nil
+}
+
+func (s *Store) Order(number string) (OrderResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[number]
+	if !ok {
+		return OrderResponse{}, ErrOrderNotRegistered
+	}
+
+	s.advance(order)
+
+	response := OrderResponse{
+		Order:  order.number,
+		Status: order.status,
+	}
+	if order.status == StatusProcessed && order.accrual.IsPositive() {
+		accrual := order.accrual
+		response.Accrual = &accrual
+	}
+
+	return response, nil
+}
+
+func (s *Store) advance(order *registeredOrder) {
+	if order.status == StatusProcessed || order.status == StatusInvalid {
+		return
+	}
+
+	elapsed := s.now().Sub(order.registeredAt)
+	switch {
+	case elapsed >= s.processingDelay+s.processingDuration:
+		order.accrual, order.status = s.calculate(order.goods)
+	case elapsed >= s.processingDelay:
+		order.status = StatusProcessing
+	}
+}
+
+// calculate rewards every good by the first rule whose match is contained in its description.
+// Orders without a single matching good are not accepted for accrual.
+func (s *Store) calculate(goods []Good) (decimal.Decimal, string) {
+	accrual := decimal.Zero
+	matched := false
+
+	for _, good := range goods {
+		for _, rule := range s.rules {
+			if !strings.Contains(good.Description, rule.Match) {
+				continue
+			}
+
+			matched = true
+			if rule.RewardType == RewardPercent {
+				accrual = accrual.Add(good.Price.Mul(rule.Reward).Div(decimal.NewFromInt(100)))
+			} else {
+				accrual = accrual.Add(rule.Reward)
+			}
+			break
+		}
+	}
+
+	if !matched {
+		return decimal.Zero, StatusInvalid
+	}
+
+	return accrual.Round(2), StatusProcessed
+}
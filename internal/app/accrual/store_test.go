@@ -0,0 +1,65 @@
+package accrual
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreOrderProgression(t *testing.T) {
+	now := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)
+	store := NewStore(time.Second, 2*time.Second)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.RegisterRule(RewardRule{Match: "Bork", Reward: decimal.NewFromInt(10), RewardType: RewardPercent}))
+	require.NoError(t, store.RegisterRule(RewardRule{Match: "Miele", Reward: decimal.NewFromInt(50), RewardType: RewardPoints}))
+	assert.ErrorIs(t, store.RegisterRule(RewardRule{Match: "Bork", Reward: decimal.NewFromInt(5), RewardType: RewardPoints}), ErrMatchAlreadyRegistered)
+
+	require.NoError(t, store.RegisterOrder(RegisterOrderRequest{
+		Order: "12345678903",
+		Goods: []Good{
+			{Description: "Чайник Bork", Price: decimal.NewFromInt(7000)},
+			{Description: "Пылесос Miele", Price: decimal.NewFromInt(20000)},
+		},
+	}))
+	require.NoError(t, store.RegisterOrder(RegisterOrderRequest{
+		Order: "79927398713",
+		Goods: []Good{{Description: "Утюг Tefal", Price: decimal.NewFromInt(3000)}},
+	}))
+	assert.ErrorIs(t, store.RegisterOrder(RegisterOrderRequest{Order: "12345678903", Goods: []Good{{Description: "Bork", Price: decimal.NewFromInt(1)}}}), ErrOrderAlreadyRegistered)
+	assert.ErrorIs(t, store.RegisterOrder(RegisterOrderRequest{Order: "12345678904", Goods: []Good{{Description: "Bork", Price: decimal.NewFromInt(1)}}}), ErrBadRequest)
+
+	_, err := store.Order("4561261212345467")
+	assert.ErrorIs(t, err, ErrOrderNotRegistered)
+
+	order, err := store.Order("12345678903")
+	require.NoError(t, err)
+	assert.Equal(t, StatusRegistered, order.Status)
+	assert.Nil(t, order.Accrual)
+
+	now = now.Add(time.Second)
+	order, err = store.Order("12345678903")
+	require.NoError(t, err)
+	assert.Equal(t, StatusProcessing, order.Status)
+
+	now = now.Add(2 * time.Second)
+	order, err = store.Order("12345678903")
+	require.NoError(t, err)
+	assert.Equal(t, StatusProcessed, order.Status)
+	require.NotNil(t, order.Accrual)
+	assert.True(t, decimal.NewFromInt(750).Equal(*order.Accrual))
+
+	invalid, err := store.Order("79927398713")
+	require.NoError(t, err)
+	assert.Equal(t, StatusInvalid, invalid.Status)
+
+	require.NoError(t, store.RegisterRule(RewardRule{Match: "Чайник", Reward: decimal.NewFromInt(100), RewardType: RewardPoints}))
+	now = now.Add(time.Hour)
+	order, err = store.Order("12345678903")
+	require.NoError(t, err)
+	assert.Equal(t, StatusProcessed, order.Status)
+	assert.True(t, decimal.NewFromInt(750).Equal(*order.Accrual))
+}
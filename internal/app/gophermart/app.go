@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"time"
@@ -17,22 +18,45 @@ import (
 	accrualHttp "github.com/msmkdenis/yap-gophermart/internal/accrual/http"
 	accrualService "github.com/msmkdenis/yap-gophermart/internal/accrual/service"
 	balanceHandler "github.com/msmkdenis/yap-gophermart/internal/balance/handler"
+	balanceModel "github.com/msmkdenis/yap-gophermart/internal/balance/model"
 	balanceRepository "github.com/msmkdenis/yap-gophermart/internal/balance/repository"
 	balanceService "github.com/msmkdenis/yap-gophermart/internal/balance/service"
 	"github.com/msmkdenis/yap-gophermart/internal/config"
 	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	healthHandler "github.com/msmkdenis/yap-gophermart/internal/health/handler"
+	healthService "github.com/msmkdenis/yap-gophermart/internal/health/service"
 	"github.com/msmkdenis/yap-gophermart/internal/middleware"
 	orderHandler "github.com/msmkdenis/yap-gophermart/internal/order/handler"
 	orderRepository "github.com/msmkdenis/yap-gophermart/internal/order/repository"
 	orderService "github.com/msmkdenis/yap-gophermart/internal/order/service"
+	promoHandler "github.com/msmkdenis/yap-gophermart/internal/promo/handler"
+	promoRepository "github.com/msmkdenis/yap-gophermart/internal/promo/repository"
+	promoService "github.com/msmkdenis/yap-gophermart/internal/promo/service"
+	referralHandler "github.com/msmkdenis/yap-gophermart/internal/referral/handler"
+	referralRepository "github.com/msmkdenis/yap-gophermart/internal/referral/repository"
+	referralService "github.com/msmkdenis/yap-gophermart/internal/referral/service"
+	"github.com/msmkdenis/yap-gophermart/internal/scheduler"
 	userHandler "github.com/msmkdenis/yap-gophermart/internal/user/handler"
 	userRepository "github.com/msmkdenis/yap-gophermart/internal/user/repository"
 	userService "github.com/msmkdenis/yap-gophermart/internal/user/service"
 	"github.com/msmkdenis/yap-gophermart/internal/utils"
 )
 
+// Server shutdown and accrual worker drain have their own deadlines that end well before shutdownTimeout
+// forces the exit, leaving the worker time to release the orders it has claimed.
+const (
+	shutdownTimeout       = 30 * time.Second
+	serverShutdownTimeout = 10 * time.Second
+	workerDrainTimeout    = 10 * time.Second
+)
+
 func Run(quitSignal chan os.Signal) {
-	cfg := *config.NewConfig()
+	RunWithConfig(*config.NewConfig(), quitSignal, nil)
+}
+
+// RunWithConfig starts the service with the given configuration and blocks until quitSignal is received
+// and the service is shut down. When listener is not nil the server accepts connections on it instead of cfg.Address.
+func RunWithConfig(cfg config.Config, quitSignal chan os.Signal, listener net.Listener) {
 	logger, err := zap.NewProduction()
 	if err != nil {
 		log.Fatal("Unable to initialize zap logger", err)
@@ -41,23 +65,54 @@ func Run(quitSignal chan os.Signal) {
 	decimal.MarshalJSONWithoutQuotes = true
 
 	jwtManager := utils.InitJWTManager(cfg.TokenName, cfg.Secret, logger)
-	postgresPool := initPostgresPool(&cfg, logger)
-
-	userRepo := userRepository.NewPostgresUserRepository(postgresPool, logger)
-	userServ := userService.NewUserService(userRepo, logger)
-
-	orderRepo := orderRepository.NewPostgresOrderRepository(postgresPool, logger)
-	orderServ := orderService.NewOrderService(orderRepo, logger)
-
-	balanceRepo := balanceRepository.NewPostgresBalanceRepository(postgresPool, logger)
-	balanceServ := balanceService.NewBalanceService(balanceRepo, logger)
-
-	orderAccrual := accrualHttp.NewOrderAccrual(cfg.AccrualSystemAddress, logger)
-	accrualTrManager := manager.Must(trmpgx.NewDefaultFactory(postgresPool.DB))
-	accrualService.NewOrderAccrualService(orderRepo, balanceRepo, orderAccrual, logger, accrualTrManager).Run()
+	repositories := initRepositories(&cfg, logger)
+
+	userServ := userService.NewUserService(repositories.user, repositories.referral, repositories.trManager, logger, cfg.ReferralLimit)
+	orderServ := orderService.NewOrderService(repositories.order, logger)
+	balanceServ := balanceService.NewBalanceService(repositories.balance, logger, balanceService.Config{
+		HoldTTL:              cfg.HoldTTL,
+		PointsLifetimeMonths: cfg.PointsLifetimeMonths,
+		TransferLimits: balanceModel.TransferLimits{
+			DailySum:   cfg.TransferDailyLimit,
+			DailyCount: cfg.TransferDailyCount,
+		},
+	})
+
+	promoServ := promoService.NewPromoService(repositories.promo, repositories.balance, repositories.trManager, logger)
+	referralServ := referralService.NewReferralService(repositories.referral, logger)
+
+	accrualBreaker := accrualHttp.NewCircuitBreaker(accrualHttp.BreakerConfig{
+		FailureThreshold: cfg.AccrualBreakerFailures,
+		OpenTimeout:      cfg.AccrualBreakerOpenTimeout,
+		HalfOpenRequests: cfg.AccrualBreakerProbes,
+	}, logger)
+	orderAccrual := accrualHttp.NewOrderAccrual(cfg.AccrualSystemAddress, cfg.AccrualTimeout, accrualBreaker, logger)
+	orderAccrualWorker := accrualService.NewOrderAccrualService(repositories.order, repositories.balance, repositories.referral, orderAccrual, logger, repositories.trManager, accrualService.WorkerConfig{
+		Workers:           cfg.AccrualWorkers,
+		BatchSize:         cfg.AccrualBatchSize,
+		PollInterval:      cfg.AccrualPollInterval,
+		RequestsPerSecond: cfg.AccrualRPS,
+		LeaseTimeout:      cfg.AccrualLeaseTimeout,
+		Backoff: accrualService.Backoff{
+			Base: cfg.AccrualBackoffBase,
+			Max:  cfg.AccrualBackoffMax,
+		},
+		MaxAttempts:   cfg.AccrualMaxAttempts,
+		ReferralBonus: cfg.ReferralBonus,
+	})
+	orderAccrualWorker.Start(context.Background())
+
+	balanceScheduler := scheduler.NewScheduler(logger)
+	balanceScheduler.Every("hold expiry", cfg.HoldExpiryInterval, balanceServ.ReleaseExpiredHolds)
+	balanceScheduler.Every("points expiry", cfg.PointsExpiryInterval, balanceServ.ExpirePoints)
+	balanceScheduler.Daily("tier recalculation", cfg.TierRecalculationAt, balanceServ.RecalculateTiers)
+	balanceScheduler.Start(context.Background())
+
+	healthServ := healthService.NewHealthService(accrualBreaker, orderAccrualWorker, logger)
 
 	requestLogger := middleware.InitRequestLogger(logger)
 	jwtAuth := middleware.InitJWTAuth(jwtManager, logger)
+	adminAuth := middleware.InitAdminAuth(cfg.AdminToken, logger)
 
 	e := echo.New()
 
@@ -67,14 +122,20 @@ func Run(quitSignal chan os.Signal) {
 
 	userHandler.NewUserHandler(e, userServ, jwtManager, cfg.Secret, logger)
 	orderHandler.NewOrderHandler(e, orderServ, logger, jwtAuth)
+	orderHandler.NewOrderAdminHandler(e, orderServ, logger, adminAuth)
 	balanceHandler.NewBalanceHandler(e, balanceServ, logger, jwtAuth)
+	balanceHandler.NewBalanceAdminHandler(e, balanceServ, logger, adminAuth)
+	promoHandler.NewPromoHandler(e, promoServ, logger, jwtAuth)
+	promoHandler.NewPromoAdminHandler(e, promoServ, logger, adminAuth)
+	referralHandler.NewReferralHandler(e, referralServ, logger, jwtAuth)
+	healthHandler.NewHealthHandler(e, healthServ, logger)
 
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 
 	go func() {
 		<-quitSignal
 
-		shutdownCtx, cancel := context.WithTimeout(serverCtx, 30*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(serverCtx, shutdownTimeout)
 		defer cancel()
 
 		go func() {
@@ -84,12 +145,27 @@ func Run(quitSignal chan os.Signal) {
 			}
 		}()
 
-		if errShutdown := e.Shutdown(shutdownCtx); errShutdown != nil {
-			e.Logger.Fatal(errShutdown)
+		serverShutdownCtx, cancelServerShutdown := context.WithTimeout(shutdownCtx, serverShutdownTimeout)
+		defer cancelServerShutdown()
+		if errShutdown := e.Shutdown(serverShutdownCtx); errShutdown != nil {
+			logger.Error("Unable to shutdown server gracefully", zap.Error(errShutdown))
+		}
+
+		drainCtx, cancelDrain := context.WithTimeout(shutdownCtx, workerDrainTimeout)
+		defer cancelDrain()
+		if errStop := orderAccrualWorker.Stop(drainCtx); errStop != nil {
+			logger.Error("Unable to drain accrual worker", zap.Error(errStop))
+		}
+		if errStop := balanceScheduler.Stop(shutdownCtx); errStop != nil {
+			logger.Error("Unable to stop scheduler", zap.Error(errStop))
 		}
 		serverStopCtx()
 	}()
 
+	if listener != nil {
+		e.Listener = listener
+	}
+
 	errStart := e.Start(cfg.Address)
 	if errStart != nil && !errors.Is(errStart, http.ErrServerClosed) {
 		log.Fatal(err)
@@ -98,6 +174,60 @@ func Run(quitSignal chan os.Signal) {
 	<-serverCtx.Done()
 }
 
+type orderRepositoryStorage interface {
+	orderService.OrderRepository
+	accrualService.OrderRepository
+}
+
+type balanceRepositoryStorage interface {
+	balanceService.BalanceRepository
+	accrualService.BalanceRepository
+	promoService.BalanceRepository
+}
+
+type referralRepositoryStorage interface {
+	userService.ReferralRepository
+	referralService.ReferralRepository
+	accrualService.ReferralRepository
+}
+
+type repositories struct {
+	user      userService.UserRepository
+	order     orderRepositoryStorage
+	balance   balanceRepositoryStorage
+	promo     promoService.PromoRepository
+	referral  referralRepositoryStorage
+	trManager *manager.Manager
+}
+
+func initRepositories(cfg *config.Config, logger *zap.Logger) repositories {
+	switch cfg.Storage {
+	case config.StorageMemory:
+		storage := db.NewMemoryStorage(logger)
+		return repositories{
+			user:      userRepository.NewMemoryUserRepository(storage, logger),
+			order:     orderRepository.NewMemoryOrderRepository(storage, logger),
+			balance:   balanceRepository.NewMemoryBalanceRepository(storage, logger),
+			promo:     promoRepository.NewMemoryPromoRepository(storage, logger),
+			referral:  referralRepository.NewMemoryReferralRepository(storage, logger),
+			trManager: manager.Must(storage.TrFactory()),
+		}
+	case config.StoragePostgres, "":
+		postgresPool := initPostgresPool(cfg, logger)
+		return repositories{
+			user:      userRepository.NewPostgresUserRepository(postgresPool, logger),
+			order:     orderRepository.NewPostgresOrderRepository(postgresPool, logger),
+			balance:   balanceRepository.NewPostgresBalanceRepository(postgresPool, logger),
+			promo:     promoRepository.NewPostgresPromoRepository(postgresPool, logger),
+			referral:  referralRepository.NewPostgresReferralRepository(postgresPool, logger),
+			trManager: manager.Must(trmpgx.NewDefaultFactory(postgresPool.DB)),
+		}
+	default:
+		logger.Fatal("Unknown storage", zap.String("storage", cfg.Storage))
+		return repositories{}
+	}
+}
+
 func initPostgresPool(cfg *config.Config, logger *zap.Logger) *db.PostgresPool {
 	postgresPool, err := db.NewPostgresPool(cfg.DatabaseURI, logger)
 	if err != nil {
@@ -3,6 +3,7 @@ package apperrors
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
@@ -20,6 +21,40 @@ var (
 	ErrBalanceNotFound                 = errors.New("balance not found")
 	ErrInsufficientFunds               = errors.New("insufficient funds")
 	ErrNoWithdrawals                   = errors.New("no withdrawals")
+	ErrNoLedgerEntries                 = errors.New("no ledger entries")
+	ErrWithdrawalAlreadyExists         = errors.New("withdrawal for order already exists")
+	ErrWithdrawalNotFound              = errors.New("withdrawal not found")
+	ErrWithdrawalAlreadyReversed       = errors.New("withdrawal already reversed")
+	ErrHoldNotFound                    = errors.New("hold not found")
+	ErrHoldNotActive                   = errors.New("hold is not active")
+	ErrHoldAlreadyExists               = errors.New("active hold for order already exists")
+	ErrNoHolds                         = errors.New("no holds")
+	ErrTransferRecipientNotFound       = errors.New("transfer recipient not found")
+	ErrTransferToSelf                  = errors.New("transfer to self")
+	ErrTransferLimitExceeded           = errors.New("daily transfer limit exceeded")
+	ErrNoTransfers                     = errors.New("no transfers")
+	ErrInvalidPromoCode                = errors.New("invalid promo code")
+	ErrPromoCodeAlreadyExists          = errors.New("promo code already exists")
+	ErrPromoCodeNotFound               = errors.New("promo code not found")
+	ErrPromoCodeNotActive              = errors.New("promo code is not active")
+	ErrPromoCodeExhausted              = errors.New("promo code redemptions exhausted")
+	ErrPromoCodeAlreadyRedeemed        = errors.New("promo code already redeemed by user")
+	ErrNoPromoCodes                    = errors.New("no promo codes")
+	ErrReferralCodeNotFound            = errors.New("referral code not found")
+	ErrReferralCodeAlreadyExists       = errors.New("referral code already exists")
+	ErrSelfReferral                    = errors.New("self referral")
+	ErrReferralLimitExceeded           = errors.New("referral limit of referrer exceeded")
+	ErrNoPendingReferral               = errors.New("no pending referral")
+	ErrIdempotencyKeyReused            = errors.New("idempotency key reused with different request")
+	ErrIdempotencyKeyInProgress        = errors.New("request with idempotency key in progress")
+	ErrOrderStatusConflict             = errors.New("order status changed concurrently")
+	ErrIllegalStatusTransition         = errors.New("illegal order status transition")
+	ErrAccrualAlreadyCredited          = errors.New("accrual already credited")
+	ErrCircuitOpen                     = errors.New("circuit breaker is open")
+	ErrAccrualUnavailable              = errors.New("accrual system unavailable")
+	ErrAccrualOrderNotRegistered       = errors.New("order not registered in accrual system")
+	ErrAccrualUnexpectedStatus         = errors.New("unexpected accrual system response status")
+	ErrAccrualInvalidResponse          = errors.New("invalid accrual system response")
 )
 
 type ValueError struct {
@@ -43,3 +78,63 @@ func (v *ValueError) Error() string {
 func (v *ValueError) Unwrap() error {
 	return v.err
 }
+
+type RateLimitError struct {
+	RetryAfter        time.Duration
+	RequestsPerMinute int
+}
+
+func NewRateLimitError(retryAfter time.Duration, requestsPerMinute int) error {
+	return &RateLimitError{
+		RetryAfter:        retryAfter,
+		RequestsPerMinute: requestsPerMinute,
+	}
+}
+
+func (r *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: retry after %s, no more than %d requests per minute", ErrRateLimit, r.RetryAfter, r.RequestsPerMinute)
+}
+
+func (r *RateLimitError) Unwrap() error {
+	return ErrRateLimit
+}
+
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func NewCircuitOpenError(retryAfter time.Duration) error {
+	return &CircuitOpenError{
+		RetryAfter: retryAfter,
+	}
+}
+
+func (c *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrCircuitOpen, c.RetryAfter)
+}
+
+func (c *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+type AccrualResponseError struct {
+	StatusCode int
+	Reason     string
+	err        error
+}
+
+func NewAccrualResponseError(statusCode int, reason string, err error) error {
+	return &AccrualResponseError{
+		StatusCode: statusCode,
+		Reason:     reason,
+		err:        err,
+	}
+}
+
+func (a *AccrualResponseError) Error() string {
+	return fmt.Sprintf("%s: status %d: %s", a.err, a.StatusCode, a.Reason)
+}
+
+func (a *AccrualResponseError) Unwrap() error {
+	return a.err
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/balance/handler/dto"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+)
+
+// BalanceAdminService mockgen --build_flags=--mod=mod -destination=internal/mocks/mock_balance_admin_service.go -package=mock github.com/msmkdenis/yap-gophermart/internal/balance/handler BalanceAdminService
+type BalanceAdminService interface {
+	CheckConsistency(ctx context.Context) (*dto.ConsistencyResponse, error)
+	ReverseWithdrawal(ctx context.Context, orderNumber string, reason string) (*dto.WithdrawalReversalResponse, error)
+	RecalculateTiers(ctx context.Context) error
+}
+
+type BalanceAdminHandler struct {
+	balanceService BalanceAdminService
+	logger         *zap.Logger
+	adminAuth      *middleware.AdminAuth
+}
+
+func NewBalanceAdminHandler(e *echo.Echo, service BalanceAdminService, logger *zap.Logger, adminAuth *middleware.AdminAuth) *BalanceAdminHandler {
+	handler := &BalanceAdminHandler{
+		balanceService: service,
+		logger:         logger,
+		adminAuth:      adminAuth,
+	}
+
+	adminBalance := e.Group("/api/admin/balance", adminAuth.AdminAuth())
+	adminBalance.GET("/consistency", handler.CheckConsistency)
+	adminBalance.POST("/withdrawals/:order/reversal", handler.ReverseWithdrawal)
+	adminBalance.POST("/tiers/recalculation", handler.RecalculateTiers)
+
+	return handler
+}
+
+// @Summary       Check balance consistency
+// @Description   Compare every materialized balance with the sum of its ledger postings and check that every ledger transaction sums to zero.
+// @Tags          Admin API
+// @Produce       json
+// @Success       200    {object}   dto.ConsistencyResponse
+// @Failure       401
+// @Failure       403
+// @Failure       500
+// @Security      AdminToken
+// @Router        /api/admin/balance/consistency [get]
+func (h *BalanceAdminHandler) CheckConsistency(c echo.Context) error {
+	consistency, err := h.balanceService.CheckConsistency(c.Request().Context())
+	if err != nil {
+		h.logger.Error("Unable to check balance consistency", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, consistency)
+}
+
+// @Summary       Reverse withdrawal
+// @Description   Refund the withdrawal made for the order back to the user balance, e.g. when the order is cancelled. A withdrawal is reversed at most once.
+// @Tags          Admin API
+// @Accept        json
+// @Produce       json
+// @Param         order      path       string                           true   "Order number of the withdrawal."
+// @Param         reversal   body       dto.WithdrawalReversalRequest    true   "Reason of the reversal."
+// @Success       200    {object}   dto.WithdrawalReversalResponse
+// @Failure       400
+// @Failure       401
+// @Failure       403
+// @Failure       404
+// @Failure       409
+// @Failure       415
+// @Failure       500
+// @Security      AdminToken
+// @Router        /api/admin/balance/withdrawals/{order}/reversal [post]
+func (h *BalanceAdminHandler) ReverseWithdrawal(c echo.Context) error {
+	header := c.Request().Header.Get("Content-Type")
+	if header != "application/json" {
+		msg := "Content-Type header is not application/json"
+		h.logger.Error("StatusUnsupportedMediaType: " + msg)
+		return c.String(http.StatusUnsupportedMediaType, msg)
+	}
+
+	request := new(dto.WithdrawalReversalRequest)
+	if bindErr := c.Bind(request); bindErr != nil {
+		h.logger.Warn("Unable to bind data", zap.Error(bindErr))
+		return c.String(http.StatusBadRequest, "Bad request")
+	}
+
+	if validateErr := validator.New().Struct(request); validateErr != nil {
+		h.logger.Warn("Bad Request: invalid request", zap.Error(validateErr))
+		return c.String(http.StatusBadRequest, "Invalid request data")
+	}
+
+	reversal, err := h.balanceService.ReverseWithdrawal(c.Request().Context(), c.Param("order"), request.Reason)
+
+	if errors.Is(err, apperrors.ErrWithdrawalNotFound) {
+		h.logger.Info("Withdrawal not found", zap.Error(err))
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if errors.Is(err, apperrors.ErrWithdrawalAlreadyReversed) {
+		h.logger.Warn("Withdrawal already reversed", zap.Error(err))
+		return c.NoContent(http.StatusConflict)
+	}
+
+	if err != nil {
+		h.logger.Error("Unable to reverse withdrawal", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, reversal)
+}
+
+// @Summary       Recalculate loyalty tiers
+// @Description   Place every user into the loyalty tier matching the accruals of the last 12 months without waiting for the nightly recalculation.
+// @Tags          Admin API
+// @Success       200
+// @Failure       401
+// @Failure       403
+// @Failure       500
+// @Security      AdminToken
+// @Router        /api/admin/balance/tiers/recalculation [post]
+func (h *BalanceAdminHandler) RecalculateTiers(c echo.Context) error {
+	err := h.balanceService.RecalculateTiers(c.Request().Context())
+	if err != nil {
+		h.logger.Error("Unable to recalculate loyalty tiers", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
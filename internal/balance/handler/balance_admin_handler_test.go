@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/balance/handler/dto"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+	mock "github.com/msmkdenis/yap-gophermart/internal/mocks"
+)
+
+const adminTokenMock = "supersecretadmintoken"
+
+type BalanceAdminHandlersSuite struct {
+	suite.Suite
+	h              *BalanceAdminHandler
+	balanceService *mock.MockBalanceAdminService
+	echo           *echo.Echo
+	ctrl           *gomock.Controller
+}
+
+func TestAdminSuite(t *testing.T) {
+	suite.Run(t, new(BalanceAdminHandlersSuite))
+}
+
+func (b *BalanceAdminHandlersSuite) SetupTest() {
+	logger, _ := zap.NewProduction()
+	adminAuth := middleware.InitAdminAuth(adminTokenMock, logger)
+	b.ctrl = gomock.NewController(b.T())
+	b.echo = echo.New()
+	b.balanceService = mock.NewMockBalanceAdminService(b.ctrl)
+	b.h = NewBalanceAdminHandler(b.echo, b.balanceService, logger, adminAuth)
+}
+
+func (b *BalanceAdminHandlersSuite) TestCheckConsistency() {
+	consistency := &dto.ConsistencyResponse{
+		Consistent: false,
+		Drifts: []dto.BalanceDriftResponse{
+			{
+				UserLogin:       "awesome_login",
+				Current:         decimal.NewFromInt(300),
+				LedgerCurrent:   decimal.NewFromInt(250),
+				Withdrawn:       decimal.NewFromInt(200),
+				LedgerWithdrawn: decimal.NewFromInt(200),
+			},
+		},
+		UnbalancedTransactions: []dto.UnbalancedTransactionResponse{},
+	}
+
+	response, errMarshal := json.Marshal(consistency)
+	require.NoError(b.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		token        string
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name:  "Unauthorized - 401",
+			token: "wrong_token",
+			prepare: func() {
+				b.balanceService.EXPECT().CheckConsistency(gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:  "Success - 200",
+			token: adminTokenMock,
+			prepare: func() {
+				b.balanceService.EXPECT().CheckConsistency(gomock.Any()).Times(1).Return(consistency, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+		{
+			name:  "InternalServerError - 500",
+			token: adminTokenMock,
+			prepare: func() {
+				b.balanceService.EXPECT().CheckConsistency(gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		b.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/admin/balance/consistency", nil)
+			request.Header.Set("X-Admin-Token", test.token)
+
+			w := httptest.NewRecorder()
+			b.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			} else {
+				assert.Equal(t, "", w.Body.String())
+			}
+		})
+	}
+}
+
+func (b *BalanceAdminHandlersSuite) TestReverseWithdrawal() {
+	orderNumber := "2377225624"
+
+	reversal := &dto.WithdrawalReversalResponse{
+		OrderNumber: orderNumber,
+		UserLogin:   "awesome_login",
+		Amount:      decimal.NewFromInt(200),
+		Reason:      "order cancelled",
+		ReversedAt:  "2024-01-10T12:00:00Z",
+	}
+
+	response, errMarshal := json.Marshal(reversal)
+	require.NoError(b.T(), errMarshal)
+
+	validReq := `{"reason":"order cancelled"}`
+
+	testCases := []struct {
+		name         string
+		token        string
+		contentType  string
+		body         string
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name:        "Unauthorized - 401",
+			token:       "wrong_token",
+			contentType: "application/json",
+			body:        validReq,
+			prepare: func() {
+				b.balanceService.EXPECT().ReverseWithdrawal(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:        "UnsupportedMediaType - 415",
+			token:       adminTokenMock,
+			contentType: "text/plain",
+			body:        validReq,
+			prepare: func() {
+				b.balanceService.EXPECT().ReverseWithdrawal(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Missing reason - 400",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        `{}`,
+			prepare: func() {
+				b.balanceService.EXPECT().ReverseWithdrawal(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Success - 200",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        validReq,
+			prepare: func() {
+				b.balanceService.EXPECT().ReverseWithdrawal(gomock.Any(), orderNumber, "order cancelled").Times(1).Return(reversal, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+		{
+			name:        "Withdrawal not found - 404",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        validReq,
+			prepare: func() {
+				b.balanceService.EXPECT().ReverseWithdrawal(gomock.Any(), orderNumber, gomock.Any()).Times(1).Return(nil, apperrors.ErrWithdrawalNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:        "Already reversed - 409",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        validReq,
+			prepare: func() {
+				b.balanceService.EXPECT().ReverseWithdrawal(gomock.Any(), orderNumber, gomock.Any()).Times(1).Return(nil, apperrors.ErrWithdrawalAlreadyReversed)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:        "InternalServerError - 500",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        validReq,
+			prepare: func() {
+				b.balanceService.EXPECT().ReverseWithdrawal(gomock.Any(), orderNumber, gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		b.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "http://localhost:8000/api/admin/balance/withdrawals/"+orderNumber+"/reversal",
+				strings.NewReader(test.body))
+			request.Header.Set("X-Admin-Token", test.token)
+			request.Header.Set("Content-Type", test.contentType)
+
+			w := httptest.NewRecorder()
+			b.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			}
+		})
+	}
+}
+
+func (b *BalanceAdminHandlersSuite) TestRecalculateTiers() {
+	testCases := []struct {
+		name         string
+		token        string
+		prepare      func()
+		expectedCode int
+	}{
+		{
+			name:  "Unauthorized - 401",
+			token: "wrong_token",
+			prepare: func() {
+				b.balanceService.EXPECT().RecalculateTiers(gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:  "Success - 200",
+			token: adminTokenMock,
+			prepare: func() {
+				b.balanceService.EXPECT().RecalculateTiers(gomock.Any()).Times(1).Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:  "InternalServerError - 500",
+			token: adminTokenMock,
+			prepare: func() {
+				b.balanceService.EXPECT().RecalculateTiers(gomock.Any()).Times(1).Return(errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		b.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "http://localhost:8000/api/admin/balance/tiers/recalculation", nil)
+			request.Header.Set("X-Admin-Token", test.token)
+
+			w := httptest.NewRecorder()
+			b.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+		})
+	}
+}
@@ -18,10 +18,22 @@ import (
 // BalanceService mockgen --build_flags=--mod=mod -destination=internal/mocks/mock_balance_service.go -package=mock github.com/msmkdenis/yap-gophermart/internal/balance/handler BalanceService
 type BalanceService interface {
 	GetByUser(ctx context.Context, userLogin string) (*dto.BalanceResponse, error)
-	Withdraw(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal) error
+	Withdraw(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal, idempotencyKey string) error
 	GetWithdrawals(ctx context.Context, userLogin string) ([]dto.WithdrawalResponse, error)
+	GetLedger(ctx context.Context, userLogin string) ([]dto.LedgerEntryResponse, error)
+	Hold(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal) (*dto.HoldResponse, error)
+	CaptureHold(ctx context.Context, userLogin string, holdID string) (*dto.HoldResponse, error)
+	ReleaseHold(ctx context.Context, userLogin string, holdID string) (*dto.HoldResponse, error)
+	GetHolds(ctx context.Context, userLogin string) ([]dto.HoldResponse, error)
+	Transfer(ctx context.Context, senderLogin string, recipientLogin string, amount decimal.Decimal) (*dto.TransferResponse, error)
+	GetTransfers(ctx context.Context, userLogin string) ([]dto.TransferResponse, error)
 }
 
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
+
 type BalanceHandler struct {
 	balanceService BalanceService
 	logger         *zap.Logger
@@ -38,13 +50,20 @@ func NewBalanceHandler(e *echo.Echo, service BalanceService, logger *zap.Logger,
 	protectedBalance := e.Group("/api/user", jwtAuth.JWTAuth())
 	protectedBalance.GET("/balance", handler.GetBalance)
 	protectedBalance.POST("/balance/withdraw", handler.Withdraw)
+	protectedBalance.GET("/balance/ledger", handler.GetLedger)
+	protectedBalance.POST("/balance/holds", handler.Hold)
+	protectedBalance.GET("/balance/holds", handler.GetHolds)
+	protectedBalance.POST("/balance/holds/:id/capture", handler.CaptureHold)
+	protectedBalance.POST("/balance/holds/:id/release", handler.ReleaseHold)
+	protectedBalance.POST("/balance/transfer", handler.Transfer)
+	protectedBalance.GET("/balance/transfers", handler.GetTransfers)
 	protectedBalance.GET("/withdrawals", handler.GetWithdrawals)
 
 	return handler
 }
 
 // @Summary       Get user balance
-// @Description   Get the current balance of the user's loyalty points account.
+// @Description   Get the balance of the user's loyalty points account: current is available for withdrawal, held is reserved by active holds.
 // @Tags          Balance API
 // @Produce       json
 // @Success       200    {object}   dto.BalanceResponse
@@ -99,14 +118,49 @@ func (h *BalanceHandler) GetWithdrawals(c echo.Context) error {
 	return c.JSON(http.StatusOK, withdrawals)
 }
 
+// @Summary       Get balance ledger
+// @Description   Get the postings of the user's loyalty points account, oldest first, with the balance after each posting.
+// @Tags          Balance API
+// @Produce       json
+// @Success       200    {array}     dto.LedgerEntryResponse
+// @Success       204
+// @Failure       401
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/balance/ledger [get]
+func (h *BalanceHandler) GetLedger(c echo.Context) error {
+	userLogin, ok := c.Get("userLogin").(string)
+	if !ok {
+		h.logger.Error("Internal server error", zap.Error(apperrors.ErrUnableToGetUserLoginFromContext))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	entries, err := h.balanceService.GetLedger(c.Request().Context(), userLogin)
+	if errors.Is(err, apperrors.ErrNoLedgerEntries) {
+		h.logger.Info("No ledger entries found", zap.Error(err))
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if err != nil {
+		h.logger.Error("Internal server error: unable to get ledger", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
 // @Summary       Withdrawal request
 // @Description   Withdraw points from the loyalty points account to pay for a new order.
+// @Description   A request repeated with the same Idempotency-Key and body returns the result of the first request without a second debit.
 // @Tags          Balance API
 // @Accept        json
-// @Param         withdrawal   body       dto.BalanceWithdrawRequest   true   "Order number and withdrawal sum."
+// @Param         Idempotency-Key   header     string                       false  "Unique key of the withdrawal request."
+// @Param         withdrawal        body       dto.BalanceWithdrawRequest   true   "Order number and withdrawal sum."
 // @Success       200
+// @Failure       400
 // @Failure       401
 // @Failure       402
+// @Failure       409
 // @Failure       422
 // @Failure       500
 // @Security      JWT
@@ -125,6 +179,12 @@ func (h *BalanceHandler) Withdraw(c echo.Context) error {
 		return c.String(http.StatusUnsupportedMediaType, msg)
 	}
 
+	idempotencyKey := c.Request().Header.Get(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		h.logger.Warn("Bad Request: idempotency key is too long")
+		return c.String(http.StatusBadRequest, "Idempotency-Key is too long")
+	}
+
 	request := new(dto.BalanceWithdrawRequest)
 	if bindErr := c.Bind(request); bindErr != nil {
 		h.logger.Warn("Unable to bind data", zap.Error(bindErr))
@@ -142,7 +202,7 @@ func (h *BalanceHandler) Withdraw(c echo.Context) error {
 		return c.String(http.StatusBadRequest, "Invalid request data")
 	}
 
-	err := h.balanceService.Withdraw(c.Request().Context(), request.OrderNumber, userLogin, request.Amount)
+	err := h.balanceService.Withdraw(c.Request().Context(), request.OrderNumber, userLogin, request.Amount, idempotencyKey)
 
 	if errors.Is(err, apperrors.ErrBadNumber) {
 		h.logger.Error("Bad number", zap.Error(err))
@@ -154,6 +214,16 @@ func (h *BalanceHandler) Withdraw(c echo.Context) error {
 		return c.NoContent(http.StatusPaymentRequired)
 	}
 
+	if errors.Is(err, apperrors.ErrIdempotencyKeyReused) {
+		h.logger.Warn("Idempotency key reused with different request", zap.Error(err))
+		return c.NoContent(http.StatusUnprocessableEntity)
+	}
+
+	if errors.Is(err, apperrors.ErrWithdrawalAlreadyExists) || errors.Is(err, apperrors.ErrIdempotencyKeyInProgress) {
+		h.logger.Warn("Conflict", zap.Error(err))
+		return c.NoContent(http.StatusConflict)
+	}
+
 	if err != nil {
 		h.logger.Error("Internal server error", zap.Error(err))
 		return c.NoContent(http.StatusInternalServerError)
@@ -161,3 +231,275 @@ func (h *BalanceHandler) Withdraw(c echo.Context) error {
 
 	return c.NoContent(http.StatusOK)
 }
+
+// @Summary       Hold points
+// @Description   Reserve points of the loyalty points account for a new order. Held points are not available until the hold is captured or released,
+// @Description   a hold that is neither captured nor released in time is released automatically.
+// @Tags          Balance API
+// @Accept        json
+// @Produce       json
+// @Param         hold   body       dto.HoldRequest   true   "Order number and sum to hold."
+// @Success       201    {object}   dto.HoldResponse
+// @Failure       400
+// @Failure       401
+// @Failure       402
+// @Failure       409
+// @Failure       415
+// @Failure       422
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/balance/holds [post]
+func (h *BalanceHandler) Hold(c echo.Context) error {
+	userLogin, ok := c.Get("userLogin").(string)
+	if !ok {
+		h.logger.Error("Internal server error", zap.Error(apperrors.ErrUnableToGetUserLoginFromContext))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	header := c.Request().Header.Get("Content-Type")
+	if header != "application/json" {
+		msg := "Content-Type header is not application/json"
+		h.logger.Error("StatusUnsupportedMediaType: " + msg)
+		return c.String(http.StatusUnsupportedMediaType, msg)
+	}
+
+	request := new(dto.HoldRequest)
+	if bindErr := c.Bind(request); bindErr != nil {
+		h.logger.Warn("Unable to bind data", zap.Error(bindErr))
+		return c.String(http.StatusBadRequest, "Bad request")
+	}
+
+	requestValidator := validator.New()
+	errRegisterValidator := requestValidator.RegisterValidation("positive_withdraw", dto.PositiveWithdraw)
+	if errRegisterValidator != nil {
+		h.logger.Warn("Unable to register validator", zap.Error(errRegisterValidator))
+	}
+
+	if validateErr := requestValidator.Struct(request); validateErr != nil {
+		h.logger.Warn("Bad Request: invalid request", zap.Error(validateErr))
+		return c.String(http.StatusBadRequest, "Invalid request data")
+	}
+
+	hold, err := h.balanceService.Hold(c.Request().Context(), request.OrderNumber, userLogin, request.Amount)
+
+	if errors.Is(err, apperrors.ErrBadNumber) {
+		h.logger.Error("Bad number", zap.Error(err))
+		return c.NoContent(http.StatusUnprocessableEntity)
+	}
+
+	if errors.Is(err, apperrors.ErrInsufficientFunds) {
+		h.logger.Warn("Bad Request: insufficient funds", zap.Error(err))
+		return c.NoContent(http.StatusPaymentRequired)
+	}
+
+	if errors.Is(err, apperrors.ErrHoldAlreadyExists) {
+		h.logger.Warn("Conflict", zap.Error(err))
+		return c.NoContent(http.StatusConflict)
+	}
+
+	if err != nil {
+		h.logger.Error("Internal server error", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, hold)
+}
+
+// @Summary       Get holds list
+// @Description   Get the holds of the user's loyalty points account, newest first.
+// @Tags          Balance API
+// @Produce       json
+// @Success       200    {array}     dto.HoldResponse
+// @Success       204
+// @Failure       401
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/balance/holds [get]
+func (h *BalanceHandler) GetHolds(c echo.Context) error {
+	userLogin, ok := c.Get("userLogin").(string)
+	if !ok {
+		h.logger.Error("Internal server error", zap.Error(apperrors.ErrUnableToGetUserLoginFromContext))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	holds, err := h.balanceService.GetHolds(c.Request().Context(), userLogin)
+	if errors.Is(err, apperrors.ErrNoHolds) {
+		h.logger.Info("No holds found", zap.Error(err))
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if err != nil {
+		h.logger.Error("Internal server error: unable to get holds", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, holds)
+}
+
+// @Summary       Capture hold
+// @Description   Withdraw the held points for the order of the hold.
+// @Tags          Balance API
+// @Produce       json
+// @Param         id     path       string   true   "Hold id."
+// @Success       200    {object}   dto.HoldResponse
+// @Failure       401
+// @Failure       404
+// @Failure       409
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/balance/holds/{id}/capture [post]
+func (h *BalanceHandler) CaptureHold(c echo.Context) error {
+	return h.finishHold(c, h.balanceService.CaptureHold)
+}
+
+// @Summary       Release hold
+// @Description   Return the held points to the available balance.
+// @Tags          Balance API
+// @Produce       json
+// @Param         id     path       string   true   "Hold id."
+// @Success       200    {object}   dto.HoldResponse
+// @Failure       401
+// @Failure       404
+// @Failure       409
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/balance/holds/{id}/release [post]
+func (h *BalanceHandler) ReleaseHold(c echo.Context) error {
+	return h.finishHold(c, h.balanceService.ReleaseHold)
+}
+
+func (h *BalanceHandler) finishHold(c echo.Context, finish func(ctx context.Context, userLogin string, holdID string) (*dto.HoldResponse, error)) error {
+	userLogin, ok := c.Get("userLogin").(string)
+	if !ok {
+		h.logger.Error("Internal server error", zap.Error(apperrors.ErrUnableToGetUserLoginFromContext))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	hold, err := finish(c.Request().Context(), userLogin, c.Param("id"))
+
+	if errors.Is(err, apperrors.ErrHoldNotFound) {
+		h.logger.Info("Hold not found", zap.Error(err))
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if errors.Is(err, apperrors.ErrHoldNotActive) || errors.Is(err, apperrors.ErrWithdrawalAlreadyExists) {
+		h.logger.Warn("Conflict", zap.Error(err))
+		return c.NoContent(http.StatusConflict)
+	}
+
+	if err != nil {
+		h.logger.Error("Internal server error", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, hold)
+}
+
+// @Summary       Transfer points
+// @Description   Transfer points of the loyalty points account to another user. The sum and the number of transfers per day are limited.
+// @Tags          Balance API
+// @Accept        json
+// @Produce       json
+// @Param         transfer   body       dto.TransferRequest   true   "Recipient login and sum to transfer."
+// @Success       200        {object}   dto.TransferResponse
+// @Failure       400
+// @Failure       401
+// @Failure       402
+// @Failure       404
+// @Failure       415
+// @Failure       422
+// @Failure       429
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/balance/transfer [post]
+func (h *BalanceHandler) Transfer(c echo.Context) error {
+	userLogin, ok := c.Get("userLogin").(string)
+	if !ok {
+		h.logger.Error("Internal server error", zap.Error(apperrors.ErrUnableToGetUserLoginFromContext))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	header := c.Request().Header.Get("Content-Type")
+	if header != "application/json" {
+		msg := "Content-Type header is not application/json"
+		h.logger.Error("StatusUnsupportedMediaType: " + msg)
+		return c.String(http.StatusUnsupportedMediaType, msg)
+	}
+
+	request := new(dto.TransferRequest)
+	if bindErr := c.Bind(request); bindErr != nil {
+		h.logger.Warn("Unable to bind data", zap.Error(bindErr))
+		return c.String(http.StatusBadRequest, "Bad request")
+	}
+
+	requestValidator := validator.New()
+	errRegisterValidator := requestValidator.RegisterValidation("positive_withdraw", dto.PositiveWithdraw)
+	if errRegisterValidator != nil {
+		h.logger.Warn("Unable to register validator", zap.Error(errRegisterValidator))
+	}
+
+	if validateErr := requestValidator.Struct(request); validateErr != nil {
+		h.logger.Warn("Bad Request: invalid request", zap.Error(validateErr))
+		return c.String(http.StatusBadRequest, "Invalid request data")
+	}
+
+	transfer, err := h.balanceService.Transfer(c.Request().Context(), userLogin, request.Recipient, request.Amount)
+
+	if errors.Is(err, apperrors.ErrTransferToSelf) {
+		h.logger.Warn("Unprocessable entity", zap.Error(err))
+		return c.NoContent(http.StatusUnprocessableEntity)
+	}
+
+	if errors.Is(err, apperrors.ErrTransferRecipientNotFound) {
+		h.logger.Info("Recipient not found", zap.Error(err))
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if errors.Is(err, apperrors.ErrInsufficientFunds) {
+		h.logger.Warn("Bad Request: insufficient funds", zap.Error(err))
+		return c.NoContent(http.StatusPaymentRequired)
+	}
+
+	if errors.Is(err, apperrors.ErrTransferLimitExceeded) {
+		h.logger.Warn("Too many requests: transfer limit exceeded", zap.Error(err))
+		return c.NoContent(http.StatusTooManyRequests)
+	}
+
+	if err != nil {
+		h.logger.Error("Internal server error", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, transfer)
+}
+
+// @Summary       Get transfers list
+// @Description   Get the transfers sent (direction out) and received (direction in) by the user, oldest first.
+// @Tags          Balance API
+// @Produce       json
+// @Success       200    {array}     dto.TransferResponse
+// @Success       204
+// @Failure       401
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/balance/transfers [get]
+func (h *BalanceHandler) GetTransfers(c echo.Context) error {
+	userLogin, ok := c.Get("userLogin").(string)
+	if !ok {
+		h.logger.Error("Internal server error", zap.Error(apperrors.ErrUnableToGetUserLoginFromContext))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	transfers, err := h.balanceService.GetTransfers(c.Request().Context(), userLogin)
+	if errors.Is(err, apperrors.ErrNoTransfers) {
+		h.logger.Info("No transfers found", zap.Error(err))
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if err != nil {
+		h.logger.Error("Internal server error: unable to get transfers", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, transfers)
+}
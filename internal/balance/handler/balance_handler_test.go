@@ -64,8 +64,14 @@ func (b *BalanceHandlersSuite) TestGetBalance() {
 	require.NoError(b.T(), errCookie)
 
 	balanceResponse := &dto.BalanceResponse{
-		Current:   decimal.NewFromInt(100),
-		Withdrawn: decimal.NewFromInt(0),
+		Current:    decimal.NewFromInt(100),
+		Withdrawn:  decimal.NewFromInt(0),
+		Held:       decimal.NewFromInt(20),
+		Tier:       "Silver",
+		Multiplier: decimal.RequireFromString("1.1"),
+		Expirations: []dto.ExpirationResponse{
+			{Amount: decimal.NewFromInt(120), ExpiresAt: time.Now().AddDate(1, 0, 0).Format(time.RFC3339)},
+		},
 	}
 
 	response, errMarshal := json.Marshal(balanceResponse)
@@ -251,6 +257,99 @@ func (b *BalanceHandlersSuite) TestGetWithdrawals() {
 	}
 }
 
+func (b *BalanceHandlersSuite) TestGetLedger() {
+	login := "awesome_login"
+
+	cookie, errCookie := b.createCookie(login)
+	require.NoError(b.T(), errCookie)
+
+	ledgerResponse := []dto.LedgerEntryResponse{
+		{
+			TransactionID: "9a1f2a4e-6f0e-4b8e-9d51-2f6f7c0e1a11",
+			Amount:        decimal.NewFromInt(500),
+			Reason:        "accrual",
+			Reference:     "12345678903",
+			Balance:       decimal.NewFromInt(500),
+			PostedAt:      time.Now().Format(time.RFC3339),
+		},
+		{
+			TransactionID: "0c7d9a8b-3e55-4d1c-a1f4-8b2d6e9f3c22",
+			Amount:        decimal.NewFromInt(-200),
+			Reason:        "withdrawal",
+			Reference:     "2377225624",
+			Balance:       decimal.NewFromInt(300),
+			PostedAt:      time.Now().Format(time.RFC3339),
+		},
+	}
+
+	response, errMarshal := json.Marshal(ledgerResponse)
+	require.NoError(b.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		cookie       *http.Cookie
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name: "Unauthorized - 401",
+			prepare: func() {
+				b.balanceService.EXPECT().GetLedger(gomock.Any(), login).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "Success - 200",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().GetLedger(gomock.Any(), login).Times(1).Return(ledgerResponse, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+		{
+			name:   "NoContent - 204",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().GetLedger(gomock.Any(), login).Times(1).Return(nil, apperrors.ErrNoLedgerEntries)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:   "InternalServerError - 500",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().GetLedger(gomock.Any(), login).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		b.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/user/balance/ledger", nil)
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+
+			w := httptest.NewRecorder()
+			b.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			} else {
+				assert.Equal(t, "", w.Body.String())
+			}
+		})
+	}
+}
+
 func (b *BalanceHandlersSuite) TestWithdraw() {
 	login := "awesome_login"
 
@@ -272,6 +371,8 @@ func (b *BalanceHandlersSuite) TestWithdraw() {
 	invalidReq, errMarshal := json.Marshal(invalidWithdrawRequest)
 	require.NoError(b.T(), errMarshal)
 
+	idempotencyKey := "8e0f3a52-5d3c-4f7e-9a43-6a1f0e2b7c19"
+
 	testCases := []struct {
 		name         string
 		method       string
@@ -287,7 +388,7 @@ func (b *BalanceHandlersSuite) TestWithdraw() {
 			method: http.MethodPost,
 			path:   "http://localhost:8000/api/user/balance/withdraw",
 			prepare: func() {
-				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 			},
 			expectedCode: http.StatusUnauthorized,
 		},
@@ -298,7 +399,7 @@ func (b *BalanceHandlersSuite) TestWithdraw() {
 			header: map[string][]string{"Content-Type": {"application/json"}},
 			cookie: cookie,
 			prepare: func() {
-				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(apperrors.ErrBadNumber)
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(apperrors.ErrBadNumber)
 			},
 			expectedCode: http.StatusUnprocessableEntity,
 			body:         string(validReq),
@@ -310,7 +411,7 @@ func (b *BalanceHandlersSuite) TestWithdraw() {
 			header: map[string][]string{"Content-Type": {""}},
 			cookie: cookie,
 			prepare: func() {
-				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 			},
 			expectedCode: http.StatusUnsupportedMediaType,
 			body:         string(validReq),
@@ -322,7 +423,7 @@ func (b *BalanceHandlersSuite) TestWithdraw() {
 			header: map[string][]string{"Content-Type": {"application/json"}},
 			cookie: cookie,
 			prepare: func() {
-				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 			},
 			expectedCode: http.StatusBadRequest,
 			body:         string(invalidReq),
@@ -334,7 +435,7 @@ func (b *BalanceHandlersSuite) TestWithdraw() {
 			header: map[string][]string{"Content-Type": {"application/json"}},
 			cookie: cookie,
 			prepare: func() {
-				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(apperrors.ErrInsufficientFunds)
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(apperrors.ErrInsufficientFunds)
 			},
 			expectedCode: http.StatusPaymentRequired,
 			body:         string(validReq),
@@ -346,7 +447,7 @@ func (b *BalanceHandlersSuite) TestWithdraw() {
 			header: map[string][]string{"Content-Type": {"application/json"}},
 			cookie: cookie,
 			prepare: func() {
-				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(errors.New("some error"))
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(errors.New("some error"))
 			},
 			expectedCode: http.StatusInternalServerError,
 			body:         string(validReq),
@@ -358,11 +459,71 @@ func (b *BalanceHandlersSuite) TestWithdraw() {
 			header: map[string][]string{"Content-Type": {"application/json"}},
 			cookie: cookie,
 			prepare: func() {
-				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			expectedCode: http.StatusOK,
+			body:         string(validReq),
+		},
+		{
+			name:   "Success with idempotency key - 200",
+			method: http.MethodPost,
+			path:   "http://localhost:8000/api/user/balance/withdraw",
+			header: map[string][]string{"Content-Type": {"application/json"}, "Idempotency-Key": {idempotencyKey}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), validWithdrawRequest.OrderNumber, login, gomock.Any(), idempotencyKey).Times(1).Return(nil)
 			},
 			expectedCode: http.StatusOK,
 			body:         string(validReq),
 		},
+		{
+			name:   "Idempotency key reused - 422",
+			method: http.MethodPost,
+			path:   "http://localhost:8000/api/user/balance/withdraw",
+			header: map[string][]string{"Content-Type": {"application/json"}, "Idempotency-Key": {idempotencyKey}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), idempotencyKey).Times(1).Return(apperrors.ErrIdempotencyKeyReused)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+			body:         string(validReq),
+		},
+		{
+			name:   "Idempotency key in progress - 409",
+			method: http.MethodPost,
+			path:   "http://localhost:8000/api/user/balance/withdraw",
+			header: map[string][]string{"Content-Type": {"application/json"}, "Idempotency-Key": {idempotencyKey}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), idempotencyKey).Times(1).Return(apperrors.ErrIdempotencyKeyInProgress)
+			},
+			expectedCode: http.StatusConflict,
+			body:         string(validReq),
+		},
+		{
+			name:   "Withdrawal for order exists - 409",
+			method: http.MethodPost,
+			path:   "http://localhost:8000/api/user/balance/withdraw",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "").Times(1).Return(apperrors.ErrWithdrawalAlreadyExists)
+			},
+			expectedCode: http.StatusConflict,
+			body:         string(validReq),
+		},
+		{
+			name:   "Idempotency key too long - 400",
+			method: http.MethodPost,
+			path:   "http://localhost:8000/api/user/balance/withdraw",
+			header: map[string][]string{"Content-Type": {"application/json"}, "Idempotency-Key": {strings.Repeat("k", 256)}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusBadRequest,
+			body:         string(validReq),
+		},
 	}
 
 	for _, test := range testCases {
@@ -376,10 +537,602 @@ func (b *BalanceHandlersSuite) TestWithdraw() {
 				request.AddCookie(test.cookie)
 			}
 			request.Header.Set("Content-Type", test.header.Get("Content-Type"))
+			if key := test.header.Get("Idempotency-Key"); key != "" {
+				request.Header.Set("Idempotency-Key", key)
+			}
+			w := httptest.NewRecorder()
+			b.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+		})
+	}
+}
+
+func (b *BalanceHandlersSuite) TestHold() {
+	login := "awesome_login"
+
+	cookie, errCookie := b.createCookie(login)
+	require.NoError(b.T(), errCookie)
+
+	validReq, errMarshal := json.Marshal(dto.HoldRequest{OrderNumber: "2377225624", Amount: decimal.NewFromInt(100)})
+	require.NoError(b.T(), errMarshal)
+
+	invalidReq, errMarshal := json.Marshal(dto.HoldRequest{OrderNumber: "2377225624", Amount: decimal.NewFromInt(-100)})
+	require.NoError(b.T(), errMarshal)
+
+	holdResponse := &dto.HoldResponse{
+		ID:          "5b0c7f0e-2b8a-4a53-9f1e-0c3d2a6e8b47",
+		OrderNumber: "2377225624",
+		Amount:      decimal.NewFromInt(100),
+		Status:      "HELD",
+		CreatedAt:   time.Now().Format(time.RFC3339),
+		ExpiresAt:   time.Now().Add(15 * time.Minute).Format(time.RFC3339),
+	}
+
+	response, errMarshal := json.Marshal(holdResponse)
+	require.NoError(b.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		header       http.Header
+		cookie       *http.Cookie
+		prepare      func()
+		expectedCode int
+		body         string
+		expectedBody []byte
+	}{
+		{
+			name: "Unauthorized - 401",
+			prepare: func() {
+				b.balanceService.EXPECT().Hold(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+			body:         string(validReq),
+		},
+		{
+			name:   "UnsupportedMediaType - 415",
+			header: map[string][]string{"Content-Type": {""}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Hold(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnsupportedMediaType,
+			body:         string(validReq),
+		},
+		{
+			name:   "Bad Request - 400",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Hold(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusBadRequest,
+			body:         string(invalidReq),
+		},
+		{
+			name:   "UnprocessableEntity - 422",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Hold(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrBadNumber)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+			body:         string(validReq),
+		},
+		{
+			name:   "PaymentRequired - 402",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Hold(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrInsufficientFunds)
+			},
+			expectedCode: http.StatusPaymentRequired,
+			body:         string(validReq),
+		},
+		{
+			name:   "Hold for order exists - 409",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Hold(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrHoldAlreadyExists)
+			},
+			expectedCode: http.StatusConflict,
+			body:         string(validReq),
+		},
+		{
+			name:   "InternalServerError - 500",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Hold(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			body:         string(validReq),
+		},
+		{
+			name:   "Created - 201",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Hold(gomock.Any(), "2377225624", login, gomock.Any()).Times(1).Return(holdResponse, nil)
+			},
+			expectedCode: http.StatusCreated,
+			body:         string(validReq),
+			expectedBody: response,
+		},
+	}
+
+	for _, test := range testCases {
+		b.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "http://localhost:8000/api/user/balance/holds", strings.NewReader(test.body))
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+			request.Header.Set("Content-Type", test.header.Get("Content-Type"))
 			w := httptest.NewRecorder()
 			b.echo.ServeHTTP(w, request)
 
 			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			}
+		})
+	}
+}
+
+func (b *BalanceHandlersSuite) TestGetHolds() {
+	login := "awesome_login"
+
+	cookie, errCookie := b.createCookie(login)
+	require.NoError(b.T(), errCookie)
+
+	holdsResponse := []dto.HoldResponse{
+		{
+			ID:          "5b0c7f0e-2b8a-4a53-9f1e-0c3d2a6e8b47",
+			OrderNumber: "2377225624",
+			Amount:      decimal.NewFromInt(100),
+			Status:      "CAPTURED",
+			CreatedAt:   time.Now().Format(time.RFC3339),
+			ExpiresAt:   time.Now().Add(15 * time.Minute).Format(time.RFC3339),
+			FinishedAt:  time.Now().Format(time.RFC3339),
+		},
+	}
+
+	response, errMarshal := json.Marshal(holdsResponse)
+	require.NoError(b.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		cookie       *http.Cookie
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name: "Unauthorized - 401",
+			prepare: func() {
+				b.balanceService.EXPECT().GetHolds(gomock.Any(), login).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "Success - 200",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().GetHolds(gomock.Any(), login).Times(1).Return(holdsResponse, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+		{
+			name:   "NoContent - 204",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().GetHolds(gomock.Any(), login).Times(1).Return(nil, apperrors.ErrNoHolds)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:   "InternalServerError - 500",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().GetHolds(gomock.Any(), login).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		b.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/user/balance/holds", nil)
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+
+			w := httptest.NewRecorder()
+			b.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			} else {
+				assert.Equal(t, "", w.Body.String())
+			}
+		})
+	}
+}
+
+func (b *BalanceHandlersSuite) TestFinishHold() {
+	login := "awesome_login"
+	holdID := "5b0c7f0e-2b8a-4a53-9f1e-0c3d2a6e8b47"
+
+	cookie, errCookie := b.createCookie(login)
+	require.NoError(b.T(), errCookie)
+
+	holdResponse := &dto.HoldResponse{
+		ID:          holdID,
+		OrderNumber: "2377225624",
+		Amount:      decimal.NewFromInt(100),
+		Status:      "RELEASED",
+		CreatedAt:   time.Now().Format(time.RFC3339),
+		ExpiresAt:   time.Now().Add(15 * time.Minute).Format(time.RFC3339),
+		FinishedAt:  time.Now().Format(time.RFC3339),
+	}
+
+	response, errMarshal := json.Marshal(holdResponse)
+	require.NoError(b.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		path         string
+		cookie       *http.Cookie
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name: "Unauthorized - 401",
+			path: "http://localhost:8000/api/user/balance/holds/" + holdID + "/capture",
+			prepare: func() {
+				b.balanceService.EXPECT().CaptureHold(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "Capture not found - 404",
+			path:   "http://localhost:8000/api/user/balance/holds/" + holdID + "/capture",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().CaptureHold(gomock.Any(), login, holdID).Times(1).Return(nil, apperrors.ErrHoldNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:   "Capture not active - 409",
+			path:   "http://localhost:8000/api/user/balance/holds/" + holdID + "/capture",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().CaptureHold(gomock.Any(), login, holdID).Times(1).Return(nil, apperrors.ErrHoldNotActive)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:   "Capture withdrawal for order exists - 409",
+			path:   "http://localhost:8000/api/user/balance/holds/" + holdID + "/capture",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().CaptureHold(gomock.Any(), login, holdID).Times(1).Return(nil, apperrors.ErrWithdrawalAlreadyExists)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:   "Capture InternalServerError - 500",
+			path:   "http://localhost:8000/api/user/balance/holds/" + holdID + "/capture",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().CaptureHold(gomock.Any(), login, holdID).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "Release not active - 409",
+			path:   "http://localhost:8000/api/user/balance/holds/" + holdID + "/release",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().ReleaseHold(gomock.Any(), login, holdID).Times(1).Return(nil, apperrors.ErrHoldNotActive)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:   "Release success - 200",
+			path:   "http://localhost:8000/api/user/balance/holds/" + holdID + "/release",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().ReleaseHold(gomock.Any(), login, holdID).Times(1).Return(holdResponse, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+	}
+
+	for _, test := range testCases {
+		b.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodPost, test.path, nil)
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+
+			w := httptest.NewRecorder()
+			b.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			}
+		})
+	}
+}
+
+func (b *BalanceHandlersSuite) TestTransfer() {
+	login := "awesome_login"
+
+	cookie, errCookie := b.createCookie(login)
+	require.NoError(b.T(), errCookie)
+
+	validReq, errMarshal := json.Marshal(dto.TransferRequest{Recipient: "another_login", Amount: decimal.NewFromInt(100)})
+	require.NoError(b.T(), errMarshal)
+
+	invalidReq, errMarshal := json.Marshal(dto.TransferRequest{Recipient: "another_login", Amount: decimal.NewFromInt(-100)})
+	require.NoError(b.T(), errMarshal)
+
+	noRecipientReq, errMarshal := json.Marshal(dto.TransferRequest{Amount: decimal.NewFromInt(100)})
+	require.NoError(b.T(), errMarshal)
+
+	transferResponse := &dto.TransferResponse{
+		ID:            "0f4c1a8e-6d0b-4c5e-a7a2-5c2d9b7f3e11",
+		Direction:     "out",
+		Sender:        login,
+		Recipient:     "another_login",
+		Amount:        decimal.NewFromInt(100),
+		TransferredAt: time.Now().Format(time.RFC3339),
+	}
+
+	response, errMarshal := json.Marshal(transferResponse)
+	require.NoError(b.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		header       http.Header
+		cookie       *http.Cookie
+		prepare      func()
+		expectedCode int
+		body         string
+		expectedBody []byte
+	}{
+		{
+			name: "Unauthorized - 401",
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+			body:         string(validReq),
+		},
+		{
+			name:   "UnsupportedMediaType - 415",
+			header: map[string][]string{"Content-Type": {""}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnsupportedMediaType,
+			body:         string(validReq),
+		},
+		{
+			name:   "Bad Request - 400",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusBadRequest,
+			body:         string(invalidReq),
+		},
+		{
+			name:   "Bad Request without recipient - 400",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusBadRequest,
+			body:         string(noRecipientReq),
+		},
+		{
+			name:   "Transfer to self - 422",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrTransferToSelf)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+			body:         string(validReq),
+		},
+		{
+			name:   "Recipient not found - 404",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrTransferRecipientNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			body:         string(validReq),
+		},
+		{
+			name:   "PaymentRequired - 402",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrInsufficientFunds)
+			},
+			expectedCode: http.StatusPaymentRequired,
+			body:         string(validReq),
+		},
+		{
+			name:   "Daily limit exceeded - 429",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrTransferLimitExceeded)
+			},
+			expectedCode: http.StatusTooManyRequests,
+			body:         string(validReq),
+		},
+		{
+			name:   "InternalServerError - 500",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			body:         string(validReq),
+		},
+		{
+			name:   "Success - 200",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().Transfer(gomock.Any(), login, "another_login", gomock.Any()).Times(1).Return(transferResponse, nil)
+			},
+			expectedCode: http.StatusOK,
+			body:         string(validReq),
+			expectedBody: response,
+		},
+	}
+
+	for _, test := range testCases {
+		b.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "http://localhost:8000/api/user/balance/transfer", strings.NewReader(test.body))
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+			request.Header.Set("Content-Type", test.header.Get("Content-Type"))
+			w := httptest.NewRecorder()
+			b.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			}
+		})
+	}
+}
+
+func (b *BalanceHandlersSuite) TestGetTransfers() {
+	login := "awesome_login"
+
+	cookie, errCookie := b.createCookie(login)
+	require.NoError(b.T(), errCookie)
+
+	transfersResponse := []dto.TransferResponse{
+		{
+			ID:            "0f4c1a8e-6d0b-4c5e-a7a2-5c2d9b7f3e11",
+			Direction:     "out",
+			Sender:        login,
+			Recipient:     "another_login",
+			Amount:        decimal.NewFromInt(100),
+			TransferredAt: time.Now().Format(time.RFC3339),
+		},
+		{
+			ID:            "8a1d2e0c-3b4f-4f6a-9c8d-7e6f5a4b3c2d",
+			Direction:     "in",
+			Sender:        "another_login",
+			Recipient:     login,
+			Amount:        decimal.NewFromInt(50),
+			TransferredAt: time.Now().Format(time.RFC3339),
+		},
+	}
+
+	response, errMarshal := json.Marshal(transfersResponse)
+	require.NoError(b.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		cookie       *http.Cookie
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name: "Unauthorized - 401",
+			prepare: func() {
+				b.balanceService.EXPECT().GetTransfers(gomock.Any(), login).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "Success - 200",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().GetTransfers(gomock.Any(), login).Times(1).Return(transfersResponse, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+		{
+			name:   "NoContent - 204",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().GetTransfers(gomock.Any(), login).Times(1).Return(nil, apperrors.ErrNoTransfers)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:   "InternalServerError - 500",
+			cookie: cookie,
+			prepare: func() {
+				b.balanceService.EXPECT().GetTransfers(gomock.Any(), login).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		b.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/user/balance/transfers", nil)
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+
+			w := httptest.NewRecorder()
+			b.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			} else {
+				assert.Equal(t, "", w.Body.String())
+			}
 		})
 	}
 }
@@ -10,14 +10,33 @@ import (
 )
 
 type BalanceResponse struct {
-	Current   decimal.Decimal `json:"current"`
-	Withdrawn decimal.Decimal `json:"withdrawn"`
+	Current     decimal.Decimal      `json:"current"`
+	Withdrawn   decimal.Decimal      `json:"withdrawn"`
+	Held        decimal.Decimal      `json:"held"`
+	Tier        string               `json:"tier"`
+	Multiplier  decimal.Decimal      `json:"multiplier"`
+	Expirations []ExpirationResponse `json:"expirations,omitempty"`
+}
+
+type ExpirationResponse struct {
+	Amount    decimal.Decimal `json:"sum"`
+	ExpiresAt string          `json:"expires_at"`
+}
+
+func MapToExpirationResponse(expiration model.Expiration) ExpirationResponse {
+	return ExpirationResponse{
+		Amount:    expiration.Amount,
+		ExpiresAt: expiration.ExpiresAt.Format(time.RFC3339),
+	}
 }
 
 func MapToBalanceResponse(balance model.Balance) BalanceResponse {
 	return BalanceResponse{
-		Current:   balance.Current,
-		Withdrawn: balance.Withdrawn,
+		Current:    balance.Current,
+		Withdrawn:  balance.Withdrawn,
+		Held:       balance.Held,
+		Tier:       balance.Tier,
+		Multiplier: balance.Multiplier,
 	}
 }
 
@@ -30,14 +49,41 @@ type WithdrawalResponse struct {
 	OrderNumber string          `json:"order"`
 	Amount      decimal.Decimal `json:"sum"`
 	ProcessedAt string          `json:"processed_at"`
+	ReversedAt  string          `json:"reversed_at,omitempty"`
 }
 
 func MapToWithdrawalResponse(withdrawal model.Withdrawal) WithdrawalResponse {
-	return WithdrawalResponse{
+	response := WithdrawalResponse{
 		OrderNumber: withdrawal.OrderNumber,
 		Amount:      withdrawal.Amount,
 		ProcessedAt: withdrawal.ProcessedAt.Format(time.RFC3339),
 	}
+	if withdrawal.ReversedAt != nil {
+		response.ReversedAt = withdrawal.ReversedAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+type WithdrawalReversalRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type WithdrawalReversalResponse struct {
+	OrderNumber string          `json:"order"`
+	UserLogin   string          `json:"user_login"`
+	Amount      decimal.Decimal `json:"sum"`
+	Reason      string          `json:"reason"`
+	ReversedAt  string          `json:"reversed_at"`
+}
+
+func MapToWithdrawalReversalResponse(reversal model.WithdrawalReversal) WithdrawalReversalResponse {
+	return WithdrawalReversalResponse{
+		OrderNumber: reversal.OrderNumber,
+		UserLogin:   reversal.UserLogin,
+		Amount:      reversal.Amount,
+		Reason:      reversal.Reason,
+		ReversedAt:  reversal.ReversedAt.Format(time.RFC3339),
+	}
 }
 
 func PositiveWithdraw(fl validator.FieldLevel) bool {
@@ -47,3 +93,130 @@ func PositiveWithdraw(fl validator.FieldLevel) bool {
 	}
 	return data.GreaterThan(decimal.Zero)
 }
+
+type LedgerEntryResponse struct {
+	TransactionID string          `json:"transaction_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Reason        string          `json:"reason"`
+	Reference     string          `json:"reference"`
+	Balance       decimal.Decimal `json:"balance"`
+	PostedAt      string          `json:"posted_at"`
+}
+
+func MapToLedgerEntryResponse(entry model.LedgerEntry) LedgerEntryResponse {
+	return LedgerEntryResponse{
+		TransactionID: entry.TransactionID,
+		Amount:        entry.Amount,
+		Reason:        entry.Reason,
+		Reference:     entry.Reference,
+		Balance:       entry.Balance,
+		PostedAt:      entry.PostedAt.Format(time.RFC3339),
+	}
+}
+
+type BalanceDriftResponse struct {
+	UserLogin       string          `json:"user_login"`
+	Current         decimal.Decimal `json:"current"`
+	LedgerCurrent   decimal.Decimal `json:"ledger_current"`
+	Withdrawn       decimal.Decimal `json:"withdrawn"`
+	LedgerWithdrawn decimal.Decimal `json:"ledger_withdrawn"`
+}
+
+func MapToBalanceDriftResponse(drift model.BalanceDrift) BalanceDriftResponse {
+	return BalanceDriftResponse{
+		UserLogin:       drift.UserLogin,
+		Current:         drift.Current,
+		LedgerCurrent:   drift.LedgerCurrent,
+		Withdrawn:       drift.Withdrawn,
+		LedgerWithdrawn: drift.LedgerWithdrawn,
+	}
+}
+
+type UnbalancedTransactionResponse struct {
+	TransactionID string          `json:"transaction_id"`
+	Sum           decimal.Decimal `json:"sum"`
+}
+
+type ConsistencyResponse struct {
+	Consistent             bool                            `json:"consistent"`
+	Drifts                 []BalanceDriftResponse          `json:"drifts"`
+	UnbalancedTransactions []UnbalancedTransactionResponse `json:"unbalanced_transactions"`
+}
+
+func MapToConsistencyResponse(drifts []model.BalanceDrift, transactions []model.UnbalancedTransaction) ConsistencyResponse {
+	response := ConsistencyResponse{
+		Consistent:             len(drifts) == 0 && len(transactions) == 0,
+		Drifts:                 make([]BalanceDriftResponse, 0, len(drifts)),
+		UnbalancedTransactions: make([]UnbalancedTransactionResponse, 0, len(transactions)),
+	}
+	for _, drift := range drifts {
+		response.Drifts = append(response.Drifts, MapToBalanceDriftResponse(drift))
+	}
+	for _, transaction := range transactions {
+		response.UnbalancedTransactions = append(response.UnbalancedTransactions, UnbalancedTransactionResponse{
+			TransactionID: transaction.TransactionID,
+			Sum:           transaction.Sum,
+		})
+	}
+	return response
+}
+
+type HoldRequest struct {
+	OrderNumber string          `json:"order" validate:"required"`
+	Amount      decimal.Decimal `json:"sum" validate:"required,positive_withdraw"`
+}
+
+type HoldResponse struct {
+	ID          string          `json:"id"`
+	OrderNumber string          `json:"order"`
+	Amount      decimal.Decimal `json:"sum"`
+	Status      string          `json:"status"`
+	CreatedAt   string          `json:"created_at"`
+	ExpiresAt   string          `json:"expires_at"`
+	FinishedAt  string          `json:"finished_at,omitempty"`
+}
+
+func MapToHoldResponse(hold model.Hold) HoldResponse {
+	response := HoldResponse{
+		ID:          hold.ID,
+		OrderNumber: hold.OrderNumber,
+		Amount:      hold.Amount,
+		Status:      hold.Status,
+		CreatedAt:   hold.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:   hold.ExpiresAt.Format(time.RFC3339),
+	}
+	if hold.FinishedAt != nil {
+		response.FinishedAt = hold.FinishedAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+type TransferRequest struct {
+	Recipient string          `json:"recipient" validate:"required"`
+	Amount    decimal.Decimal `json:"sum" validate:"required,positive_withdraw"`
+}
+
+type TransferResponse struct {
+	ID            string          `json:"id"`
+	Direction     string          `json:"direction"`
+	Sender        string          `json:"sender"`
+	Recipient     string          `json:"recipient"`
+	Amount        decimal.Decimal `json:"sum"`
+	TransferredAt string          `json:"transferred_at"`
+}
+
+// MapToTransferResponse maps the transfer as seen by the user: "out" when the user sent it, "in" when received.
+func MapToTransferResponse(transfer model.Transfer, userLogin string) TransferResponse {
+	direction := "in"
+	if transfer.SenderLogin == userLogin {
+		direction = "out"
+	}
+	return TransferResponse{
+		ID:            transfer.ID,
+		Direction:     direction,
+		Sender:        transfer.SenderLogin,
+		Recipient:     transfer.RecipientLogin,
+		Amount:        transfer.Amount,
+		TransferredAt: transfer.TransferredAt.Format(time.RFC3339),
+	}
+}
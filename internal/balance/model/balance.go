@@ -6,11 +6,27 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Balance of the user: Current is available for withdrawals and holds, Held is reserved by active holds.
+// Accruals of the user are multiplied by the Multiplier of the loyalty Tier.
 type Balance struct {
-	ID        string          `db:"id"`
-	UserLogin string          `db:"user_login"`
-	Current   decimal.Decimal `db:"current"`
-	Withdrawn decimal.Decimal `db:"withdrawn"`
+	ID         string          `db:"id"`
+	UserLogin  string          `db:"user_login"`
+	Current    decimal.Decimal `db:"current"`
+	Withdrawn  decimal.Decimal `db:"withdrawn"`
+	Held       decimal.Decimal `db:"held"`
+	Tier       string          `db:"tier"`
+	Multiplier decimal.Decimal `db:"multiplier"`
+}
+
+// DefaultTier is the loyalty tier of a new balance.
+const DefaultTier = "Bronze"
+
+// Tier is a loyalty tier rule: a user credited at least MinPoints of accruals within the tier period
+// is placed into the tier with the greatest MinPoints and gets the accruals multiplied by Multiplier.
+type Tier struct {
+	Name       string          `db:"name"`
+	MinPoints  decimal.Decimal `db:"min_points"`
+	Multiplier decimal.Decimal `db:"multiplier"`
 }
 
 type Withdrawal struct {
@@ -19,4 +35,145 @@ type Withdrawal struct {
 	UserLogin   string          `db:"user_login"`
 	Amount      decimal.Decimal `db:"sum"`
 	ProcessedAt time.Time       `db:"processed_at"`
+	ReversedAt  *time.Time      `db:"reversed_at"`
+}
+
+// WithdrawalReversal refunds a withdrawal to the balance. A withdrawal is reversed at most once.
+type WithdrawalReversal struct {
+	ID           string
+	WithdrawalID string
+	OrderNumber  string
+	UserLogin    string
+	Amount       decimal.Decimal
+	Reason       string
+	ReversedAt   time.Time
+}
+
+// Ledger posting reasons. The system leg of a transaction is posted to the account named after its reason,
+// except for a transfer whose both legs are posted to the user accounts of the sender and the recipient.
+const (
+	ReasonAccrual    = "accrual"
+	ReasonWithdrawal = "withdrawal"
+	ReasonAdjustment = "adjustment"
+	ReasonReversal   = "reversal"
+	ReasonExpiration = "expiration"
+	ReasonTransfer   = "transfer"
+	ReasonPromo      = "promo"
+	ReasonReferral   = "referral"
+)
+
+// AccountUser is the account of the user leg of a ledger transaction.
+const AccountUser = "user"
+
+// LedgerEntry is a posting to the user account with the account balance after it.
+type LedgerEntry struct {
+	ID            string          `db:"id"`
+	TransactionID string          `db:"transaction_id"`
+	Amount        decimal.Decimal `db:"amount"`
+	Reason        string          `db:"reason"`
+	Reference     string          `db:"reference"`
+	PostedAt      time.Time       `db:"posted_at"`
+	Balance       decimal.Decimal `db:"balance"`
+}
+
+// BalanceDrift is a materialized balance that differs from the sum of its ledger postings.
+// Holds are not posted to the ledger, so the ledger sum matches Current and Held together.
+type BalanceDrift struct {
+	UserLogin       string          `db:"user_login"`
+	Current         decimal.Decimal `db:"current"`
+	Held            decimal.Decimal `db:"held"`
+	LedgerCurrent   decimal.Decimal `db:"ledger_current"`
+	Withdrawn       decimal.Decimal `db:"withdrawn"`
+	LedgerWithdrawn decimal.Decimal `db:"ledger_withdrawn"`
+}
+
+// UnbalancedTransaction is a ledger transaction whose postings do not sum to zero.
+type UnbalancedTransaction struct {
+	TransactionID string          `db:"transaction_id"`
+	Sum           decimal.Decimal `db:"sum"`
+}
+
+// Results of withdrawals stored under their idempotency keys.
+const (
+	ResultWithdrawn         = "withdrawn"
+	ResultInsufficientFunds = "insufficient_funds"
+	ResultWithdrawalExists  = "withdrawal_exists"
+)
+
+// IdempotencyKey identifies a withdrawal request of the user: Fingerprint is a hash of the request body.
+type IdempotencyKey struct {
+	UserLogin   string
+	Key         string
+	Fingerprint string
+}
+
+// IdempotencyRecord is the state of a used idempotency key, Result is nil while the withdrawal is in progress.
+type IdempotencyRecord struct {
+	Fingerprint string  `db:"fingerprint"`
+	Result      *string `db:"result"`
+}
+
+// Hold statuses. A hold is created HELD and finished once: captured into a withdrawal,
+// released by the user or released on expiration.
+const (
+	HoldStatusHeld     = "HELD"
+	HoldStatusCaptured = "CAPTURED"
+	HoldStatusReleased = "RELEASED"
+	HoldStatusExpired  = "EXPIRED"
+)
+
+// Hold reserves points of the balance for an order until it is captured or released.
+type Hold struct {
+	ID           string          `db:"id"`
+	UserLogin    string          `db:"user_login"`
+	OrderNumber  string          `db:"order_number"`
+	Amount       decimal.Decimal `db:"amount"`
+	Status       string          `db:"status"`
+	WithdrawalID *string         `db:"withdrawal_id"`
+	CreatedAt    time.Time       `db:"created_at"`
+	ExpiresAt    time.Time       `db:"expires_at"`
+	FinishedAt   *time.Time      `db:"finished_at"`
+}
+
+// Lot is a credit to the balance: debits consume the lots of the user oldest first, and what remains of a lot
+// expires after the points lifetime. The remaining sums of the lots of the user match Current and Held together.
+type Lot struct {
+	ID         string          `db:"id"`
+	UserLogin  string          `db:"user_login"`
+	Reason     string          `db:"reason"`
+	Reference  string          `db:"reference"`
+	Amount     decimal.Decimal `db:"amount"`
+	Remaining  decimal.Decimal `db:"remaining"`
+	Expired    decimal.Decimal `db:"expired"`
+	CreditedAt time.Time       `db:"credited_at"`
+}
+
+// Expiration is the sum of points of the user that expire on the day of ExpiresAt.
+type Expiration struct {
+	ExpiresAt time.Time       `db:"expires_at"`
+	Amount    decimal.Decimal `db:"amount"`
+}
+
+// Transfer moves points from the balance of the sender to the balance of the recipient.
+type Transfer struct {
+	ID             string          `db:"id"`
+	SenderLogin    string          `db:"sender_login"`
+	RecipientLogin string          `db:"recipient_login"`
+	Amount         decimal.Decimal `db:"amount"`
+	TransferredAt  time.Time       `db:"transferred_at"`
+}
+
+// TransferLimits restrict the transfers of a sender within a calendar day, a zero limit is not checked.
+type TransferLimits struct {
+	DailySum   decimal.Decimal
+	DailyCount int
+}
+
+// Exceeded reports whether one more transfer of amount exceeds the limits given the transfers already made today.
+func (l TransferLimits) Exceeded(todaySum decimal.Decimal, todayCount int, amount decimal.Decimal) bool {
+	if l.DailyCount > 0 && todayCount+1 > l.DailyCount {
+		return true
+	}
+
+	return l.DailySum.IsPositive() && todaySum.Add(amount).GreaterThan(l.DailySum)
 }
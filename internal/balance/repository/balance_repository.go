@@ -4,8 +4,10 @@ import (
 	"context"
 	_ "embed"
 	"errors"
+	"time"
 
 	trmpgx "github.com/avito-tech/go-transaction-manager/pgxv5"
+	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -36,6 +38,121 @@ var blockBalanceByUser string
 //go:embed queries/bonus_accrual.sql
 var bonusAccrual string
 
+//go:embed queries/insert_accrual_credit.sql
+var insertAccrualCredit string
+
+//go:embed queries/insert_ledger_transaction.sql
+var insertLedgerTransaction string
+
+//go:embed queries/select_ledger_by_user.sql
+var selectLedgerByUser string
+
+//go:embed queries/select_balance_drifts.sql
+var selectBalanceDrifts string
+
+//go:embed queries/select_unbalanced_transactions.sql
+var selectUnbalancedTransactions string
+
+//go:embed queries/reserve_withdrawal_idempotency.sql
+var reserveWithdrawalIdempotency string
+
+//go:embed queries/select_withdrawal_idempotency.sql
+var selectWithdrawalIdempotency string
+
+//go:embed queries/complete_withdrawal_idempotency.sql
+var completeWithdrawalIdempotency string
+
+//go:embed queries/delete_withdrawal_idempotency.sql
+var deleteWithdrawalIdempotency string
+
+//go:embed queries/select_withdrawal_by_order.sql
+var selectWithdrawalByOrder string
+
+//go:embed queries/insert_withdrawal_reversal.sql
+var insertWithdrawalReversal string
+
+//go:embed queries/reverse_withdrawal_by_user.sql
+var reverseWithdrawalByUser string
+
+//go:embed queries/insert_hold.sql
+var insertHold string
+
+//go:embed queries/hold_balance_by_user.sql
+var holdBalanceByUser string
+
+//go:embed queries/capture_held_by_user.sql
+var captureHeldByUser string
+
+//go:embed queries/release_held_by_user.sql
+var releaseHeldByUser string
+
+//go:embed queries/select_hold_by_user.sql
+var selectHoldByUser string
+
+//go:embed queries/select_holds_by_user.sql
+var selectHoldsByUser string
+
+//go:embed queries/finish_hold.sql
+var finishHold string
+
+//go:embed queries/release_expired_holds.sql
+var releaseExpiredHolds string
+
+//go:embed queries/insert_lot.sql
+var insertLot string
+
+//go:embed queries/consume_lots_by_user.sql
+var consumeLotsByUser string
+
+//go:embed queries/select_users_with_expired_lots.sql
+var selectUsersWithExpiredLots string
+
+//go:embed queries/select_expired_lots_by_user.sql
+var selectExpiredLotsByUser string
+
+//go:embed queries/expire_lot.sql
+var expireLot string
+
+//go:embed queries/select_expirations_by_user.sql
+var selectExpirationsByUser string
+
+//go:embed queries/select_accrual_multiplier_by_user.sql
+var selectAccrualMultiplierByUser string
+
+//go:embed queries/update_tiers.sql
+var updateTiers string
+
+//go:embed queries/block_balances_by_users.sql
+var blockBalancesByUsers string
+
+//go:embed queries/transfer_lots.sql
+var transferLots string
+
+//go:embed queries/select_lots_by_user.sql
+var selectLotsByUser string
+
+//go:embed queries/consume_lots_for_withdrawal.sql
+var consumeLotsForWithdrawal string
+
+//go:embed queries/refund_withdrawal_lots.sql
+var refundWithdrawalLots string
+
+//go:embed queries/select_daily_transfers_by_sender.sql
+var selectDailyTransfersBySender string
+
+//go:embed queries/insert_transfer.sql
+var insertTransfer string
+
+//go:embed queries/insert_transfer_transaction.sql
+var insertTransferTransaction string
+
+//go:embed queries/select_transfers_by_user.sql
+var selectTransfersByUser string
+
+// idempotencyReservationTimeout is the time after which an idempotency key reserved by a withdrawal
+// that never completed (e.g. the service stopped) may be reserved again by a request with the same body.
+const idempotencyReservationTimeout = time.Minute
+
 type PostgresBalanceRepository struct {
 	postgresPool *db.PostgresPool
 	logger       *zap.Logger
@@ -50,15 +167,28 @@ func NewPostgresBalanceRepository(postgresPool *db.PostgresPool, logger *zap.Log
 	}
 }
 
-func (r *PostgresBalanceRepository) UpdateBalance(ctx context.Context, userLogin string, amount decimal.Decimal) error {
+// UpdateBalance posts the amount to the user account of the ledger against the system account of the reason
+// and applies it to the materialized balance: a credit adds a lot, a debit consumes the oldest lots.
+// Must be called within a transaction.
+func (r *PostgresBalanceRepository) UpdateBalance(ctx context.Context, userLogin string, amount decimal.Decimal, reason string, reference string) error {
 	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
 
 	batch := &pgx.Batch{}
 	batch.Queue(blockBalanceByUser, userLogin)
+	batch.Queue(insertLedgerTransaction, uuid.New().String(), userLogin, amount, reason, reference)
 	batch.Queue(bonusAccrual, amount, userLogin)
+	if amount.IsPositive() {
+		batch.Queue(insertLot, userLogin, reason, reference, amount)
+	} else {
+		batch.Queue(consumeLotsByUser, amount.Neg(), userLogin)
+	}
 	result := conn.SendBatch(ctx, batch)
 
 	err := result.Close()
+	if isInsufficientFunds(err) {
+		return apperrors.ErrInsufficientFunds
+	}
+
 	if err != nil {
 		return apperrors.NewValueError("close failed", utils.Caller(), err)
 	}
@@ -66,10 +196,69 @@ func (r *PostgresBalanceRepository) UpdateBalance(ctx context.Context, userLogin
 	return nil
 }
 
+// BlockBalances locks the balances of both users in the order of the logins, the same order Transfer uses,
+// so that a transaction updating both balances does not deadlock with a transfer. Must be called within a transaction.
+func (r *PostgresBalanceRepository) BlockBalances(ctx context.Context, firstLogin string, secondLogin string) error {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	if _, err := conn.Exec(ctx, blockBalancesByUsers, firstLogin, secondLogin); err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+// CreditAccrual credits the order accrual to the user balance exactly once: the credit is recorded under the order number,
+// so a repeated credit for the same order is rejected with ErrAccrualAlreadyCredited. Must be called within a transaction.
+func (r *PostgresBalanceRepository) CreditAccrual(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal) error {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	tag, err := conn.Exec(ctx, insertAccrualCredit, orderNumber, userLogin, amount)
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrAccrualAlreadyCredited
+	}
+
+	return r.UpdateBalance(ctx, userLogin, amount, model.ReasonAccrual, orderNumber)
+}
+
+// SelectAccrualMultiplier returns the multiplier of the user loyalty tier to apply to the order accrual.
+// Must be called within the transaction that credits the accrual.
+func (r *PostgresBalanceRepository) SelectAccrualMultiplier(ctx context.Context, userLogin string) (decimal.Decimal, error) {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	var multiplier decimal.Decimal
+	err := conn.QueryRow(ctx, selectAccrualMultiplierByUser, userLogin).Scan(&multiplier)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+		} else {
+			err = apperrors.NewValueError("query failed", utils.Caller(), err)
+		}
+		return decimal.Zero, err
+	}
+
+	return multiplier, nil
+}
+
+// RecalculateTiers places every user into the loyalty tier matching the raw accruals, before the tier multiplier,
+// of the orders credited within the last periodMonths and returns the number of users whose tier changed.
+func (r *PostgresBalanceRepository) RecalculateTiers(ctx context.Context, periodMonths int) (int, error) {
+	tag, err := r.postgresPool.DB.Exec(ctx, updateTiers, periodMonths)
+	if err != nil {
+		return 0, apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
 func (r *PostgresBalanceRepository) SelectByUserLogin(ctx context.Context, userLogin string) (*model.Balance, error) {
 	var balance model.Balance
 	err := r.postgresPool.DB.QueryRow(ctx, selectBalanceByUser, userLogin).
-		Scan(&balance.ID, &balance.UserLogin, &balance.Current, &balance.Withdrawn)
+		Scan(&balance.ID, &balance.UserLogin, &balance.Current, &balance.Withdrawn, &balance.Held, &balance.Tier, &balance.Multiplier)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			err = apperrors.ErrBalanceNotFound
@@ -101,7 +290,9 @@ func (r *PostgresBalanceRepository) SelectWithdrawalsByUserLogin(ctx context.Con
 	return withdrawals, nil
 }
 
-func (r *PostgresBalanceRepository) Withdraw(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal) error {
+// Withdraw debits the balance, consuming the oldest lots first. The idempotency key, if any, must be reserved by ReserveIdempotencyKey:
+// it is completed with ResultWithdrawn in the withdrawal transaction.
+func (r *PostgresBalanceRepository) Withdraw(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal, idempotencyKey *model.IdempotencyKey) error {
 	tx, err := r.postgresPool.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
 	if err != nil {
 		return apperrors.NewValueError("unable to start transaction", utils.Caller(), err)
@@ -123,20 +314,515 @@ func (r *PostgresBalanceRepository) Withdraw(ctx context.Context, orderNumber st
 		return apperrors.NewValueError("unable to prepare query", utils.Caller(), err)
 	}
 
+	post, err := tx.Prepare(ctx, "post", insertLedgerTransaction)
+	if err != nil {
+		return apperrors.NewValueError("unable to prepare query", utils.Caller(), err)
+	}
+
+	// the withdrawal id is the id of its ledger transaction
+	withdrawalID := uuid.New().String()
+
 	batch := &pgx.Batch{}
 	batch.Queue(block.Name, userLogin)
 	batch.Queue(withdraw.Name, amount, userLogin)
-	batch.Queue(saveWithdrawal.Name, orderNumber, userLogin, amount)
+	batch.Queue(saveWithdrawal.Name, withdrawalID, orderNumber, userLogin, amount)
+	batch.Queue(post.Name, withdrawalID, userLogin, amount.Neg(), model.ReasonWithdrawal, orderNumber)
+	batch.Queue(consumeLotsForWithdrawal, amount, userLogin, withdrawalID)
+	if idempotencyKey != nil {
+		batch.Queue(completeWithdrawalIdempotency, idempotencyKey.UserLogin, idempotencyKey.Key, model.ResultWithdrawn)
+	}
 	result := tx.SendBatch(ctx, batch)
 
 	err = result.Close()
+	if isInsufficientFunds(err) {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	var e *pgconn.PgError
+	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation && e.ConstraintName == "unique_withdrawal_order_number" {
+		return apperrors.ErrWithdrawalAlreadyExists
+	}
+
+	if err != nil {
+		return apperrors.NewValueError("close failed", utils.Caller(), err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return apperrors.NewValueError("commit failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+// ReverseWithdrawal refunds the withdrawal made for the order: the sum returns to current, withdrawn decreases
+// and the reversal is posted to the ledger under the reversal id. The refund is credited as lots keeping the credit dates of the lots
+// consumed by the withdrawal, or dated by the withdrawal when they were not recorded. A reversed withdrawal fails with ErrWithdrawalAlreadyReversed.
+func (r *PostgresBalanceRepository) ReverseWithdrawal(ctx context.Context, orderNumber string, reason string) (*model.WithdrawalReversal, error) {
+	tx, err := r.postgresPool.DB.Begin(ctx)
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to start transaction", utils.Caller(), err)
+	}
+	defer tx.Rollback(ctx)
+
+	var withdrawal model.Withdrawal
+	err = tx.QueryRow(ctx, selectWithdrawalByOrder, orderNumber).
+		Scan(&withdrawal.ID, &withdrawal.OrderNumber, &withdrawal.UserLogin, &withdrawal.Amount, &withdrawal.ProcessedAt, &withdrawal.ReversedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrWithdrawalNotFound
+	}
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	if withdrawal.ReversedAt != nil {
+		return nil, apperrors.ErrWithdrawalAlreadyReversed
+	}
+
+	reversal := model.WithdrawalReversal{
+		ID:           uuid.New().String(),
+		WithdrawalID: withdrawal.ID,
+		OrderNumber:  withdrawal.OrderNumber,
+		UserLogin:    withdrawal.UserLogin,
+		Amount:       withdrawal.Amount,
+		Reason:       reason,
+	}
+
+	_, err = tx.Exec(ctx, blockBalanceByUser, withdrawal.UserLogin)
+	if err != nil {
+		return nil, apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	err = tx.QueryRow(ctx, insertWithdrawalReversal, reversal.ID, reversal.WithdrawalID, reversal.Reason).Scan(&reversal.ReversedAt)
 	var e *pgconn.PgError
-	if errors.As(err, &e) && e.Code == pgerrcode.CheckViolation {
-		if e.ConstraintName == "not_negative_balance" {
-			return apperrors.ErrInsufficientFunds
+	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
+		return nil, apperrors.ErrWithdrawalAlreadyReversed
+	}
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(reverseWithdrawalByUser, reversal.Amount, reversal.UserLogin)
+	batch.Queue(insertLedgerTransaction, reversal.ID, reversal.UserLogin, reversal.Amount, model.ReasonReversal, reversal.OrderNumber)
+	batch.Queue(refundWithdrawalLots, reversal.WithdrawalID, reversal.UserLogin, reversal.OrderNumber, reversal.Amount, withdrawal.ProcessedAt)
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return nil, apperrors.NewValueError("close failed", utils.Caller(), err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, apperrors.NewValueError("commit failed", utils.Caller(), err)
+	}
+
+	return &reversal, nil
+}
+
+// ReserveIdempotencyKey reserves the key for a withdrawal and returns nil. If the key is already used,
+// its record is returned instead.
+func (r *PostgresBalanceRepository) ReserveIdempotencyKey(ctx context.Context, idempotencyKey model.IdempotencyKey) (*model.IdempotencyRecord, error) {
+	tag, err := r.postgresPool.DB.Exec(ctx, reserveWithdrawalIdempotency,
+		idempotencyKey.UserLogin, idempotencyKey.Key, idempotencyKey.Fingerprint, int(idempotencyReservationTimeout.Seconds()))
+	if err != nil {
+		return nil, apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	if tag.RowsAffected() > 0 {
+		return nil, nil
+	}
+
+	var record model.IdempotencyRecord
+	err = r.postgresPool.DB.QueryRow(ctx, selectWithdrawalIdempotency, idempotencyKey.UserLogin, idempotencyKey.Key).
+		Scan(&record.Fingerprint, &record.Result)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	return &record, nil
+}
+
+// CompleteIdempotencyKey stores the result of a withdrawal that failed without changing the balance.
+func (r *PostgresBalanceRepository) CompleteIdempotencyKey(ctx context.Context, idempotencyKey model.IdempotencyKey, result string) error {
+	_, err := r.postgresPool.DB.Exec(ctx, completeWithdrawalIdempotency, idempotencyKey.UserLogin, idempotencyKey.Key, result)
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey removes the reservation of a withdrawal that failed unexpectedly, so the request may be retried.
+func (r *PostgresBalanceRepository) ReleaseIdempotencyKey(ctx context.Context, idempotencyKey model.IdempotencyKey) error {
+	_, err := r.postgresPool.DB.Exec(ctx, deleteWithdrawalIdempotency, idempotencyKey.UserLogin, idempotencyKey.Key)
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+func (r *PostgresBalanceRepository) SelectLedgerByUserLogin(ctx context.Context, userLogin string) ([]model.LedgerEntry, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectLedgerByUser, userLogin)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	entries, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.LedgerEntry])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	if len(entries) == 0 {
+		return nil, apperrors.ErrNoLedgerEntries
+	}
+
+	return entries, nil
+}
+
+// SelectBalanceDrifts returns the balances whose current or withdrawn sum differs from their ledger postings.
+func (r *PostgresBalanceRepository) SelectBalanceDrifts(ctx context.Context) ([]model.BalanceDrift, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectBalanceDrifts)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	drifts, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.BalanceDrift])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	return drifts, nil
+}
+
+// SelectUnbalancedTransactions returns the ledger transactions whose postings do not sum to zero.
+func (r *PostgresBalanceRepository) SelectUnbalancedTransactions(ctx context.Context) ([]model.UnbalancedTransaction, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectUnbalancedTransactions)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	transactions, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.UnbalancedTransaction])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	return transactions, nil
+}
+
+// InsertHold moves the hold amount from current to held until the hold expires after ttl. Fails with ErrInsufficientFunds
+// when current is not enough and with ErrHoldAlreadyExists when the order already has an active hold.
+func (r *PostgresBalanceRepository) InsertHold(ctx context.Context, hold *model.Hold, ttl time.Duration) error {
+	tx, err := r.postgresPool.DB.Begin(ctx)
+	if err != nil {
+		return apperrors.NewValueError("unable to start transaction", utils.Caller(), err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, blockBalanceByUser, hold.UserLogin)
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	err = tx.QueryRow(ctx, insertHold, hold.ID, hold.UserLogin, hold.OrderNumber, hold.Amount, ttl.Seconds()).
+		Scan(&hold.CreatedAt, &hold.ExpiresAt)
+	var e *pgconn.PgError
+	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation && e.ConstraintName == "unique_active_hold_order_number" {
+		return apperrors.ErrHoldAlreadyExists
+	}
+	if err != nil {
+		return apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	_, err = tx.Exec(ctx, holdBalanceByUser, hold.Amount, hold.UserLogin)
+	if isInsufficientFunds(err) {
+		return apperrors.ErrInsufficientFunds
+	}
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return apperrors.NewValueError("commit failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+// CaptureHold turns the active hold of the user into a withdrawal for its order: the amount moves from held to withdrawn
+// and is posted to the ledger as a withdrawal.
+func (r *PostgresBalanceRepository) CaptureHold(ctx context.Context, userLogin string, holdID string) (*model.Hold, error) {
+	return r.finishHold(ctx, userLogin, holdID, model.HoldStatusCaptured)
+}
+
+// ReleaseHold returns the amount of the active hold of the user from held to current.
+func (r *PostgresBalanceRepository) ReleaseHold(ctx context.Context, userLogin string, holdID string) (*model.Hold, error) {
+	return r.finishHold(ctx, userLogin, holdID, model.HoldStatusReleased)
+}
+
+// ReleaseExpiredHolds releases every active hold whose expiration passed and returns their number.
+func (r *PostgresBalanceRepository) ReleaseExpiredHolds(ctx context.Context) (int, error) {
+	var released int
+	err := r.postgresPool.DB.QueryRow(ctx, releaseExpiredHolds).Scan(&released)
+	if err != nil {
+		return 0, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	return released, nil
+}
+
+func (r *PostgresBalanceRepository) SelectHoldsByUserLogin(ctx context.Context, userLogin string) ([]model.Hold, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectHoldsByUser, userLogin)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	holds, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.Hold])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	if len(holds) == 0 {
+		return nil, apperrors.ErrNoHolds
+	}
+
+	return holds, nil
+}
+
+func (r *PostgresBalanceRepository) finishHold(ctx context.Context, userLogin string, holdID string, status string) (*model.Hold, error) {
+	tx, err := r.postgresPool.DB.Begin(ctx)
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to start transaction", utils.Caller(), err)
+	}
+	defer tx.Rollback(ctx)
+
+	queryRows, err := tx.Query(ctx, selectHoldByUser, holdID, userLogin)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	hold, err := pgx.CollectOneRow(queryRows, pgx.RowToStructByPos[model.Hold])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect row", utils.Caller(), err)
+	}
+
+	// a hold past its expiration is reported as expired until it is released by ReleaseExpiredHolds
+	if hold.Status != model.HoldStatusHeld {
+		return nil, apperrors.ErrHoldNotActive
+	}
+
+	_, err = tx.Exec(ctx, blockBalanceByUser, userLogin)
+	if err != nil {
+		return nil, apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	batch := &pgx.Batch{}
+	if status == model.HoldStatusCaptured {
+		// the withdrawal id is the id of its ledger transaction
+		withdrawalID := uuid.New().String()
+		hold.WithdrawalID = &withdrawalID
+
+		batch.Queue(insertWithdrawal, withdrawalID, hold.OrderNumber, userLogin, hold.Amount)
+		batch.Queue(captureHeldByUser, hold.Amount, userLogin)
+		batch.Queue(insertLedgerTransaction, withdrawalID, userLogin, hold.Amount.Neg(), model.ReasonWithdrawal, hold.OrderNumber)
+		batch.Queue(consumeLotsForWithdrawal, hold.Amount, userLogin, withdrawalID)
+	} else {
+		batch.Queue(releaseHeldByUser, hold.Amount, userLogin)
+	}
+	batch.Queue(finishHold, hold.ID, status, hold.WithdrawalID).QueryRow(func(row pgx.Row) error {
+		return row.Scan(&hold.FinishedAt)
+	})
+	hold.Status = status
+
+	err = tx.SendBatch(ctx, batch).Close()
+	var e *pgconn.PgError
+	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation && e.ConstraintName == "unique_withdrawal_order_number" {
+		return nil, apperrors.ErrWithdrawalAlreadyExists
+	}
+	if err != nil {
+		return nil, apperrors.NewValueError("close failed", utils.Caller(), err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, apperrors.NewValueError("commit failed", utils.Caller(), err)
+	}
+
+	return &hold, nil
+}
+
+// SelectUsersWithExpiredLots returns the users with remaining points in lots credited at least lifetimeMonths months ago.
+func (r *PostgresBalanceRepository) SelectUsersWithExpiredLots(ctx context.Context, lifetimeMonths int) ([]string, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectUsersWithExpiredLots, lifetimeMonths)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	userLogins, err := pgx.CollectRows(queryRows, pgx.RowTo[string])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	return userLogins, nil
+}
+
+// SelectLotsByUserLogin returns the lots of the user with remaining points, oldest first.
+func (r *PostgresBalanceRepository) SelectLotsByUserLogin(ctx context.Context, userLogin string) ([]model.Lot, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectLotsByUser, userLogin)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	lots, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.Lot])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	return lots, nil
+}
+
+// ExpireLotsByUserLogin debits the remaining points of the lots of the user credited at least lifetimeMonths months ago,
+// oldest first, posting each lot to the ledger as an expiration, and returns the expired sum. Held points do not expire:
+// the part of the lots reserved by holds expires once the holds are released.
+func (r *PostgresBalanceRepository) ExpireLotsByUserLogin(ctx context.Context, userLogin string, lifetimeMonths int) (decimal.Decimal, error) {
+	tx, err := r.postgresPool.DB.Begin(ctx)
+	if err != nil {
+		return decimal.Zero, apperrors.NewValueError("unable to start transaction", utils.Caller(), err)
+	}
+	defer tx.Rollback(ctx)
+
+	var balance model.Balance
+	err = tx.QueryRow(ctx, blockBalanceByUser, userLogin).Scan(&balance.ID, &balance.UserLogin, &balance.Current, &balance.Withdrawn)
+	if err != nil {
+		return decimal.Zero, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	queryRows, err := tx.Query(ctx, selectExpiredLotsByUser, userLogin, lifetimeMonths)
+	if err != nil {
+		return decimal.Zero, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	lots, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.Lot])
+	if err != nil {
+		return decimal.Zero, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	expired := decimal.Zero
+	batch := &pgx.Batch{}
+	for _, lot := range lots {
+		amount := decimal.Min(lot.Remaining, balance.Current.Sub(expired))
+		if !amount.IsPositive() {
+			break
 		}
+
+		batch.Queue(expireLot, lot.ID, amount)
+		batch.Queue(insertLedgerTransaction, uuid.New().String(), userLogin, amount.Neg(), model.ReasonExpiration, lot.Reference)
+		expired = expired.Add(amount)
+	}
+
+	if expired.IsZero() {
+		return decimal.Zero, nil
+	}
+
+	batch.Queue(bonusAccrual, expired.Neg(), userLogin)
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return decimal.Zero, apperrors.NewValueError("close failed", utils.Caller(), err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return decimal.Zero, apperrors.NewValueError("commit failed", utils.Caller(), err)
+	}
+
+	return expired, nil
+}
+
+// SelectExpirationsByUserLogin returns the remaining points of the user by the day they expire on, soonest first.
+func (r *PostgresBalanceRepository) SelectExpirationsByUserLogin(ctx context.Context, userLogin string, lifetimeMonths int) ([]model.Expiration, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectExpirationsByUser, userLogin, lifetimeMonths)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	expirations, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.Expiration])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	return expirations, nil
+}
+
+// Transfer moves the amount from the sender to the recipient: the sender consumes its oldest lots, the recipient
+// is credited lots keeping the credit dates of the consumed ones, so that a transfer does not postpone the expiry,
+// and both legs are posted to the ledger as one transaction with the transfer id.
+// Both balances are locked in the order of the logins, so that opposite transfers do not deadlock.
+func (r *PostgresBalanceRepository) Transfer(ctx context.Context, transfer *model.Transfer, limits model.TransferLimits) error {
+	tx, err := r.postgresPool.DB.Begin(ctx)
+	if err != nil {
+		return apperrors.NewValueError("unable to start transaction", utils.Caller(), err)
 	}
+	defer tx.Rollback(ctx)
 
+	queryRows, err := tx.Query(ctx, blockBalancesByUsers, transfer.SenderLogin, transfer.RecipientLogin)
+	if err != nil {
+		return apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	balances, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[struct {
+		UserLogin string
+		Current   decimal.Decimal
+	}])
+	if err != nil {
+		return apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	locked := make(map[string]bool, len(balances))
+	for _, balance := range balances {
+		locked[balance.UserLogin] = true
+	}
+	if !locked[transfer.SenderLogin] {
+		return apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+	}
+	if !locked[transfer.RecipientLogin] {
+		return apperrors.ErrTransferRecipientNotFound
+	}
+
+	var todaySum decimal.Decimal
+	var todayCount int
+	err = tx.QueryRow(ctx, selectDailyTransfersBySender, transfer.SenderLogin).Scan(&todaySum, &todayCount)
+	if err != nil {
+		return apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	if limits.Exceeded(todaySum, todayCount, transfer.Amount) {
+		return apperrors.ErrTransferLimitExceeded
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(bonusAccrual, transfer.Amount.Neg(), transfer.SenderLogin)
+	batch.Queue(bonusAccrual, transfer.Amount, transfer.RecipientLogin)
+	batch.Queue(insertTransfer, transfer.ID, transfer.SenderLogin, transfer.RecipientLogin, transfer.Amount).QueryRow(func(row pgx.Row) error {
+		return row.Scan(&transfer.TransferredAt)
+	})
+	batch.Queue(insertTransferTransaction, transfer.ID, transfer.SenderLogin, transfer.RecipientLogin, transfer.Amount)
+	batch.Queue(transferLots, transfer.Amount, transfer.SenderLogin, transfer.RecipientLogin)
+
+	err = tx.SendBatch(ctx, batch).Close()
+	if isInsufficientFunds(err) {
+		return apperrors.ErrInsufficientFunds
+	}
 	if err != nil {
 		return apperrors.NewValueError("close failed", utils.Caller(), err)
 	}
@@ -148,3 +834,28 @@ func (r *PostgresBalanceRepository) Withdraw(ctx context.Context, orderNumber st
 
 	return nil
 }
+
+// SelectTransfersByUserLogin returns the transfers sent and received by the user, oldest first.
+func (r *PostgresBalanceRepository) SelectTransfersByUserLogin(ctx context.Context, userLogin string) ([]model.Transfer, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectTransfersByUser, userLogin)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	transfers, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.Transfer])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	if len(transfers) == 0 {
+		return nil, apperrors.ErrNoTransfers
+	}
+
+	return transfers, nil
+}
+
+func isInsufficientFunds(err error) bool {
+	var e *pgconn.PgError
+	return errors.As(err, &e) && e.Code == pgerrcode.CheckViolation && e.ConstraintName == "not_negative_balance"
+}
@@ -0,0 +1,889 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/balance/model"
+	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	orderModel "github.com/msmkdenis/yap-gophermart/internal/order/model"
+	orderRepository "github.com/msmkdenis/yap-gophermart/internal/order/repository"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+// defaultTiers are the loyalty tier rules the loyalty_tier migration seeds the database with.
+var defaultTiers = []model.Tier{
+	{Name: model.DefaultTier, MinPoints: decimal.Zero, Multiplier: decimal.NewFromInt(1)},
+	{Name: "Silver", MinPoints: decimal.NewFromInt(1000), Multiplier: decimal.RequireFromString("1.10")},
+	{Name: "Gold", MinPoints: decimal.NewFromInt(5000), Multiplier: decimal.RequireFromString("1.25")},
+}
+
+type memoryAccrualCredit struct {
+	OrderNumber string
+	UserLogin   string
+	Amount      decimal.Decimal
+	CreditedAt  time.Time
+}
+
+type memoryLedgerPosting struct {
+	ID            string
+	TransactionID string
+	Account       string
+	UserLogin     string
+	Amount        decimal.Decimal
+	Reason        string
+	Reference     string
+	PostedAt      time.Time
+}
+
+type memoryIdempotencyID struct {
+	UserLogin string
+	Key       string
+}
+
+type memoryIdempotencyKey struct {
+	Fingerprint string
+	Result      *string
+	ReservedAt  time.Time
+}
+
+type MemoryBalanceRepository struct {
+	storage        *db.MemoryStorage
+	balances       *db.MemoryTable[string, model.Balance]
+	withdrawals    *db.MemoryTable[string, model.Withdrawal]
+	accrualCredits *db.MemoryTable[string, memoryAccrualCredit]
+	ledger         *db.MemoryTable[string, memoryLedgerPosting]
+	idempotency    *db.MemoryTable[memoryIdempotencyID, memoryIdempotencyKey]
+	reversals      *db.MemoryTable[string, model.WithdrawalReversal]
+	holds          *db.MemoryTable[string, model.Hold]
+	lots           *db.MemoryTable[string, model.Lot]
+	transfers      *db.MemoryTable[string, model.Transfer]
+	tiers          *db.MemoryTable[string, model.Tier]
+	withdrawalLots *db.MemoryTable[string, []model.Lot]
+	orders         *db.MemoryTable[string, orderRepository.MemoryOrder]
+	logger         *zap.Logger
+	now            func() time.Time
+}
+
+func NewMemoryBalanceRepository(storage *db.MemoryStorage, logger *zap.Logger) *MemoryBalanceRepository {
+	r := &MemoryBalanceRepository{
+		storage:        storage,
+		balances:       db.Table[string, model.Balance](storage, "balance"),
+		withdrawals:    db.Table[string, model.Withdrawal](storage, "withdrawals"),
+		accrualCredits: db.Table[string, memoryAccrualCredit](storage, "accrual_credit"),
+		ledger:         db.Table[string, memoryLedgerPosting](storage, "ledger_posting"),
+		idempotency:    db.Table[memoryIdempotencyID, memoryIdempotencyKey](storage, "withdrawal_idempotency"),
+		reversals:      db.Table[string, model.WithdrawalReversal](storage, "withdrawal_reversal"),
+		holds:          db.Table[string, model.Hold](storage, "balance_hold"),
+		lots:           db.Table[string, model.Lot](storage, "balance_lot"),
+		transfers:      db.Table[string, model.Transfer](storage, "balance_transfer"),
+		tiers:          db.Table[string, model.Tier](storage, "loyalty_tier"),
+		withdrawalLots: db.Table[string, []model.Lot](storage, "withdrawal_lot"),
+		orders:         db.Table[string, orderRepository.MemoryOrder](storage, "order"),
+		logger:         logger,
+		now:            time.Now,
+	}
+
+	for _, tier := range defaultTiers {
+		if _, ok := r.tiers.Get(tier.Name); !ok {
+			r.tiers.Put(tier.Name, tier)
+		}
+	}
+
+	return r
+}
+
+// UpdateBalance posts the amount to the user account of the ledger against the system account of the reason
+// and applies it to the materialized balance: a credit adds a lot, a debit consumes the oldest lots.
+func (r *MemoryBalanceRepository) UpdateBalance(ctx context.Context, userLogin string, amount decimal.Decimal, reason string, reference string) error {
+	defer r.storage.Lock(ctx)()
+
+	return r.updateBalance(userLogin, amount, reason, reference)
+}
+
+// BlockBalances exists for parity with the Postgres repository: the memory storage is locked as a whole.
+func (r *MemoryBalanceRepository) BlockBalances(ctx context.Context, firstLogin string, secondLogin string) error {
+	defer r.storage.Lock(ctx)()
+
+	return nil
+}
+
+// CreditAccrual credits the order accrual to the user balance exactly once: the credit is recorded under the order number,
+// so a repeated credit for the same order is rejected with ErrAccrualAlreadyCredited.
+func (r *MemoryBalanceRepository) CreditAccrual(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal) error {
+	defer r.storage.Lock(ctx)()
+
+	if _, ok := r.accrualCredits.Get(orderNumber); ok {
+		return apperrors.ErrAccrualAlreadyCredited
+	}
+
+	if err := r.updateBalance(userLogin, amount, model.ReasonAccrual, orderNumber); err != nil {
+		return err
+	}
+
+	r.accrualCredits.Put(orderNumber, memoryAccrualCredit{
+		OrderNumber: orderNumber,
+		UserLogin:   userLogin,
+		Amount:      amount,
+		CreditedAt:  r.now(),
+	})
+
+	return nil
+}
+
+func (r *MemoryBalanceRepository) SelectByUserLogin(ctx context.Context, userLogin string) (*model.Balance, error) {
+	defer r.storage.Lock(ctx)()
+
+	balance, ok := r.balances.Get(userLogin)
+	if !ok {
+		return nil, apperrors.ErrBalanceNotFound
+	}
+	balance.Multiplier = r.multiplier(balance.Tier)
+
+	return &balance, nil
+}
+
+// SelectAccrualMultiplier returns the multiplier of the user loyalty tier to apply to the order accrual.
+func (r *MemoryBalanceRepository) SelectAccrualMultiplier(ctx context.Context, userLogin string) (decimal.Decimal, error) {
+	defer r.storage.Lock(ctx)()
+
+	balance, ok := r.balances.Get(userLogin)
+	if !ok {
+		return decimal.Zero, apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+	}
+
+	return r.multiplier(balance.Tier), nil
+}
+
+// RecalculateTiers places every user into the loyalty tier matching the raw accruals, before the tier multiplier,
+// of the orders credited within the last periodMonths and returns the number of users whose tier changed.
+func (r *MemoryBalanceRepository) RecalculateTiers(ctx context.Context, periodMonths int) (int, error) {
+	defer r.storage.Lock(ctx)()
+
+	since := r.now().AddDate(0, -periodMonths, 0)
+	accrued := make(map[string]decimal.Decimal)
+	for _, credit := range r.accrualCredits.Select(func(credit memoryAccrualCredit) bool {
+		return !credit.CreditedAt.Before(since)
+	}) {
+		order, ok := r.orders.Get(credit.OrderNumber)
+		if !ok || order.Status != orderModel.StatusProcessed {
+			continue
+		}
+		accrued[credit.UserLogin] = accrued[credit.UserLogin].Add(order.RawAccrual)
+	}
+
+	tiers := r.tiers.Select(func(model.Tier) bool { return true })
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MinPoints.GreaterThan(tiers[j].MinPoints)
+	})
+
+	changed := 0
+	for _, balance := range r.balances.Select(func(model.Balance) bool { return true }) {
+		for _, tier := range tiers {
+			if tier.MinPoints.GreaterThan(accrued[balance.UserLogin]) {
+				continue
+			}
+			if balance.Tier != tier.Name {
+				balance.Tier = tier.Name
+				r.balances.Put(balance.UserLogin, balance)
+				changed++
+			}
+			break
+		}
+	}
+
+	return changed, nil
+}
+
+func (r *MemoryBalanceRepository) SelectWithdrawalsByUserLogin(ctx context.Context, userLogin string) ([]model.Withdrawal, error) {
+	defer r.storage.Lock(ctx)()
+
+	withdrawals := r.withdrawals.Select(func(withdrawal model.Withdrawal) bool {
+		return withdrawal.UserLogin == userLogin
+	})
+	for i := range withdrawals {
+		if reversal, ok := r.reversals.Get(withdrawals[i].ID); ok {
+			withdrawals[i].ReversedAt = &reversal.ReversedAt
+		}
+	}
+	sort.Slice(withdrawals, func(i, j int) bool {
+		return withdrawals[i].ProcessedAt.Before(withdrawals[j].ProcessedAt)
+	})
+
+	if len(withdrawals) == 0 {
+		return nil, apperrors.ErrNoWithdrawals
+	}
+
+	return withdrawals, nil
+}
+
+// Withdraw debits the balance, consuming the oldest lots first. The idempotency key, if any, must be reserved
+// by ReserveIdempotencyKey: it is completed with ResultWithdrawn together with the withdrawal.
+func (r *MemoryBalanceRepository) Withdraw(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal, idempotencyKey *model.IdempotencyKey) error {
+	defer r.storage.Lock(ctx)()
+
+	balance, ok := r.balances.Get(userLogin)
+	if !ok {
+		return apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+	}
+
+	if balance.Current.LessThan(amount) {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	existing := r.withdrawals.Select(func(withdrawal model.Withdrawal) bool {
+		return withdrawal.OrderNumber == orderNumber
+	})
+	if len(existing) > 0 {
+		return apperrors.ErrWithdrawalAlreadyExists
+	}
+
+	balance.Current = balance.Current.Sub(amount)
+	balance.Withdrawn = balance.Withdrawn.Add(amount)
+	r.balances.Put(userLogin, balance)
+
+	// the withdrawal id is the id of its ledger transaction
+	id := uuid.New().String()
+	r.withdrawals.Put(id, model.Withdrawal{
+		ID:          id,
+		OrderNumber: orderNumber,
+		UserLogin:   userLogin,
+		Amount:      amount,
+		ProcessedAt: r.now(),
+	})
+	r.post(id, userLogin, amount.Neg(), model.ReasonWithdrawal, orderNumber)
+	r.withdrawalLots.Put(id, r.consumeLots(userLogin, amount))
+	if idempotencyKey != nil {
+		r.completeIdempotencyKey(*idempotencyKey, model.ResultWithdrawn)
+	}
+
+	return nil
+}
+
+// ReverseWithdrawal refunds the withdrawal made for the order: the sum returns to current, withdrawn decreases
+// and the reversal is posted to the ledger under the reversal id. The refund is credited as lots keeping the credit dates
+// of the lots consumed by the withdrawal. A reversed withdrawal fails with ErrWithdrawalAlreadyReversed.
+func (r *MemoryBalanceRepository) ReverseWithdrawal(ctx context.Context, orderNumber string, reason string) (*model.WithdrawalReversal, error) {
+	defer r.storage.Lock(ctx)()
+
+	withdrawals := r.withdrawals.Select(func(withdrawal model.Withdrawal) bool {
+		return withdrawal.OrderNumber == orderNumber
+	})
+	if len(withdrawals) == 0 {
+		return nil, apperrors.ErrWithdrawalNotFound
+	}
+	withdrawal := withdrawals[0]
+
+	if _, ok := r.reversals.Get(withdrawal.ID); ok {
+		return nil, apperrors.ErrWithdrawalAlreadyReversed
+	}
+
+	balance, ok := r.balances.Get(withdrawal.UserLogin)
+	if !ok {
+		return nil, apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+	}
+
+	balance.Current = balance.Current.Add(withdrawal.Amount)
+	balance.Withdrawn = balance.Withdrawn.Sub(withdrawal.Amount)
+	r.balances.Put(withdrawal.UserLogin, balance)
+
+	reversal := model.WithdrawalReversal{
+		ID:           uuid.New().String(),
+		WithdrawalID: withdrawal.ID,
+		OrderNumber:  withdrawal.OrderNumber,
+		UserLogin:    withdrawal.UserLogin,
+		Amount:       withdrawal.Amount,
+		Reason:       reason,
+		ReversedAt:   r.now(),
+	}
+	r.reversals.Put(withdrawal.ID, reversal)
+	r.post(reversal.ID, reversal.UserLogin, reversal.Amount, model.ReasonReversal, reversal.OrderNumber)
+	consumed, ok := r.withdrawalLots.Get(withdrawal.ID)
+	if !ok {
+		consumed = []model.Lot{{Amount: withdrawal.Amount, CreditedAt: withdrawal.ProcessedAt}}
+	}
+	for _, lot := range consumed {
+		r.insertLotAt(reversal.UserLogin, model.ReasonReversal, reversal.OrderNumber, lot.Amount, lot.CreditedAt)
+	}
+
+	return &reversal, nil
+}
+
+// InsertHold moves the hold amount from current to held until the hold expires after ttl. Fails with ErrInsufficientFunds
+// when current is not enough and with ErrHoldAlreadyExists when the order already has an active hold.
+func (r *MemoryBalanceRepository) InsertHold(ctx context.Context, hold *model.Hold, ttl time.Duration) error {
+	defer r.storage.Lock(ctx)()
+
+	balance, ok := r.balances.Get(hold.UserLogin)
+	if !ok {
+		return apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+	}
+
+	active := r.holds.Select(func(existing model.Hold) bool {
+		return existing.OrderNumber == hold.OrderNumber && existing.Status == model.HoldStatusHeld
+	})
+	if len(active) > 0 {
+		return apperrors.ErrHoldAlreadyExists
+	}
+
+	if balance.Current.LessThan(hold.Amount) {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	balance.Current = balance.Current.Sub(hold.Amount)
+	balance.Held = balance.Held.Add(hold.Amount)
+	r.balances.Put(hold.UserLogin, balance)
+
+	hold.Status = model.HoldStatusHeld
+	hold.CreatedAt = r.now()
+	hold.ExpiresAt = hold.CreatedAt.Add(ttl)
+	r.holds.Put(hold.ID, *hold)
+
+	return nil
+}
+
+// CaptureHold turns the active hold of the user into a withdrawal for its order: the amount moves from held to withdrawn
+// and is posted to the ledger as a withdrawal.
+func (r *MemoryBalanceRepository) CaptureHold(ctx context.Context, userLogin string, holdID string) (*model.Hold, error) {
+	defer r.storage.Lock(ctx)()
+
+	hold, balance, err := r.activeHold(userLogin, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := r.withdrawals.Select(func(withdrawal model.Withdrawal) bool {
+		return withdrawal.OrderNumber == hold.OrderNumber
+	})
+	if len(existing) > 0 {
+		return nil, apperrors.ErrWithdrawalAlreadyExists
+	}
+
+	balance.Held = balance.Held.Sub(hold.Amount)
+	balance.Withdrawn = balance.Withdrawn.Add(hold.Amount)
+	r.balances.Put(userLogin, balance)
+
+	// the withdrawal id is the id of its ledger transaction
+	withdrawalID := uuid.New().String()
+	r.withdrawals.Put(withdrawalID, model.Withdrawal{
+		ID:          withdrawalID,
+		OrderNumber: hold.OrderNumber,
+		UserLogin:   userLogin,
+		Amount:      hold.Amount,
+		ProcessedAt: r.now(),
+	})
+	r.post(withdrawalID, userLogin, hold.Amount.Neg(), model.ReasonWithdrawal, hold.OrderNumber)
+	r.withdrawalLots.Put(withdrawalID, r.consumeLots(userLogin, hold.Amount))
+
+	hold.WithdrawalID = &withdrawalID
+	r.finishHold(&hold, model.HoldStatusCaptured)
+
+	return &hold, nil
+}
+
+// ReleaseHold returns the amount of the active hold of the user from held to current.
+func (r *MemoryBalanceRepository) ReleaseHold(ctx context.Context, userLogin string, holdID string) (*model.Hold, error) {
+	defer r.storage.Lock(ctx)()
+
+	hold, balance, err := r.activeHold(userLogin, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.releaseHold(balance, &hold, model.HoldStatusReleased)
+
+	return &hold, nil
+}
+
+// ReleaseExpiredHolds releases every active hold whose expiration passed and returns their number.
+func (r *MemoryBalanceRepository) ReleaseExpiredHolds(ctx context.Context) (int, error) {
+	defer r.storage.Lock(ctx)()
+
+	now := r.now()
+	expired := r.holds.Select(func(hold model.Hold) bool {
+		return hold.Status == model.HoldStatusHeld && !hold.ExpiresAt.After(now)
+	})
+
+	for i := range expired {
+		balance, ok := r.balances.Get(expired[i].UserLogin)
+		if !ok {
+			return 0, apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+		}
+		r.releaseHold(balance, &expired[i], model.HoldStatusExpired)
+	}
+
+	return len(expired), nil
+}
+
+// SelectUsersWithExpiredLots returns the users with remaining points in lots credited at least lifetimeMonths months ago.
+func (r *MemoryBalanceRepository) SelectUsersWithExpiredLots(ctx context.Context, lifetimeMonths int) ([]string, error) {
+	defer r.storage.Lock(ctx)()
+
+	expiredBefore := r.now().AddDate(0, -lifetimeMonths, 0)
+	lots := r.lots.Select(func(lot model.Lot) bool {
+		return lot.Remaining.IsPositive() && !lot.CreditedAt.After(expiredBefore)
+	})
+
+	seen := make(map[string]bool)
+	userLogins := make([]string, 0)
+	for _, lot := range lots {
+		if !seen[lot.UserLogin] {
+			seen[lot.UserLogin] = true
+			userLogins = append(userLogins, lot.UserLogin)
+		}
+	}
+
+	return userLogins, nil
+}
+
+// ExpireLotsByUserLogin debits the remaining points of the lots of the user credited at least lifetimeMonths months ago,
+// oldest first, posting each lot to the ledger as an expiration, and returns the expired sum. Held points do not expire:
+// the part of the lots reserved by holds expires once the holds are released.
+func (r *MemoryBalanceRepository) ExpireLotsByUserLogin(ctx context.Context, userLogin string, lifetimeMonths int) (decimal.Decimal, error) {
+	defer r.storage.Lock(ctx)()
+
+	balance, ok := r.balances.Get(userLogin)
+	if !ok {
+		return decimal.Zero, apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+	}
+
+	expiredBefore := r.now().AddDate(0, -lifetimeMonths, 0)
+	lots := r.remainingLots(userLogin)
+
+	expired := decimal.Zero
+	for _, lot := range lots {
+		if lot.CreditedAt.After(expiredBefore) {
+			break
+		}
+
+		amount := decimal.Min(lot.Remaining, balance.Current.Sub(expired))
+		if !amount.IsPositive() {
+			break
+		}
+
+		lot.Remaining = lot.Remaining.Sub(amount)
+		lot.Expired = lot.Expired.Add(amount)
+		r.lots.Put(lot.ID, lot)
+		r.post(uuid.New().String(), userLogin, amount.Neg(), model.ReasonExpiration, lot.Reference)
+		expired = expired.Add(amount)
+	}
+
+	balance.Current = balance.Current.Sub(expired)
+	r.balances.Put(userLogin, balance)
+
+	return expired, nil
+}
+
+// SelectLotsByUserLogin returns the lots of the user with remaining points, oldest first.
+func (r *MemoryBalanceRepository) SelectLotsByUserLogin(ctx context.Context, userLogin string) ([]model.Lot, error) {
+	defer r.storage.Lock(ctx)()
+
+	return r.remainingLots(userLogin), nil
+}
+
+// SelectExpirationsByUserLogin returns the remaining points of the user by the day they expire on, soonest first.
+func (r *MemoryBalanceRepository) SelectExpirationsByUserLogin(ctx context.Context, userLogin string, lifetimeMonths int) ([]model.Expiration, error) {
+	defer r.storage.Lock(ctx)()
+
+	expirations := make([]model.Expiration, 0)
+	for _, lot := range r.remainingLots(userLogin) {
+		expiresAt := lot.CreditedAt.AddDate(0, lifetimeMonths, 0)
+		day := time.Date(expiresAt.Year(), expiresAt.Month(), expiresAt.Day(), 0, 0, 0, 0, expiresAt.Location())
+
+		last := len(expirations) - 1
+		if last >= 0 && expirations[last].ExpiresAt.Equal(day) {
+			expirations[last].Amount = expirations[last].Amount.Add(lot.Remaining)
+			continue
+		}
+		expirations = append(expirations, model.Expiration{ExpiresAt: day, Amount: lot.Remaining})
+	}
+
+	return expirations, nil
+}
+
+// Transfer moves the amount from the sender to the recipient: the sender consumes its oldest lots, the recipient
+// is credited lots keeping the credit dates of the consumed ones, so that a transfer does not postpone the expiry,
+// and both legs are posted to the ledger as one transaction with the transfer id.
+func (r *MemoryBalanceRepository) Transfer(ctx context.Context, transfer *model.Transfer, limits model.TransferLimits) error {
+	defer r.storage.Lock(ctx)()
+
+	sender, ok := r.balances.Get(transfer.SenderLogin)
+	if !ok {
+		return apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+	}
+
+	recipient, ok := r.balances.Get(transfer.RecipientLogin)
+	if !ok {
+		return apperrors.ErrTransferRecipientNotFound
+	}
+
+	now := r.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	sent := r.transfers.Select(func(existing model.Transfer) bool {
+		return existing.SenderLogin == transfer.SenderLogin && !existing.TransferredAt.Before(today)
+	})
+	todaySum := decimal.Zero
+	for _, existing := range sent {
+		todaySum = todaySum.Add(existing.Amount)
+	}
+
+	if limits.Exceeded(todaySum, len(sent), transfer.Amount) {
+		return apperrors.ErrTransferLimitExceeded
+	}
+
+	if sender.Current.LessThan(transfer.Amount) {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	sender.Current = sender.Current.Sub(transfer.Amount)
+	recipient.Current = recipient.Current.Add(transfer.Amount)
+	r.balances.Put(sender.UserLogin, sender)
+	r.balances.Put(recipient.UserLogin, recipient)
+
+	transfer.TransferredAt = now
+	r.transfers.Put(transfer.ID, *transfer)
+
+	for _, leg := range []memoryLedgerPosting{
+		{UserLogin: transfer.SenderLogin, Amount: transfer.Amount.Neg(), Reference: transfer.RecipientLogin},
+		{UserLogin: transfer.RecipientLogin, Amount: transfer.Amount, Reference: transfer.SenderLogin},
+	} {
+		leg.ID = uuid.New().String()
+		leg.TransactionID = transfer.ID
+		leg.Account = model.AccountUser
+		leg.Reason = model.ReasonTransfer
+		leg.PostedAt = now
+		r.ledger.Put(leg.ID, leg)
+	}
+
+	for _, consumed := range r.consumeLots(transfer.SenderLogin, transfer.Amount) {
+		r.insertLotAt(transfer.RecipientLogin, model.ReasonTransfer, transfer.SenderLogin, consumed.Amount, consumed.CreditedAt)
+	}
+
+	return nil
+}
+
+// SelectTransfersByUserLogin returns the transfers sent and received by the user, oldest first.
+func (r *MemoryBalanceRepository) SelectTransfersByUserLogin(ctx context.Context, userLogin string) ([]model.Transfer, error) {
+	defer r.storage.Lock(ctx)()
+
+	transfers := r.transfers.Select(func(transfer model.Transfer) bool {
+		return transfer.SenderLogin == userLogin || transfer.RecipientLogin == userLogin
+	})
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].TransferredAt.Before(transfers[j].TransferredAt)
+	})
+
+	if len(transfers) == 0 {
+		return nil, apperrors.ErrNoTransfers
+	}
+
+	return transfers, nil
+}
+
+func (r *MemoryBalanceRepository) SelectHoldsByUserLogin(ctx context.Context, userLogin string) ([]model.Hold, error) {
+	defer r.storage.Lock(ctx)()
+
+	holds := r.holds.Select(func(hold model.Hold) bool {
+		return hold.UserLogin == userLogin
+	})
+	sort.Slice(holds, func(i, j int) bool {
+		return holds[i].CreatedAt.After(holds[j].CreatedAt)
+	})
+
+	if len(holds) == 0 {
+		return nil, apperrors.ErrNoHolds
+	}
+
+	return holds, nil
+}
+
+// ReserveIdempotencyKey reserves the key for a withdrawal and returns nil. If the key is already used,
+// its record is returned instead.
+func (r *MemoryBalanceRepository) ReserveIdempotencyKey(ctx context.Context, idempotencyKey model.IdempotencyKey) (*model.IdempotencyRecord, error) {
+	defer r.storage.Lock(ctx)()
+
+	id := memoryIdempotencyID{UserLogin: idempotencyKey.UserLogin, Key: idempotencyKey.Key}
+	used, ok := r.idempotency.Get(id)
+	abandoned := ok && used.Result == nil && used.Fingerprint == idempotencyKey.Fingerprint &&
+		r.now().Sub(used.ReservedAt) > idempotencyReservationTimeout
+	if ok && !abandoned {
+		return &model.IdempotencyRecord{Fingerprint: used.Fingerprint, Result: used.Result}, nil
+	}
+
+	r.idempotency.Put(id, memoryIdempotencyKey{Fingerprint: idempotencyKey.Fingerprint, ReservedAt: r.now()})
+	return nil, nil
+}
+
+// CompleteIdempotencyKey stores the result of a withdrawal that failed without changing the balance.
+func (r *MemoryBalanceRepository) CompleteIdempotencyKey(ctx context.Context, idempotencyKey model.IdempotencyKey, result string) error {
+	defer r.storage.Lock(ctx)()
+
+	r.completeIdempotencyKey(idempotencyKey, result)
+	return nil
+}
+
+// ReleaseIdempotencyKey removes the reservation of a withdrawal that failed unexpectedly, so the request may be retried.
+func (r *MemoryBalanceRepository) ReleaseIdempotencyKey(ctx context.Context, idempotencyKey model.IdempotencyKey) error {
+	defer r.storage.Lock(ctx)()
+
+	id := memoryIdempotencyID{UserLogin: idempotencyKey.UserLogin, Key: idempotencyKey.Key}
+	if used, ok := r.idempotency.Get(id); ok && used.Result == nil {
+		r.idempotency.Delete(id)
+	}
+	return nil
+}
+
+func (r *MemoryBalanceRepository) SelectLedgerByUserLogin(ctx context.Context, userLogin string) ([]model.LedgerEntry, error) {
+	defer r.storage.Lock(ctx)()
+
+	postings := r.ledger.Select(func(posting memoryLedgerPosting) bool {
+		return posting.UserLogin == userLogin
+	})
+	sortPostings(postings)
+
+	if len(postings) == 0 {
+		return nil, apperrors.ErrNoLedgerEntries
+	}
+
+	entries := make([]model.LedgerEntry, 0, len(postings))
+	balance := decimal.Zero
+	for _, posting := range postings {
+		balance = balance.Add(posting.Amount)
+		entries = append(entries, model.LedgerEntry{
+			ID:            posting.ID,
+			TransactionID: posting.TransactionID,
+			Amount:        posting.Amount,
+			Reason:        posting.Reason,
+			Reference:     posting.Reference,
+			PostedAt:      posting.PostedAt,
+			Balance:       balance,
+		})
+	}
+
+	return entries, nil
+}
+
+// SelectBalanceDrifts returns the balances whose current or withdrawn sum differs from their ledger postings.
+func (r *MemoryBalanceRepository) SelectBalanceDrifts(ctx context.Context) ([]model.BalanceDrift, error) {
+	defer r.storage.Lock(ctx)()
+
+	ledgerCurrent := make(map[string]decimal.Decimal)
+	ledgerWithdrawn := make(map[string]decimal.Decimal)
+	userPostings := r.ledger.Select(func(posting memoryLedgerPosting) bool {
+		return posting.Account == model.AccountUser
+	})
+	for _, posting := range userPostings {
+		ledgerCurrent[posting.UserLogin] = ledgerCurrent[posting.UserLogin].Add(posting.Amount)
+		if posting.Reason == model.ReasonWithdrawal || posting.Reason == model.ReasonReversal {
+			ledgerWithdrawn[posting.UserLogin] = ledgerWithdrawn[posting.UserLogin].Sub(posting.Amount)
+		}
+	}
+
+	drifts := make([]model.BalanceDrift, 0)
+	for _, balance := range r.balances.Select(func(model.Balance) bool { return true }) {
+		drift := model.BalanceDrift{
+			UserLogin:       balance.UserLogin,
+			Current:         balance.Current,
+			Held:            balance.Held,
+			LedgerCurrent:   ledgerCurrent[balance.UserLogin],
+			Withdrawn:       balance.Withdrawn,
+			LedgerWithdrawn: ledgerWithdrawn[balance.UserLogin],
+		}
+		if !drift.Current.Add(drift.Held).Equal(drift.LedgerCurrent) || !drift.Withdrawn.Equal(drift.LedgerWithdrawn) {
+			drifts = append(drifts, drift)
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].UserLogin < drifts[j].UserLogin
+	})
+
+	return drifts, nil
+}
+
+// SelectUnbalancedTransactions returns the ledger transactions whose postings do not sum to zero.
+func (r *MemoryBalanceRepository) SelectUnbalancedTransactions(ctx context.Context) ([]model.UnbalancedTransaction, error) {
+	defer r.storage.Lock(ctx)()
+
+	sums := make(map[string]decimal.Decimal)
+	for _, posting := range r.ledger.Select(func(memoryLedgerPosting) bool { return true }) {
+		sums[posting.TransactionID] = sums[posting.TransactionID].Add(posting.Amount)
+	}
+
+	transactions := make([]model.UnbalancedTransaction, 0)
+	for transactionID, sum := range sums {
+		if !sum.IsZero() {
+			transactions = append(transactions, model.UnbalancedTransaction{TransactionID: transactionID, Sum: sum})
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].TransactionID < transactions[j].TransactionID
+	})
+
+	return transactions, nil
+}
+
+func (r *MemoryBalanceRepository) updateBalance(userLogin string, amount decimal.Decimal, reason string, reference string) error {
+	balance, ok := r.balances.Get(userLogin)
+	if !ok {
+		return apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+	}
+
+	balance.Current = balance.Current.Add(amount)
+	if balance.Current.IsNegative() {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	r.balances.Put(userLogin, balance)
+	r.post(uuid.New().String(), userLogin, amount, reason, reference)
+	if amount.IsPositive() {
+		r.insertLot(userLogin, reason, reference, amount)
+	} else {
+		r.consumeLots(userLogin, amount.Neg())
+	}
+
+	return nil
+}
+
+// multiplier returns the accrual multiplier of the tier, accruals of a user without a known tier are not multiplied.
+func (r *MemoryBalanceRepository) multiplier(tierName string) decimal.Decimal {
+	tier, ok := r.tiers.Get(tierName)
+	if !ok {
+		return decimal.NewFromInt(1)
+	}
+
+	return tier.Multiplier
+}
+
+// activeHold returns the hold of the user with its balance. A hold past its expiration is not active
+// even before it is released by ReleaseExpiredHolds.
+func (r *MemoryBalanceRepository) activeHold(userLogin string, holdID string) (model.Hold, model.Balance, error) {
+	hold, ok := r.holds.Get(holdID)
+	if !ok || hold.UserLogin != userLogin {
+		return model.Hold{}, model.Balance{}, apperrors.ErrHoldNotFound
+	}
+
+	if hold.Status != model.HoldStatusHeld || !hold.ExpiresAt.After(r.now()) {
+		return model.Hold{}, model.Balance{}, apperrors.ErrHoldNotActive
+	}
+
+	balance, ok := r.balances.Get(userLogin)
+	if !ok {
+		return model.Hold{}, model.Balance{}, apperrors.NewValueError("balance not found", utils.Caller(), apperrors.ErrBalanceNotFound)
+	}
+
+	return hold, balance, nil
+}
+
+func (r *MemoryBalanceRepository) releaseHold(balance model.Balance, hold *model.Hold, status string) {
+	balance.Held = balance.Held.Sub(hold.Amount)
+	balance.Current = balance.Current.Add(hold.Amount)
+	r.balances.Put(balance.UserLogin, balance)
+
+	r.finishHold(hold, status)
+}
+
+func (r *MemoryBalanceRepository) finishHold(hold *model.Hold, status string) {
+	finishedAt := r.now()
+	hold.Status = status
+	hold.FinishedAt = &finishedAt
+	r.holds.Put(hold.ID, *hold)
+}
+
+func (r *MemoryBalanceRepository) insertLot(userLogin string, reason string, reference string, amount decimal.Decimal) {
+	r.insertLotAt(userLogin, reason, reference, amount, r.now())
+}
+
+// insertLotAt inserts a lot credited at the given time, the points of the lot expire counting from it.
+func (r *MemoryBalanceRepository) insertLotAt(userLogin string, reason string, reference string, amount decimal.Decimal, creditedAt time.Time) {
+	id := uuid.New().String()
+	r.lots.Put(id, model.Lot{
+		ID:         id,
+		UserLogin:  userLogin,
+		Reason:     reason,
+		Reference:  reference,
+		Amount:     amount,
+		Remaining:  amount,
+		Expired:    decimal.Zero,
+		CreditedAt: creditedAt,
+	})
+}
+
+// consumeLots takes the amount from the remaining points of the lots of the user, oldest first,
+// and returns the consumed parts of the lots: the lots with the consumed amount.
+func (r *MemoryBalanceRepository) consumeLots(userLogin string, amount decimal.Decimal) []model.Lot {
+	var parts []model.Lot
+	for _, lot := range r.remainingLots(userLogin) {
+		if !amount.IsPositive() {
+			break
+		}
+
+		consumed := decimal.Min(lot.Remaining, amount)
+		lot.Remaining = lot.Remaining.Sub(consumed)
+		r.lots.Put(lot.ID, lot)
+		amount = amount.Sub(consumed)
+
+		part := lot
+		part.Amount = consumed
+		parts = append(parts, part)
+	}
+
+	return parts
+}
+
+// remainingLots returns the lots of the user with remaining points, oldest first.
+func (r *MemoryBalanceRepository) remainingLots(userLogin string) []model.Lot {
+	lots := r.lots.Select(func(lot model.Lot) bool {
+		return lot.UserLogin == userLogin && lot.Remaining.IsPositive()
+	})
+	sort.Slice(lots, func(i, j int) bool {
+		if lots[i].CreditedAt.Equal(lots[j].CreditedAt) {
+			return lots[i].ID < lots[j].ID
+		}
+		return lots[i].CreditedAt.Before(lots[j].CreditedAt)
+	})
+
+	return lots
+}
+
+func (r *MemoryBalanceRepository) completeIdempotencyKey(idempotencyKey model.IdempotencyKey, result string) {
+	id := memoryIdempotencyID{UserLogin: idempotencyKey.UserLogin, Key: idempotencyKey.Key}
+	used, ok := r.idempotency.Get(id)
+	if !ok || used.Result != nil {
+		return
+	}
+
+	used.Result = &result
+	r.idempotency.Put(id, used)
+}
+
+// post records a ledger transaction: the amount goes to the user account, its negation to the system account of the reason.
+func (r *MemoryBalanceRepository) post(transactionID string, userLogin string, amount decimal.Decimal, reason string, reference string) {
+	postedAt := r.now()
+	legs := []memoryLedgerPosting{
+		{Account: model.AccountUser, UserLogin: userLogin, Amount: amount},
+		{Account: reason, Amount: amount.Neg()},
+	}
+	for _, leg := range legs {
+		leg.ID = uuid.New().String()
+		leg.TransactionID = transactionID
+		leg.Reason = reason
+		leg.Reference = reference
+		leg.PostedAt = postedAt
+		r.ledger.Put(leg.ID, leg)
+	}
+}
+
+func sortPostings(postings []memoryLedgerPosting) {
+	sort.Slice(postings, func(i, j int) bool {
+		if postings[i].PostedAt.Equal(postings[j].PostedAt) {
+			return postings[i].ID < postings[j].ID
+		}
+		return postings[i].PostedAt.Before(postings[j].PostedAt)
+	})
+}
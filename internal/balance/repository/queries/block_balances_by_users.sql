@@ -0,0 +1,6 @@
+select
+    user_login, current
+from gophermart.balance
+where user_login in ($1, $2)
+order by user_login
+for update;
\ No newline at end of file
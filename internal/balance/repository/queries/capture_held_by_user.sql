@@ -0,0 +1,3 @@
+update gophermart.balance
+set held = held - $1, withdrawn = withdrawn + $1
+where user_login = $2;
\ No newline at end of file
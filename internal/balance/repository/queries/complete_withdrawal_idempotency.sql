@@ -0,0 +1,5 @@
+update gophermart.withdrawal_idempotency
+set
+    result = $3,
+    completed_at = now()
+where user_login = $1 and key = $2 and result is null;
\ No newline at end of file
@@ -0,0 +1,12 @@
+with lots as (
+    select
+        id,
+        remaining,
+        sum(remaining) over (order by credited_at, id) - remaining as preceding
+    from gophermart.balance_lot
+    where user_login = $2 and remaining > 0
+)
+update gophermart.balance_lot l
+set remaining = l.remaining - least(lots.remaining, $1::numeric - lots.preceding)
+from lots
+where l.id = lots.id and lots.preceding < $1::numeric;
\ No newline at end of file
@@ -0,0 +1,2 @@
+delete from gophermart.withdrawal_idempotency
+where user_login = $1 and key = $2 and result is null;
\ No newline at end of file
@@ -0,0 +1,3 @@
+update gophermart.balance_lot
+set remaining = remaining - $2, expired = expired + $2
+where id = $1;
\ No newline at end of file
@@ -0,0 +1,7 @@
+update gophermart.balance_hold
+set
+    status = $2,
+    withdrawal_id = $3,
+    finished_at = now()
+where id = $1
+returning finished_at;
\ No newline at end of file
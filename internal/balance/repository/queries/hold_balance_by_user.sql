@@ -0,0 +1,3 @@
+update gophermart.balance
+set current = current - $1, held = held + $1
+where user_login = $2;
\ No newline at end of file
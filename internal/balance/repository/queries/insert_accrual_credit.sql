@@ -0,0 +1,4 @@
+insert into gophermart.accrual_credit
+    (order_number, user_login, amount)
+values ($1, $2, $3)
+on conflict (order_number) do nothing;
\ No newline at end of file
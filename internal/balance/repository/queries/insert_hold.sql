@@ -0,0 +1,4 @@
+insert into gophermart.balance_hold
+    (id, user_login, order_number, amount, expires_at)
+values ($1, $2, $3, $4, now() + make_interval(secs => $5::double precision))
+returning created_at, expires_at;
\ No newline at end of file
@@ -0,0 +1,5 @@
+insert into gophermart.ledger_posting
+    (transaction_id, account, user_login, amount, reason, reference)
+values
+    ($1, 'user', $2, $3::numeric, $4, $5),
+    ($1, $4, null, -$3::numeric, $4, $5);
\ No newline at end of file
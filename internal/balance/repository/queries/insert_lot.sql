@@ -0,0 +1,3 @@
+insert into gophermart.balance_lot
+    (user_login, reason, reference, amount, remaining)
+values ($1, $2, $3, $4, $4);
\ No newline at end of file
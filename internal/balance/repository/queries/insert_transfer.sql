@@ -0,0 +1,4 @@
+insert into gophermart.balance_transfer
+    (id, sender_login, recipient_login, amount)
+values ($1, $2, $3, $4)
+returning transferred_at;
\ No newline at end of file
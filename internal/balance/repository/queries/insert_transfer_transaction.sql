@@ -0,0 +1,5 @@
+insert into gophermart.ledger_posting
+    (transaction_id, account, user_login, amount, reason, reference)
+values
+    ($1, 'user', $2, -$4::numeric, 'transfer', $3),
+    ($1, 'user', $3, $4::numeric, 'transfer', $2);
\ No newline at end of file
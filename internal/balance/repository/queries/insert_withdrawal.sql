@@ -1,3 +1,3 @@
 insert into gophermart.withdrawals
-    (order_number, user_login, sum)
-values ($1, $2, $3);
\ No newline at end of file
+    (id, order_number, user_login, sum)
+values ($1, $2, $3, $4);
\ No newline at end of file
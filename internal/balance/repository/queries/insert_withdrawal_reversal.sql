@@ -0,0 +1,4 @@
+insert into gophermart.withdrawal_reversal
+    (id, withdrawal_id, reason)
+values ($1, $2, $3)
+returning reversed_at;
\ No newline at end of file
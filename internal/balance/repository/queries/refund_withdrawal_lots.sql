@@ -0,0 +1,11 @@
+insert into gophermart.balance_lot (user_login, reason, reference, amount, remaining, credited_at)
+select $2, 'reversal', $3, amount, amount, credited_at
+from gophermart.withdrawal_lot
+where withdrawal_id = $1
+union all
+select $2, 'reversal', $3, $4::numeric, $4::numeric, $5::timestamp
+where not exists (
+    select 1
+    from gophermart.withdrawal_lot
+    where withdrawal_id = $1
+);
\ No newline at end of file
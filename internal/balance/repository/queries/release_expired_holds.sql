@@ -0,0 +1,18 @@
+with expired as (
+    update gophermart.balance_hold
+    set
+        status = 'EXPIRED',
+        finished_at = now()
+    where status = 'HELD' and expires_at <= now()
+    returning user_login, amount
+), released as (
+    update gophermart.balance b
+    set current = b.current + e.amount, held = b.held - e.amount
+    from (
+        select user_login, sum(amount) as amount
+        from expired
+        group by user_login
+    ) e
+    where b.user_login = e.user_login
+)
+select count(*) from expired;
\ No newline at end of file
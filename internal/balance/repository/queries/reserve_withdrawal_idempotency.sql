@@ -0,0 +1,8 @@
+insert into gophermart.withdrawal_idempotency as i
+    (user_login, key, fingerprint)
+values ($1, $2, $3)
+on conflict (user_login, key) do update
+set reserved_at = now()
+where i.result is null
+    and i.fingerprint = excluded.fingerprint
+    and i.reserved_at < now() - $4::integer * interval '1 second';
\ No newline at end of file
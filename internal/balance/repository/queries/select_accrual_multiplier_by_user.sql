@@ -0,0 +1,5 @@
+select
+    coalesce(t.multiplier, 1)
+from gophermart.balance b
+    left join gophermart.loyalty_tier t on t.name = b.tier
+where b.user_login = $1;
\ No newline at end of file
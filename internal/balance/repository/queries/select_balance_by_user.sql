@@ -1,7 +1,11 @@
 select
-    id,
-    user_login,
-    current,
-    withdrawn
-from gophermart.balance
-where user_login = $1;
\ No newline at end of file
+    b.id,
+    b.user_login,
+    b.current,
+    b.withdrawn,
+    b.held,
+    b.tier,
+    coalesce(t.multiplier, 1)
+from gophermart.balance b
+    left join gophermart.loyalty_tier t on t.name = b.tier
+where b.user_login = $1;
\ No newline at end of file
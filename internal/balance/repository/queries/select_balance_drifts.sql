@@ -0,0 +1,20 @@
+select
+    b.user_login,
+    b.current,
+    b.held,
+    coalesce(l.current, 0),
+    b.withdrawn,
+    coalesce(l.withdrawn, 0)
+from gophermart.balance b
+left join (
+    select
+        user_login,
+        sum(amount) as current,
+        -sum(amount) filter (where reason in ('withdrawal', 'reversal')) as withdrawn
+    from gophermart.ledger_posting
+    where user_login is not null
+    group by user_login
+) l on l.user_login = b.user_login
+where b.current + b.held <> coalesce(l.current, 0)
+    or b.withdrawn <> coalesce(l.withdrawn, 0)
+order by b.user_login;
\ No newline at end of file
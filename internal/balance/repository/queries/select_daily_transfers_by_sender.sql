@@ -0,0 +1,5 @@
+select
+    coalesce(sum(amount), 0),
+    count(*)
+from gophermart.balance_transfer
+where sender_login = $1 and transferred_at >= date_trunc('day', now());
\ No newline at end of file
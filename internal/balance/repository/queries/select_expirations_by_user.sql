@@ -0,0 +1,7 @@
+select
+    date_trunc('day', credited_at + make_interval(months => $2)) as expires_at,
+    sum(remaining) as amount
+from gophermart.balance_lot
+where user_login = $1 and remaining > 0
+group by 1
+order by 1;
\ No newline at end of file
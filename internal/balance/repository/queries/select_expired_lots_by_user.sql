@@ -0,0 +1,13 @@
+select
+    id,
+    user_login,
+    reason,
+    reference,
+    amount,
+    remaining,
+    expired,
+    credited_at
+from gophermart.balance_lot
+where user_login = $1 and remaining > 0 and credited_at <= now() - make_interval(months => $2)
+order by credited_at, id
+for update;
\ No newline at end of file
@@ -0,0 +1,16 @@
+select
+    id,
+    user_login,
+    order_number,
+    amount,
+    case
+        when status = 'HELD' and expires_at <= now() then 'EXPIRED'
+        else status
+    end,
+    withdrawal_id,
+    created_at,
+    expires_at,
+    finished_at
+from gophermart.balance_hold
+where id = $1 and user_login = $2
+for update;
\ No newline at end of file
@@ -0,0 +1,13 @@
+select
+    id,
+    user_login,
+    order_number,
+    amount,
+    status,
+    withdrawal_id,
+    created_at,
+    expires_at,
+    finished_at
+from gophermart.balance_hold
+where user_login = $1
+order by created_at desc;
\ No newline at end of file
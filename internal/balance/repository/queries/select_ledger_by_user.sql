@@ -0,0 +1,11 @@
+select
+    id,
+    transaction_id,
+    amount,
+    reason,
+    reference,
+    posted_at,
+    sum(amount) over (order by posted_at, id)
+from gophermart.ledger_posting
+where user_login = $1
+order by posted_at, id;
\ No newline at end of file
@@ -0,0 +1,12 @@
+select
+    id,
+    user_login,
+    reason,
+    reference,
+    amount,
+    remaining,
+    expired,
+    credited_at
+from gophermart.balance_lot
+where user_login = $1 and remaining > 0
+order by credited_at, id;
\ No newline at end of file
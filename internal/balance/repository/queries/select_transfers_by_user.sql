@@ -0,0 +1,9 @@
+select
+    id,
+    sender_login,
+    recipient_login,
+    amount,
+    transferred_at
+from gophermart.balance_transfer
+where sender_login = $1 or recipient_login = $1
+order by transferred_at;
\ No newline at end of file
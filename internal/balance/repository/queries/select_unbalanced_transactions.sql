@@ -0,0 +1,7 @@
+select
+    transaction_id,
+    sum(amount)
+from gophermart.ledger_posting
+group by transaction_id
+having sum(amount) <> 0
+order by transaction_id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+select distinct user_login
+from gophermart.balance_lot
+where remaining > 0 and credited_at <= now() - make_interval(months => $1);
\ No newline at end of file
@@ -0,0 +1,11 @@
+select
+    w.id,
+    w.order_number,
+    w.user_login,
+    w.sum,
+    w.processed_at,
+    r.reversed_at
+from gophermart.withdrawals w
+left join gophermart.withdrawal_reversal r on r.withdrawal_id = w.id
+where w.order_number = $1
+for update of w;
\ No newline at end of file
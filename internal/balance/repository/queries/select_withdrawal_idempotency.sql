@@ -0,0 +1,5 @@
+select
+    fingerprint,
+    result
+from gophermart.withdrawal_idempotency
+where user_login = $1 and key = $2;
\ No newline at end of file
@@ -1,8 +1,10 @@
 select
-    id,
-    order_number,
-    user_login,
-    sum,
-    processed_at
-from gophermart.withdrawals
-where user_login = $1;
\ No newline at end of file
+    w.id,
+    w.order_number,
+    w.user_login,
+    w.sum,
+    w.processed_at,
+    r.reversed_at
+from gophermart.withdrawals w
+left join gophermart.withdrawal_reversal r on r.withdrawal_id = w.id
+where w.user_login = $1;
\ No newline at end of file
@@ -0,0 +1,19 @@
+with lots as (
+    select
+        id,
+        remaining,
+        credited_at,
+        sum(remaining) over (order by credited_at, id) - remaining as preceding
+    from gophermart.balance_lot
+    where user_login = $2 and remaining > 0
+),
+consumed as (
+    update gophermart.balance_lot l
+    set remaining = l.remaining - least(lots.remaining, $1::numeric - lots.preceding)
+    from lots
+    where l.id = lots.id and lots.preceding < $1::numeric
+    returning lots.credited_at, least(lots.remaining, $1::numeric - lots.preceding) as amount
+)
+insert into gophermart.balance_lot (user_login, reason, reference, amount, remaining, credited_at)
+select $3, 'transfer', $2, amount, amount, credited_at
+from consumed;
\ No newline at end of file
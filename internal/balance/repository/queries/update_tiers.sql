@@ -0,0 +1,16 @@
+update gophermart.balance b
+set tier = t.name
+from gophermart.loyalty_tier t
+where t.min_points = (
+    select max(rules.min_points)
+    from gophermart.loyalty_tier rules
+    where rules.min_points <= (
+        select coalesce(sum(o.raw_accrual), 0)
+        from gophermart.accrual_credit c
+        join gophermart.order o on o.number = c.order_number
+        where c.user_login = b.user_login
+            and o.status = 'PROCESSED'
+            and c.credited_at >= now() - make_interval(months => $1)
+    )
+)
+    and b.tier <> t.name;
\ No newline at end of file
@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ShiraazMoollatjie/goluhn"
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 
@@ -14,21 +19,53 @@ import (
 	"github.com/msmkdenis/yap-gophermart/internal/utils"
 )
 
+// tierPeriodMonths is the rolling period whose accruals place the user into a loyalty tier.
+const tierPeriodMonths = 12
+
 type BalanceRepository interface {
 	SelectByUserLogin(ctx context.Context, userLogin string) (*model.Balance, error)
-	Withdraw(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal) error
+	Withdraw(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal, idempotencyKey *model.IdempotencyKey) error
+	ReserveIdempotencyKey(ctx context.Context, idempotencyKey model.IdempotencyKey) (*model.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, idempotencyKey model.IdempotencyKey, result string) error
+	ReleaseIdempotencyKey(ctx context.Context, idempotencyKey model.IdempotencyKey) error
 	SelectWithdrawalsByUserLogin(ctx context.Context, userLogin string) ([]model.Withdrawal, error)
+	ReverseWithdrawal(ctx context.Context, orderNumber string, reason string) (*model.WithdrawalReversal, error)
+	InsertHold(ctx context.Context, hold *model.Hold, ttl time.Duration) error
+	CaptureHold(ctx context.Context, userLogin string, holdID string) (*model.Hold, error)
+	ReleaseHold(ctx context.Context, userLogin string, holdID string) (*model.Hold, error)
+	SelectHoldsByUserLogin(ctx context.Context, userLogin string) ([]model.Hold, error)
+	ReleaseExpiredHolds(ctx context.Context) (int, error)
+	SelectUsersWithExpiredLots(ctx context.Context, lifetimeMonths int) ([]string, error)
+	ExpireLotsByUserLogin(ctx context.Context, userLogin string, lifetimeMonths int) (decimal.Decimal, error)
+	SelectExpirationsByUserLogin(ctx context.Context, userLogin string, lifetimeMonths int) ([]model.Expiration, error)
+	RecalculateTiers(ctx context.Context, periodMonths int) (int, error)
+	Transfer(ctx context.Context, transfer *model.Transfer, limits model.TransferLimits) error
+	SelectTransfersByUserLogin(ctx context.Context, userLogin string) ([]model.Transfer, error)
+	SelectLedgerByUserLogin(ctx context.Context, userLogin string) ([]model.LedgerEntry, error)
+	SelectBalanceDrifts(ctx context.Context) ([]model.BalanceDrift, error)
+	SelectUnbalancedTransactions(ctx context.Context) ([]model.UnbalancedTransaction, error)
+}
+
+// Config configures the balance service. HoldTTL is the time after which a hold that was neither captured
+// nor released is released automatically, credited points expire after PointsLifetimeMonths (never when it is 0),
+// TransferLimits caps the points a user can send to other users per day.
+type Config struct {
+	HoldTTL              time.Duration
+	PointsLifetimeMonths int
+	TransferLimits       model.TransferLimits
 }
 
 type BalanceUseCase struct {
 	repository BalanceRepository
 	logger     *zap.Logger
+	config     Config
 }
 
-func NewBalanceService(repository BalanceRepository, logger *zap.Logger) *BalanceUseCase {
+func NewBalanceService(repository BalanceRepository, logger *zap.Logger, config Config) *BalanceUseCase {
 	return &BalanceUseCase{
 		repository: repository,
 		logger:     logger,
+		config:     config,
 	}
 }
 
@@ -40,21 +77,73 @@ func (b *BalanceUseCase) GetByUser(ctx context.Context, userLogin string) (*dto.
 
 	balancerResponse := dto.MapToBalanceResponse(*balance)
 
+	if b.config.PointsLifetimeMonths > 0 {
+		expirations, errExpirations := b.repository.SelectExpirationsByUserLogin(ctx, userLogin, b.config.PointsLifetimeMonths)
+		if errExpirations != nil {
+			return nil, fmt.Errorf("%s %w", utils.Caller(), errExpirations)
+		}
+
+		for _, v := range expirations {
+			balancerResponse.Expirations = append(balancerResponse.Expirations, dto.MapToExpirationResponse(v))
+		}
+	}
+
 	return &balancerResponse, nil
 }
 
-func (b *BalanceUseCase) Withdraw(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal) error {
+// Withdraw debits the balance. A request with an idempotency key is executed once: a replay with the same order number
+// and amount returns the result of the first request, a replay with another body fails with ErrIdempotencyKeyReused.
+func (b *BalanceUseCase) Withdraw(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal, idempotencyKey string) error {
 	errGoLuhn := goluhn.Validate(orderNumber)
 	if errGoLuhn != nil {
 		return apperrors.ErrBadNumber
 	}
 
-	err := b.repository.Withdraw(ctx, orderNumber, userLogin, amount)
+	if idempotencyKey == "" {
+		err := b.repository.Withdraw(ctx, orderNumber, userLogin, amount, nil)
+		if err != nil {
+			return fmt.Errorf("%s %w", utils.Caller(), err)
+		}
+		return nil
+	}
+
+	key := model.IdempotencyKey{
+		UserLogin:   userLogin,
+		Key:         idempotencyKey,
+		Fingerprint: withdrawalFingerprint(orderNumber, amount),
+	}
+
+	record, err := b.repository.ReserveIdempotencyKey(ctx, key)
 	if err != nil {
 		return fmt.Errorf("%s %w", utils.Caller(), err)
 	}
 
-	return nil
+	if record != nil {
+		return replayWithdrawal(key, *record)
+	}
+
+	err = b.repository.Withdraw(ctx, orderNumber, userLogin, amount, &key)
+	if err == nil {
+		return nil
+	}
+
+	var result string
+	switch {
+	case errors.Is(err, apperrors.ErrInsufficientFunds):
+		result = model.ResultInsufficientFunds
+	case errors.Is(err, apperrors.ErrWithdrawalAlreadyExists):
+		result = model.ResultWithdrawalExists
+	}
+
+	if result != "" {
+		if errComplete := b.repository.CompleteIdempotencyKey(ctx, key, result); errComplete != nil {
+			b.logger.Error("Unable to store withdrawal result", zap.String("idempotency_key", key.Key), zap.Error(errComplete))
+		}
+	} else if errRelease := b.repository.ReleaseIdempotencyKey(ctx, key); errRelease != nil {
+		b.logger.Error("Unable to release idempotency key", zap.String("idempotency_key", key.Key), zap.Error(errRelease))
+	}
+
+	return fmt.Errorf("%s %w", utils.Caller(), err)
 }
 
 func (b *BalanceUseCase) GetWithdrawals(ctx context.Context, userLogin string) ([]dto.WithdrawalResponse, error) {
@@ -70,3 +159,249 @@ func (b *BalanceUseCase) GetWithdrawals(ctx context.Context, userLogin string) (
 
 	return withdrawalResponses, nil
 }
+
+// Hold reserves points of the user for the order: they are not available until the hold is captured or released.
+func (b *BalanceUseCase) Hold(ctx context.Context, orderNumber string, userLogin string, amount decimal.Decimal) (*dto.HoldResponse, error) {
+	errGoLuhn := goluhn.Validate(orderNumber)
+	if errGoLuhn != nil {
+		return nil, apperrors.ErrBadNumber
+	}
+
+	hold := model.Hold{
+		ID:          uuid.New().String(),
+		UserLogin:   userLogin,
+		OrderNumber: orderNumber,
+		Amount:      amount,
+		Status:      model.HoldStatusHeld,
+	}
+
+	err := b.repository.InsertHold(ctx, &hold, b.config.HoldTTL)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	holdResponse := dto.MapToHoldResponse(hold)
+
+	return &holdResponse, nil
+}
+
+// CaptureHold withdraws the held points for the order of the hold.
+func (b *BalanceUseCase) CaptureHold(ctx context.Context, userLogin string, holdID string) (*dto.HoldResponse, error) {
+	if _, errParse := uuid.Parse(holdID); errParse != nil {
+		return nil, apperrors.ErrHoldNotFound
+	}
+
+	hold, err := b.repository.CaptureHold(ctx, userLogin, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	holdResponse := dto.MapToHoldResponse(*hold)
+
+	return &holdResponse, nil
+}
+
+// ReleaseHold returns the held points to the available balance.
+func (b *BalanceUseCase) ReleaseHold(ctx context.Context, userLogin string, holdID string) (*dto.HoldResponse, error) {
+	if _, errParse := uuid.Parse(holdID); errParse != nil {
+		return nil, apperrors.ErrHoldNotFound
+	}
+
+	hold, err := b.repository.ReleaseHold(ctx, userLogin, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	holdResponse := dto.MapToHoldResponse(*hold)
+
+	return &holdResponse, nil
+}
+
+func (b *BalanceUseCase) GetHolds(ctx context.Context, userLogin string) ([]dto.HoldResponse, error) {
+	holds, err := b.repository.SelectHoldsByUserLogin(ctx, userLogin)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	holdResponses := make([]dto.HoldResponse, 0, len(holds))
+	for _, v := range holds {
+		holdResponses = append(holdResponses, dto.MapToHoldResponse(v))
+	}
+
+	return holdResponses, nil
+}
+
+// Transfer moves points from the sender balance to the balance of another user within the daily transfer limits.
+func (b *BalanceUseCase) Transfer(ctx context.Context, senderLogin string, recipientLogin string, amount decimal.Decimal) (*dto.TransferResponse, error) {
+	if senderLogin == recipientLogin {
+		return nil, apperrors.ErrTransferToSelf
+	}
+
+	transfer := model.Transfer{
+		ID:             uuid.New().String(),
+		SenderLogin:    senderLogin,
+		RecipientLogin: recipientLogin,
+		Amount:         amount,
+	}
+
+	err := b.repository.Transfer(ctx, &transfer, b.config.TransferLimits)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	b.logger.Info("Points transferred",
+		zap.String("sender", senderLogin), zap.String("recipient", recipientLogin), zap.String("sum", amount.String()))
+
+	transferResponse := dto.MapToTransferResponse(transfer, senderLogin)
+
+	return &transferResponse, nil
+}
+
+func (b *BalanceUseCase) GetTransfers(ctx context.Context, userLogin string) ([]dto.TransferResponse, error) {
+	transfers, err := b.repository.SelectTransfersByUserLogin(ctx, userLogin)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	transferResponses := make([]dto.TransferResponse, 0, len(transfers))
+	for _, v := range transfers {
+		transferResponses = append(transferResponses, dto.MapToTransferResponse(v, userLogin))
+	}
+
+	return transferResponses, nil
+}
+
+// ReleaseExpiredHolds returns the points of the holds that were neither captured nor released in time to the balances.
+func (b *BalanceUseCase) ReleaseExpiredHolds(ctx context.Context) error {
+	released, err := b.repository.ReleaseExpiredHolds(ctx)
+	if err != nil {
+		return fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	if released > 0 {
+		b.logger.Info("Expired holds released", zap.Int("released", released))
+	}
+
+	return nil
+}
+
+// ExpirePoints debits the points that were credited more than the points lifetime ago. A user whose points
+// fail to expire is skipped until the next run.
+func (b *BalanceUseCase) ExpirePoints(ctx context.Context) error {
+	if b.config.PointsLifetimeMonths == 0 {
+		return nil
+	}
+
+	userLogins, err := b.repository.SelectUsersWithExpiredLots(ctx, b.config.PointsLifetimeMonths)
+	if err != nil {
+		return fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	for _, userLogin := range userLogins {
+		expired, errExpire := b.repository.ExpireLotsByUserLogin(ctx, userLogin, b.config.PointsLifetimeMonths)
+		if errExpire != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			b.logger.Error("Unable to expire points", zap.String("user", userLogin), zap.Error(errExpire))
+			continue
+		}
+
+		if expired.IsPositive() {
+			b.logger.Info("Points expired", zap.String("user", userLogin), zap.String("sum", expired.String()))
+		}
+	}
+
+	return nil
+}
+
+// RecalculateTiers places the users into the loyalty tiers by the accruals credited within the last tierPeriodMonths.
+func (b *BalanceUseCase) RecalculateTiers(ctx context.Context) error {
+	changed, err := b.repository.RecalculateTiers(ctx, tierPeriodMonths)
+	if err != nil {
+		return fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	b.logger.Info("Loyalty tiers recalculated", zap.Int("changed", changed))
+
+	return nil
+}
+
+// ReverseWithdrawal refunds the withdrawal made for the order back to the user balance.
+func (b *BalanceUseCase) ReverseWithdrawal(ctx context.Context, orderNumber string, reason string) (*dto.WithdrawalReversalResponse, error) {
+	reversal, err := b.repository.ReverseWithdrawal(ctx, orderNumber, reason)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	b.logger.Info("Withdrawal reversed",
+		zap.String("order", reversal.OrderNumber), zap.String("user_login", reversal.UserLogin), zap.String("reason", reversal.Reason))
+
+	reversalResponse := dto.MapToWithdrawalReversalResponse(*reversal)
+
+	return &reversalResponse, nil
+}
+
+func (b *BalanceUseCase) GetLedger(ctx context.Context, userLogin string) ([]dto.LedgerEntryResponse, error) {
+	entries, err := b.repository.SelectLedgerByUserLogin(ctx, userLogin)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	ledgerResponses := make([]dto.LedgerEntryResponse, 0, len(entries))
+	for _, v := range entries {
+		ledgerResponses = append(ledgerResponses, dto.MapToLedgerEntryResponse(v))
+	}
+
+	return ledgerResponses, nil
+}
+
+// CheckConsistency compares every materialized balance with the sum of its ledger postings
+// and checks that every ledger transaction sums to zero.
+func (b *BalanceUseCase) CheckConsistency(ctx context.Context) (*dto.ConsistencyResponse, error) {
+	drifts, err := b.repository.SelectBalanceDrifts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	transactions, err := b.repository.SelectUnbalancedTransactions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	consistencyResponse := dto.MapToConsistencyResponse(drifts, transactions)
+	if !consistencyResponse.Consistent {
+		b.logger.Warn("Balance is inconsistent with ledger",
+			zap.Int("drifts", len(drifts)), zap.Int("unbalanced_transactions", len(transactions)))
+	}
+
+	return &consistencyResponse, nil
+}
+
+// withdrawalFingerprint identifies the withdrawal request body regardless of the amount formatting.
+func withdrawalFingerprint(orderNumber string, amount decimal.Decimal) string {
+	sum := sha256.Sum256([]byte(orderNumber + "\n" + amount.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// replayWithdrawal returns the stored result of the withdrawal made with the idempotency key.
+func replayWithdrawal(key model.IdempotencyKey, record model.IdempotencyRecord) error {
+	if record.Fingerprint != key.Fingerprint {
+		return apperrors.ErrIdempotencyKeyReused
+	}
+
+	if record.Result == nil {
+		return apperrors.ErrIdempotencyKeyInProgress
+	}
+
+	switch *record.Result {
+	case model.ResultWithdrawn:
+		return nil
+	case model.ResultInsufficientFunds:
+		return apperrors.ErrInsufficientFunds
+	case model.ResultWithdrawalExists:
+		return apperrors.ErrWithdrawalAlreadyExists
+	default:
+		return apperrors.NewValueError("unknown withdrawal result", utils.Caller(), errors.New(*record.Result))
+	}
+}
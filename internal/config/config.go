@@ -3,26 +3,80 @@ package config
 import (
 	"flag"
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v10"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
 )
 
 type Config struct {
-	Address              string `env:"RUN_ADDRESS"`
-	DatabaseURI          string `env:"DATABASE_URI"`
-	AccrualSystemAddress string `env:"ACCRUAL_SYSTEM_ADDRESS"`
-	Secret               string `env:"SECRET"`
-	TokenName            string `env:"TOKEN_NAME"`
+	Address                   string          `env:"RUN_ADDRESS"`
+	Storage                   string          `env:"STORAGE"`
+	DatabaseURI               string          `env:"DATABASE_URI"`
+	AccrualSystemAddress      string          `env:"ACCRUAL_SYSTEM_ADDRESS"`
+	Secret                    string          `env:"SECRET"`
+	TokenName                 string          `env:"TOKEN_NAME"`
+	AdminToken                string          `env:"ADMIN_TOKEN"`
+	AccrualLeaseTimeout       time.Duration   `env:"ACCRUAL_LEASE_TIMEOUT"`
+	AccrualBackoffBase        time.Duration   `env:"ACCRUAL_BACKOFF_BASE"`
+	AccrualBackoffMax         time.Duration   `env:"ACCRUAL_BACKOFF_MAX"`
+	AccrualMaxAttempts        int             `env:"ACCRUAL_MAX_ATTEMPTS"`
+	AccrualWorkers            int             `env:"ACCRUAL_WORKERS"`
+	AccrualBatchSize          int             `env:"ACCRUAL_BATCH_SIZE"`
+	AccrualPollInterval       time.Duration   `env:"ACCRUAL_POLL_INTERVAL"`
+	AccrualRPS                int             `env:"ACCRUAL_RPS"`
+	AccrualTimeout            time.Duration   `env:"ACCRUAL_TIMEOUT"`
+	AccrualBreakerFailures    int             `env:"ACCRUAL_BREAKER_FAILURES"`
+	AccrualBreakerOpenTimeout time.Duration   `env:"ACCRUAL_BREAKER_OPEN_TIMEOUT"`
+	AccrualBreakerProbes      int             `env:"ACCRUAL_BREAKER_PROBES"`
+	HoldTTL                   time.Duration   `env:"HOLD_TTL"`
+	HoldExpiryInterval        time.Duration   `env:"HOLD_EXPIRY_INTERVAL"`
+	PointsLifetimeMonths      int             `env:"POINTS_LIFETIME_MONTHS"`
+	PointsExpiryInterval      time.Duration   `env:"POINTS_EXPIRY_INTERVAL"`
+	TransferDailyLimit        decimal.Decimal `env:"TRANSFER_DAILY_LIMIT"`
+	TransferDailyCount        int             `env:"TRANSFER_DAILY_COUNT"`
+	TierRecalculationAt       time.Duration   `env:"TIER_RECALCULATION_AT"`
+	ReferralBonus             decimal.Decimal `env:"REFERRAL_BONUS"`
+	ReferralLimit             int             `env:"REFERRAL_LIMIT"`
 }
 
 func NewConfig() *Config {
 	config := &Config{}
 
 	flag.StringVar(&config.Address, "a", "localhost:7000", "Адрес и порт запуска сервиса")
+	flag.StringVar(&config.Storage, "storage", StoragePostgres, "Хранилище данных: postgres или memory (данные теряются при остановке сервиса)")
 	flag.StringVar(&config.DatabaseURI, "d", "user=postgres password=postgres host=localhost database=yap-gophermart sslmode=disable", "Адрес подключения к базе данных")
 	flag.StringVar(&config.AccrualSystemAddress, "r", "http://localhost:8080", "Адрес подключения к базе данных")
 	flag.StringVar(&config.Secret, "s", "supersecretkey", "Секрет для JWT")
 	flag.StringVar(&config.TokenName, "t", "token", "Enter token name Or use TOKEN_NAME env")
+	flag.StringVar(&config.AdminToken, "admin-token", "", "Токен доступа к административному API")
+	flag.DurationVar(&config.AccrualLeaseTimeout, "accrual-lease-timeout", time.Minute, "Время, на которое заказ захватывается для опроса системы начислений")
+	flag.DurationVar(&config.AccrualBackoffBase, "accrual-backoff-base", time.Second, "Начальная задержка повторного опроса заказа в системе начислений")
+	flag.DurationVar(&config.AccrualBackoffMax, "accrual-backoff-max", 10*time.Minute, "Максимальная задержка повторного опроса заказа в системе начислений")
+	flag.IntVar(&config.AccrualMaxAttempts, "accrual-max-attempts", 100, "Количество попыток опроса заказа, после которого он переносится в очередь недоставленных")
+	flag.IntVar(&config.AccrualWorkers, "accrual-workers", 10, "Количество параллельных обработчиков заказов в системе начислений")
+	flag.IntVar(&config.AccrualBatchSize, "accrual-batch-size", 10, "Количество заказов, захватываемых для опроса за один запрос к базе данных")
+	flag.DurationVar(&config.AccrualPollInterval, "accrual-poll-interval", 300*time.Millisecond, "Интервал опроса базы данных при отсутствии заказов для обработки")
+	flag.IntVar(&config.AccrualRPS, "accrual-rps", 10, "Максимальное количество запросов в секунду к системе начислений")
+	flag.DurationVar(&config.AccrualTimeout, "accrual-timeout", 5*time.Second, "Время ожидания ответа системы начислений")
+	flag.IntVar(&config.AccrualBreakerFailures, "accrual-breaker-failures", 5, "Количество ошибок системы начислений подряд, после которого опрос приостанавливается")
+	flag.DurationVar(&config.AccrualBreakerOpenTimeout, "accrual-breaker-open-timeout", 30*time.Second, "Время, на которое приостанавливается опрос системы начислений после серии ошибок")
+	flag.IntVar(&config.AccrualBreakerProbes, "accrual-breaker-probes", 1, "Количество пробных запросов к системе начислений после паузы")
+	flag.DurationVar(&config.HoldTTL, "hold-ttl", 15*time.Minute, "Время, через которое незавершенная блокировка баллов снимается автоматически")
+	flag.DurationVar(&config.HoldExpiryInterval, "hold-expiry-interval", time.Minute, "Интервал снятия просроченных блокировок баллов, 0 - блокировки не снимаются")
+	flag.IntVar(&config.PointsLifetimeMonths, "points-lifetime-months", 0, "Количество месяцев, через которое начисленные баллы сгорают, 0 - баллы не сгорают")
+	flag.DurationVar(&config.PointsExpiryInterval, "points-expiry-interval", time.Hour, "Интервал списания сгоревших баллов, 0 - сгоревшие баллы не списываются")
+	flag.TextVar(&config.TransferDailyLimit, "transfer-daily-limit", decimal.NewFromInt(1000), "Максимальная сумма баллов, которую пользователь может перевести за день, 0 - без ограничения")
+	flag.IntVar(&config.TransferDailyCount, "transfer-daily-count", 10, "Максимальное количество переводов баллов пользователя за день, 0 - без ограничения")
+	flag.DurationVar(&config.TierRecalculationAt, "tier-recalculation-at", 3*time.Hour, "Время от полуночи, в которое ежедневно пересчитываются уровни лояльности")
+	flag.TextVar(&config.ReferralBonus, "referral-bonus", decimal.NewFromInt(100), "Бонус, начисляемый приглашенному и пригласившему пользователям после первого обработанного заказа приглашенного, 0 - без бонуса")
+	flag.IntVar(&config.ReferralLimit, "referral-limit", 10, "Максимальное количество пользователей, приглашенных одним пользователем, 0 - без ограничения")
+	flag.Parse()
 
 	if err := env.Parse(config); err != nil {
 		fmt.Printf("%+v\n", err)
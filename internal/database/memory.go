@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/avito-tech/go-transaction-manager/trm"
+	trmcontext "github.com/avito-tech/go-transaction-manager/trm/context"
+	"go.uber.org/zap"
+)
+
+// MemoryStorage keeps the service data in process memory instead of PostgreSQL.
+// Every operation holds the storage lock, so operations are serialized. A transaction holds the lock
+// from begin to commit or rollback, the tables are restored from a snapshot taken at begin on rollback.
+type MemoryStorage struct {
+	mu       sync.Mutex
+	tablesMu sync.Mutex
+	tables   map[string]memoryTable
+	Logger   *zap.Logger
+}
+
+type memoryTable interface {
+	snapshot() (restore func())
+}
+
+// MemoryTable is a table of the in-memory storage, rows are stored by value and keyed by their primary key.
+// Its methods must be called while holding the storage lock, see MemoryStorage.Lock.
+type MemoryTable[K comparable, V any] struct {
+	rows map[K]V
+}
+
+func NewMemoryStorage(logger *zap.Logger) *MemoryStorage {
+	logger.Info("Using in-memory storage, data is lost on shutdown")
+
+	return &MemoryStorage{
+		tables: make(map[string]memoryTable),
+		Logger: logger,
+	}
+}
+
+// Table returns the table with the given name, creating it on first use,
+// so that repositories share tables the same way they share tables of the database.
+func Table[K comparable, V any](s *MemoryStorage, name string) *MemoryTable[K, V] {
+	s.tablesMu.Lock()
+	defer s.tablesMu.Unlock()
+
+	if table, ok := s.tables[name]; ok {
+		typed, ok := table.(*MemoryTable[K, V])
+		if !ok {
+			panic(fmt.Sprintf("memory table %s is registered with another row type", name))
+		}
+		return typed
+	}
+
+	table := &MemoryTable[K, V]{rows: make(map[K]V)}
+	s.tables[name] = table
+	return table
+}
+
+// Lock acquires the storage lock and returns the function releasing it.
+// Within a transaction of this storage the lock is already held and Lock does nothing.
+func (s *MemoryStorage) Lock(ctx context.Context) (unlock func()) {
+	if tr, ok := trmcontext.DefaultManager.Default(ctx).(*memoryTransaction); ok && tr.storage == s && tr.IsActive() {
+		return func() {}
+	}
+
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// TrFactory returns the factory of transactions for the transaction manager. Transaction settings are ignored:
+// transactions are serialized, which is at least as strict as any isolation level.
+func (s *MemoryStorage) TrFactory() trm.TrFactory {
+	return func(ctx context.Context, _ trm.Settings) (context.Context, trm.Transaction, error) {
+		s.mu.Lock()
+
+		s.tablesMu.Lock()
+		restores := make([]func(), 0, len(s.tables))
+		for _, table := range s.tables {
+			restores = append(restores, table.snapshot())
+		}
+		s.tablesMu.Unlock()
+
+		return ctx, &memoryTransaction{storage: s, restores: restores, active: true}, nil
+	}
+}
+
+func (t *MemoryTable[K, V]) Get(key K) (V, bool) {
+	row, ok := t.rows[key]
+	return row, ok
+}
+
+func (t *MemoryTable[K, V]) Put(key K, row V) {
+	t.rows[key] = row
+}
+
+func (t *MemoryTable[K, V]) Delete(key K) {
+	delete(t.rows, key)
+}
+
+// Select returns the rows matching the filter in no particular order, a nil filter matches every row.
+func (t *MemoryTable[K, V]) Select(filter func(V) bool) []V {
+	rows := make([]V, 0)
+	for _, row := range t.rows {
+		if filter == nil || filter(row) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (t *MemoryTable[K, V]) snapshot() func() {
+	rows := make(map[K]V, len(t.rows))
+	for k, v := range t.rows {
+		rows[k] = v
+	}
+
+	return func() {
+		t.rows = rows
+	}
+}
+
+type memoryTransaction struct {
+	mu       sync.Mutex
+	storage  *MemoryStorage
+	restores []func()
+	active   bool
+}
+
+func (t *memoryTransaction) Transaction() interface{} {
+	return t.storage
+}
+
+func (t *memoryTransaction) Commit(_ context.Context) error {
+	return t.close(false)
+}
+
+func (t *memoryTransaction) Rollback(_ context.Context) error {
+	return t.close(true)
+}
+
+func (t *memoryTransaction) IsActive() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.active
+}
+
+func (t *memoryTransaction) close(rollback bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.active {
+		return trm.ErrAlreadyClosed
+	}
+
+	if rollback {
+		for _, restore := range t.restores {
+			restore()
+		}
+	}
+
+	t.active = false
+	t.storage.mu.Unlock()
+	return nil
+}
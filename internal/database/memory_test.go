@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/avito-tech/go-transaction-manager/trm/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMemoryStorageTransaction(t *testing.T) {
+	storage := NewMemoryStorage(zap.NewNop())
+	table := Table[string, int](storage, "counter")
+	trManager := manager.Must(storage.TrFactory())
+
+	increment := func(ctx context.Context) {
+		defer storage.Lock(ctx)()
+		value, _ := table.Get("key")
+		table.Put("key", value+1)
+	}
+
+	err := trManager.Do(context.Background(), func(ctx context.Context) error {
+		increment(ctx)
+		increment(ctx)
+		return nil
+	})
+	require.NoError(t, err)
+
+	errRollback := errors.New("rollback")
+	err = trManager.Do(context.Background(), func(ctx context.Context) error {
+		increment(ctx)
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+
+	increment(context.Background())
+
+	defer storage.Lock(context.Background())()
+	value, ok := table.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+	assert.Same(t, table, Table[string, int](storage, "counter"))
+	assert.Panics(t, func() { Table[string, string](storage, "counter") })
+}
@@ -0,0 +1,6 @@
+begin transaction;
+
+alter table gophermart.order
+    drop column if exists accrual_lease_expires_at;
+
+commit transaction;
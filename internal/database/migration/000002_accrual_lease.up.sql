@@ -0,0 +1,10 @@
+begin transaction;
+
+alter table gophermart.order
+    add column if not exists accrual_lease_expires_at timestamp;
+
+update gophermart.order
+set accrual_lease_expires_at = coalesce(accrual_started_at, now())
+where accrual_readiness = false and status not in ('INVALID', 'PROCESSED');
+
+commit transaction;
@@ -0,0 +1,7 @@
+begin transaction;
+
+alter table gophermart.order
+    drop column if exists accrual_next_attempt_at,
+    drop column if exists accrual_dead_lettered_at;
+
+commit transaction;
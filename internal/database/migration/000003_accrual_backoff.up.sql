@@ -0,0 +1,7 @@
+begin transaction;
+
+alter table gophermart.order
+    add column if not exists accrual_next_attempt_at timestamp default now() not null,
+    add column if not exists accrual_dead_lettered_at timestamp;
+
+commit transaction;
@@ -0,0 +1,20 @@
+begin transaction;
+
+create table if not exists gophermart.accrual_credit
+(
+    order_number            text,
+    user_login              text not null,
+    amount                  numeric(10,2) not null check (amount >= 0),
+    credited_at             timestamp default now() not null,
+    constraint pk_accrual_credit primary key (order_number),
+    constraint fk_order foreign key (order_number) references gophermart.order (number),
+    constraint fk_user foreign key (user_login) references gophermart.user (login) on update cascade
+);
+
+insert into gophermart.accrual_credit (order_number, user_login, amount, credited_at)
+select number, user_login, accrual, coalesce(accrual_finished_at, now())
+from gophermart.order
+where status = 'PROCESSED' and accrual is not null
+on conflict (order_number) do nothing;
+
+commit transaction;
@@ -0,0 +1,5 @@
+begin transaction;
+
+drop table if exists gophermart.order_status_history;
+
+commit transaction;
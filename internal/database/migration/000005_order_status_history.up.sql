@@ -0,0 +1,29 @@
+begin transaction;
+
+create table if not exists gophermart.order_status_history
+(
+    id                      uuid default gen_random_uuid(),
+    order_number            text not null,
+    from_status             gophermart.order_status,
+    to_status               gophermart.order_status not null,
+    accrual                 numeric(10,2),
+    source                  text not null,
+    attempt                 integer default 0 not null,
+    observed_at             timestamp default now() not null,
+    constraint pk_order_status_history primary key (id),
+    constraint fk_order foreign key (order_number) references gophermart.order (number)
+);
+
+create index if not exists idx_order_status_history_order_number
+    on gophermart.order_status_history (order_number, observed_at);
+
+insert into gophermart.order_status_history (order_number, from_status, to_status, source, observed_at)
+select number, null, 'NEW', 'upload', uploaded_at
+from gophermart.order;
+
+insert into gophermart.order_status_history (order_number, from_status, to_status, accrual, source, attempt, observed_at)
+select number, 'NEW', status, accrual, 'migration', accrual_count, coalesce(accrual_finished_at, uploaded_at)
+from gophermart.order
+where status <> 'NEW';
+
+commit transaction;
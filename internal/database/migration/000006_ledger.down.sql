@@ -0,0 +1,7 @@
+begin transaction;
+
+drop trigger if exists ledger_posting_append_only on gophermart.ledger_posting;
+drop function if exists gophermart.ledger_posting_append_only();
+drop table if exists gophermart.ledger_posting;
+
+commit transaction;
@@ -0,0 +1,66 @@
+begin transaction;
+
+create table if not exists gophermart.ledger_posting
+(
+    id                      uuid default gen_random_uuid(),
+    transaction_id          uuid not null,
+    account                 text not null,
+    user_login              text,
+    amount                  numeric(10,2) not null,
+    reason                  text not null,
+    reference               text not null,
+    posted_at               timestamp default clock_timestamp() not null,
+    constraint pk_ledger_posting primary key (id),
+    constraint fk_user foreign key (user_login) references gophermart.user (login) on update cascade,
+    constraint user_account_has_login check ((account = 'user') = (user_login is not null))
+);
+
+create index if not exists idx_ledger_posting_user_login
+    on gophermart.ledger_posting (user_login, posted_at);
+
+create index if not exists idx_ledger_posting_transaction_id
+    on gophermart.ledger_posting (transaction_id);
+
+create or replace function gophermart.ledger_posting_append_only()
+    returns trigger
+    language plpgsql
+as $$
+begin
+    raise exception 'ledger postings are append-only';
+END
+$$;
+
+create or replace trigger ledger_posting_append_only
+    before update or delete on gophermart.ledger_posting
+    for each row
+execute function gophermart.ledger_posting_append_only();
+
+with credit as (
+    select gen_random_uuid() as transaction_id, order_number, user_login, amount, credited_at
+    from gophermart.accrual_credit
+)
+insert into gophermart.ledger_posting (transaction_id, account, user_login, amount, reason, reference, posted_at)
+select transaction_id, 'user', user_login, amount, 'accrual', order_number, credited_at from credit
+union all
+select transaction_id, 'accrual', null, -amount, 'accrual', order_number, credited_at from credit;
+
+insert into gophermart.ledger_posting (transaction_id, account, user_login, amount, reason, reference, posted_at)
+select id, 'user', user_login, -sum, 'withdrawal', order_number, coalesce(processed_at, now())
+from gophermart.withdrawals
+union all
+select id, 'withdrawal', null, sum, 'withdrawal', order_number, coalesce(processed_at, now())
+from gophermart.withdrawals;
+
+with drift as (
+    select gen_random_uuid() as transaction_id, b.user_login, b.current - coalesce(sum(p.amount), 0) as amount
+    from gophermart.balance b
+    left join gophermart.ledger_posting p on p.user_login = b.user_login
+    group by b.user_login, b.current
+    having b.current <> coalesce(sum(p.amount), 0)
+)
+insert into gophermart.ledger_posting (transaction_id, account, user_login, amount, reason, reference)
+select transaction_id, 'user', user_login, amount, 'adjustment', 'migration' from drift
+union all
+select transaction_id, 'adjustment', null, -amount, 'adjustment', 'migration' from drift;
+
+commit transaction;
@@ -0,0 +1,21 @@
+begin transaction;
+
+drop table if exists gophermart.withdrawal_idempotency;
+
+alter table gophermart.withdrawals
+    drop constraint if exists unique_withdrawal_order_number;
+
+alter table gophermart.ledger_posting disable trigger ledger_posting_append_only;
+
+update gophermart.ledger_posting p
+set reference = regexp_replace(p.reference, '-duplicate-[0-9]+$', '')
+from gophermart.withdrawals w
+where p.transaction_id = w.id and p.reason = 'withdrawal' and w.order_number ~ '-duplicate-[0-9]+$';
+
+update gophermart.withdrawals
+set order_number = regexp_replace(order_number, '-duplicate-[0-9]+$', '')
+where order_number ~ '-duplicate-[0-9]+$';
+
+alter table gophermart.ledger_posting enable trigger ledger_posting_append_only;
+
+commit transaction;
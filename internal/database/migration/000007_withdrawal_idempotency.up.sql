@@ -0,0 +1,42 @@
+begin transaction;
+
+-- order numbers were not unique before, keep the first withdrawal of every number and
+-- suffix the repeated ones so that the constraint below can be added over the existing rows,
+-- the ledger postings of the renamed withdrawals are updated to keep referencing them
+alter table gophermart.ledger_posting disable trigger ledger_posting_append_only;
+
+with duplicate as (
+    select id, order_number,
+           row_number() over (partition by order_number order by processed_at nulls last, id) as position
+    from gophermart.withdrawals
+),
+renamed as (
+    update gophermart.withdrawals w
+    set order_number = d.order_number || '-duplicate-' || (d.position - 1)
+    from duplicate d
+    where w.id = d.id and d.position > 1
+    returning w.id, w.order_number
+)
+update gophermart.ledger_posting p
+set reference = r.order_number
+from renamed r
+where p.transaction_id = r.id and p.reason = 'withdrawal';
+
+alter table gophermart.ledger_posting enable trigger ledger_posting_append_only;
+
+alter table gophermart.withdrawals
+    add constraint unique_withdrawal_order_number unique (order_number);
+
+create table if not exists gophermart.withdrawal_idempotency
+(
+    user_login              text not null,
+    key                     text not null,
+    fingerprint             text not null,
+    result                  text,
+    reserved_at             timestamp default now() not null,
+    completed_at            timestamp,
+    constraint pk_withdrawal_idempotency primary key (user_login, key),
+    constraint fk_user foreign key (user_login) references gophermart.user (login) on update cascade
+);
+
+commit transaction;
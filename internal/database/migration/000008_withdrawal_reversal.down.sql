@@ -0,0 +1,5 @@
+begin transaction;
+
+drop table if exists gophermart.withdrawal_reversal;
+
+commit transaction;
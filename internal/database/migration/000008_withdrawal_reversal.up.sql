@@ -0,0 +1,14 @@
+begin transaction;
+
+create table if not exists gophermart.withdrawal_reversal
+(
+    id                      uuid default gen_random_uuid(),
+    withdrawal_id           uuid not null,
+    reason                  text not null,
+    reversed_at             timestamp default now() not null,
+    constraint pk_withdrawal_reversal primary key (id),
+    constraint unique_withdrawal_reversal unique (withdrawal_id),
+    constraint fk_withdrawal foreign key (withdrawal_id) references gophermart.withdrawals (id)
+);
+
+commit transaction;
@@ -0,0 +1,19 @@
+begin transaction;
+
+update gophermart.balance b
+set current = b.current + h.amount
+from (
+    select user_login, sum(amount) as amount
+    from gophermart.balance_hold
+    where status = 'HELD'
+    group by user_login
+) h
+where b.user_login = h.user_login;
+
+drop table if exists gophermart.balance_hold;
+
+alter table gophermart.balance
+    drop constraint if exists not_negative_held,
+    drop column if exists held;
+
+commit transaction;
@@ -0,0 +1,32 @@
+begin transaction;
+
+alter table gophermart.balance
+    add column if not exists held numeric(10,2) default 0 not null,
+    add constraint not_negative_held check (held >= 0);
+
+create table if not exists gophermart.balance_hold
+(
+    id                      uuid default gen_random_uuid(),
+    user_login              text not null,
+    order_number            text not null,
+    amount                  numeric(10,2) not null check (amount > 0),
+    status                  text default 'HELD' not null,
+    withdrawal_id           uuid,
+    created_at              timestamp default now() not null,
+    expires_at              timestamp not null,
+    finished_at             timestamp,
+    constraint pk_balance_hold primary key (id),
+    constraint fk_user foreign key (user_login) references gophermart.user (login) on update cascade,
+    constraint fk_withdrawal foreign key (withdrawal_id) references gophermart.withdrawals (id)
+);
+
+create unique index if not exists unique_active_hold_order_number
+    on gophermart.balance_hold (order_number) where status = 'HELD';
+
+create index if not exists idx_balance_hold_user_login
+    on gophermart.balance_hold (user_login, created_at);
+
+create index if not exists idx_balance_hold_expires_at
+    on gophermart.balance_hold (expires_at) where status = 'HELD';
+
+commit transaction;
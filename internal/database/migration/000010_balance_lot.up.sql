@@ -0,0 +1,29 @@
+begin transaction;
+
+create table if not exists gophermart.balance_lot
+(
+    id                      uuid default gen_random_uuid(),
+    user_login              text not null,
+    reason                  text not null,
+    reference               text not null,
+    amount                  numeric(10,2) not null check (amount > 0),
+    remaining               numeric(10,2) not null,
+    expired                 numeric(10,2) default 0 not null,
+    credited_at             timestamp default now() not null,
+    constraint pk_balance_lot primary key (id),
+    constraint fk_user foreign key (user_login) references gophermart.user (login) on update cascade,
+    constraint lot_remaining_within_amount check (remaining >= 0 and expired >= 0 and remaining + expired <= amount)
+);
+
+create index if not exists idx_balance_lot_user_login
+    on gophermart.balance_lot (user_login, credited_at) where remaining > 0;
+
+create index if not exists idx_balance_lot_credited_at
+    on gophermart.balance_lot (credited_at) where remaining > 0;
+
+insert into gophermart.balance_lot (user_login, reason, reference, amount, remaining)
+select user_login, 'migration', 'migration', current + held, current + held
+from gophermart.balance
+where current + held > 0;
+
+commit transaction;
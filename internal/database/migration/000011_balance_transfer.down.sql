@@ -0,0 +1,5 @@
+begin transaction;
+
+drop table if exists gophermart.balance_transfer;
+
+commit transaction;
@@ -0,0 +1,22 @@
+begin transaction;
+
+create table if not exists gophermart.balance_transfer
+(
+    id                      uuid default gen_random_uuid(),
+    sender_login            text not null,
+    recipient_login         text not null,
+    amount                  numeric(10,2) not null check (amount > 0),
+    transferred_at          timestamp default now() not null,
+    constraint pk_balance_transfer primary key (id),
+    constraint fk_sender foreign key (sender_login) references gophermart.user (login) on update cascade,
+    constraint fk_recipient foreign key (recipient_login) references gophermart.user (login) on update cascade,
+    constraint transfer_to_another_user check (sender_login <> recipient_login)
+);
+
+create index if not exists idx_balance_transfer_sender_login
+    on gophermart.balance_transfer (sender_login, transferred_at);
+
+create index if not exists idx_balance_transfer_recipient_login
+    on gophermart.balance_transfer (recipient_login, transferred_at);
+
+commit transaction;
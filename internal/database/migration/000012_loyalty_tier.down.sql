@@ -0,0 +1,12 @@
+begin transaction;
+
+alter table gophermart.order
+    drop column if exists raw_accrual;
+
+alter table gophermart.balance
+    drop constraint if exists fk_tier,
+    drop column if exists tier;
+
+drop table if exists gophermart.loyalty_tier;
+
+commit transaction;
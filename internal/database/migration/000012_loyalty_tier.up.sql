@@ -0,0 +1,46 @@
+begin transaction;
+
+create table if not exists gophermart.loyalty_tier
+(
+    name                    text not null,
+    min_points              numeric(10,2) not null check (min_points >= 0),
+    multiplier              numeric(4,2) not null check (multiplier > 0),
+    constraint pk_loyalty_tier primary key (name),
+    constraint unique_min_points unique (min_points)
+);
+
+insert into gophermart.loyalty_tier (name, min_points, multiplier)
+values
+    ('Bronze', 0, 1.00),
+    ('Silver', 1000, 1.10),
+    ('Gold', 5000, 1.25)
+on conflict do nothing;
+
+alter table gophermart.balance
+    add column if not exists tier text default 'Bronze' not null,
+    add constraint fk_tier foreign key (tier) references gophermart.loyalty_tier (name) on update cascade;
+
+alter table gophermart.order
+    add column if not exists raw_accrual numeric(10,2);
+
+update gophermart.order
+set raw_accrual = accrual
+where accrual is not null;
+
+update gophermart.balance b
+set tier = t.name
+from gophermart.loyalty_tier t
+where t.min_points = (
+    select max(rules.min_points)
+    from gophermart.loyalty_tier rules
+    where rules.min_points <= (
+        select coalesce(sum(o.raw_accrual), 0)
+        from gophermart.accrual_credit c
+        join gophermart.order o on o.number = c.order_number
+        where c.user_login = b.user_login
+            and o.status = 'PROCESSED'
+            and c.credited_at >= now() - interval '12 months'
+    )
+);
+
+commit transaction;
@@ -0,0 +1,6 @@
+begin transaction;
+
+drop table if exists gophermart.promo_redemption;
+drop table if exists gophermart.promo_code;
+
+commit transaction;
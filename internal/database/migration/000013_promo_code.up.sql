@@ -0,0 +1,35 @@
+begin transaction;
+
+create table if not exists gophermart.promo_code
+(
+    id                      uuid default gen_random_uuid(),
+    code                    text not null,
+    amount                  numeric(10,2) not null check (amount > 0),
+    max_redemptions         integer not null check (max_redemptions > 0),
+    per_user_limit          integer default 1 not null check (per_user_limit > 0),
+    valid_from              timestamp not null,
+    valid_until             timestamp not null,
+    redemptions             integer default 0 not null,
+    created_at              timestamp default now() not null,
+    constraint pk_promo_code primary key (id),
+    constraint unique_promo_code unique (code),
+    constraint valid_window check (valid_until > valid_from),
+    constraint redemptions_within_limit check (redemptions between 0 and max_redemptions)
+);
+
+create table if not exists gophermart.promo_redemption
+(
+    id                      uuid default gen_random_uuid(),
+    promo_code_id           uuid not null,
+    user_login              text not null,
+    amount                  numeric(10,2) not null check (amount > 0),
+    redeemed_at             timestamp default now() not null,
+    constraint pk_promo_redemption primary key (id),
+    constraint fk_promo_code foreign key (promo_code_id) references gophermart.promo_code (id),
+    constraint fk_user foreign key (user_login) references gophermart.user (login) on update cascade
+);
+
+create index if not exists idx_promo_redemption_promo_code_user_login
+    on gophermart.promo_redemption (promo_code_id, user_login);
+
+commit transaction;
@@ -0,0 +1,9 @@
+begin transaction;
+
+drop table if exists gophermart.referral;
+
+alter table gophermart.user
+    drop constraint if exists unique_referral_code,
+    drop column if exists referral_code;
+
+commit transaction;
@@ -0,0 +1,31 @@
+begin transaction;
+
+alter table gophermart.user
+    add column if not exists referral_code text;
+
+update gophermart.user
+set referral_code = upper(substr(md5(random()::text || login), 1, 12))
+where referral_code is null;
+
+alter table gophermart.user
+    alter column referral_code set not null,
+    add constraint unique_referral_code unique (referral_code);
+
+create table if not exists gophermart.referral
+(
+    referee_login           text not null,
+    referrer_login          text not null,
+    status                  text default 'PENDING' not null check (status in ('PENDING', 'CREDITED')),
+    bonus                   numeric(10,2),
+    registered_at           timestamp default now() not null,
+    credited_at             timestamp,
+    constraint pk_referral primary key (referee_login),
+    constraint fk_referee foreign key (referee_login) references gophermart.user (login) on update cascade,
+    constraint fk_referrer foreign key (referrer_login) references gophermart.user (login) on update cascade,
+    constraint no_self_referral check (referee_login <> referrer_login)
+);
+
+create index if not exists idx_referral_referrer_login
+    on gophermart.referral (referrer_login, registered_at);
+
+commit transaction;
@@ -0,0 +1,14 @@
+begin transaction;
+
+create table if not exists gophermart.withdrawal_lot
+(
+    withdrawal_id           uuid not null,
+    credited_at             timestamp not null,
+    amount                  numeric(10,2) not null check (amount > 0),
+    constraint fk_withdrawal foreign key (withdrawal_id) references gophermart.withdrawals (id)
+);
+
+create index if not exists idx_withdrawal_lot_withdrawal_id
+    on gophermart.withdrawal_lot (withdrawal_id);
+
+commit transaction;
@@ -0,0 +1,282 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	balanceDto "github.com/msmkdenis/yap-gophermart/internal/balance/handler/dto"
+	orderDto "github.com/msmkdenis/yap-gophermart/internal/order/handler/dto"
+	promoDto "github.com/msmkdenis/yap-gophermart/internal/promo/handler/dto"
+	referralDto "github.com/msmkdenis/yap-gophermart/internal/referral/handler/dto"
+	userDto "github.com/msmkdenis/yap-gophermart/internal/user/handler/dto"
+)
+
+// Client talks to gophermart over HTTP, the auth cookie received on register or login is kept
+// in its cookie jar and sent with every following request.
+type Client struct {
+	UserLogin string
+	t         *testing.T
+	url       string
+	http      *http.Client
+}
+
+func newClient(t *testing.T, url string) *Client {
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	return &Client{
+		t:    t,
+		url:  url,
+		http: &http.Client{Jar: jar, Timeout: waitFor},
+	}
+}
+
+func (c *Client) Register(login string, password string) int {
+	status, _ := c.doJSON(http.MethodPost, "/api/user/register", userDto.UserRegisterRequest{Login: login, Password: password}, nil)
+	if status == http.StatusOK {
+		c.UserLogin = login
+	}
+	return status
+}
+
+// RegisterWithReferral registers the user referred by the owner of the referral code.
+func (c *Client) RegisterWithReferral(login string, password string, referralCode string) int {
+	status, _ := c.doJSON(http.MethodPost, "/api/user/register",
+		userDto.UserRegisterRequest{Login: login, Password: password, ReferralCode: referralCode}, nil)
+	if status == http.StatusOK {
+		c.UserLogin = login
+	}
+	return status
+}
+
+func (c *Client) Login(login string, password string) int {
+	status, _ := c.doJSON(http.MethodPost, "/api/user/login", userDto.UserLoginRequest{Login: login, Password: password}, nil)
+	if status == http.StatusOK {
+		c.UserLogin = login
+	}
+	return status
+}
+
+func (c *Client) UploadOrder(orderNumber string) int {
+	status, _ := c.do(http.MethodPost, "/api/user/orders", http.Header{"Content-Type": {"text/plain"}}, strings.NewReader(orderNumber))
+	return status
+}
+
+// Orders returns the uploaded orders, nil when there are none.
+func (c *Client) Orders() []orderDto.OrderResponse {
+	var orders []orderDto.OrderResponse
+	c.get("/api/user/orders", &orders)
+	return orders
+}
+
+// Order returns the order details, nil when the order is not found.
+func (c *Client) Order(orderNumber string) *orderDto.OrderDetailsResponse {
+	var order *orderDto.OrderDetailsResponse
+	c.get("/api/user/orders/"+orderNumber, &order)
+	return order
+}
+
+// OrderStatus returns the order status, an empty string when the order is not found.
+func (c *Client) OrderStatus(orderNumber string) string {
+	if order := c.Order(orderNumber); order != nil {
+		return order.Status
+	}
+	return ""
+}
+
+func (c *Client) Balance() balanceDto.BalanceResponse {
+	var balance balanceDto.BalanceResponse
+	status := c.get("/api/user/balance", &balance)
+	require.Equal(c.t, http.StatusOK, status, "unable to get balance")
+	return balance
+}
+
+func (c *Client) Withdraw(orderNumber string, amount decimal.Decimal) int {
+	return c.WithdrawWithKey("", orderNumber, amount)
+}
+
+// WithdrawWithKey withdraws with the Idempotency-Key header, the header is omitted for an empty key.
+func (c *Client) WithdrawWithKey(idempotencyKey string, orderNumber string, amount decimal.Decimal) int {
+	header := http.Header{}
+	if idempotencyKey != "" {
+		header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	status, _ := c.doJSON(http.MethodPost, "/api/user/balance/withdraw", balanceDto.BalanceWithdrawRequest{OrderNumber: orderNumber, Amount: amount}, header)
+	return status
+}
+
+// Withdrawals returns the withdrawals, nil when there are none.
+func (c *Client) Withdrawals() []balanceDto.WithdrawalResponse {
+	var withdrawals []balanceDto.WithdrawalResponse
+	c.get("/api/user/withdrawals", &withdrawals)
+	return withdrawals
+}
+
+// Ledger returns the balance ledger, nil when there are no postings.
+func (c *Client) Ledger() []balanceDto.LedgerEntryResponse {
+	var entries []balanceDto.LedgerEntryResponse
+	c.get("/api/user/balance/ledger", &entries)
+	return entries
+}
+
+// Consistency checks the balances against the ledger with the admin token.
+func (c *Client) Consistency() balanceDto.ConsistencyResponse {
+	status, body := c.do(http.MethodGet, "/api/admin/balance/consistency", adminHeader(), nil)
+	require.Equal(c.t, http.StatusOK, status, "unable to check consistency")
+
+	var consistency balanceDto.ConsistencyResponse
+	require.NoError(c.t, json.Unmarshal(body, &consistency))
+	return consistency
+}
+
+// ReverseWithdrawal reverses the withdrawal made for the order with the admin token.
+func (c *Client) ReverseWithdrawal(orderNumber string, reason string) int {
+	status, _ := c.doJSON(http.MethodPost, "/api/admin/balance/withdrawals/"+orderNumber+"/reversal",
+		balanceDto.WithdrawalReversalRequest{Reason: reason}, adminHeader())
+	return status
+}
+
+func (c *Client) RecalculateTiers() int {
+	status, _ := c.do(http.MethodPost, "/api/admin/balance/tiers/recalculation", adminHeader(), nil)
+	return status
+}
+
+// Hold holds points for the order, the hold is nil unless it is created.
+func (c *Client) Hold(orderNumber string, amount decimal.Decimal) (int, *balanceDto.HoldResponse) {
+	status, body := c.doJSON(http.MethodPost, "/api/user/balance/holds", balanceDto.HoldRequest{OrderNumber: orderNumber, Amount: amount}, nil)
+	if status != http.StatusCreated {
+		return status, nil
+	}
+
+	var hold balanceDto.HoldResponse
+	require.NoError(c.t, json.Unmarshal(body, &hold))
+	return status, &hold
+}
+
+func (c *Client) CaptureHold(holdID string) int {
+	status, _ := c.do(http.MethodPost, "/api/user/balance/holds/"+holdID+"/capture", nil, nil)
+	return status
+}
+
+func (c *Client) ReleaseHold(holdID string) int {
+	status, _ := c.do(http.MethodPost, "/api/user/balance/holds/"+holdID+"/release", nil, nil)
+	return status
+}
+
+// Holds returns the holds, nil when there are none.
+func (c *Client) Holds() []balanceDto.HoldResponse {
+	var holds []balanceDto.HoldResponse
+	c.get("/api/user/balance/holds", &holds)
+	return holds
+}
+
+// Transfer transfers points to the recipient, the transfer is nil unless it is made.
+func (c *Client) Transfer(recipientLogin string, amount decimal.Decimal) (int, *balanceDto.TransferResponse) {
+	status, body := c.doJSON(http.MethodPost, "/api/user/balance/transfer", balanceDto.TransferRequest{Recipient: recipientLogin, Amount: amount}, nil)
+	if status != http.StatusOK {
+		return status, nil
+	}
+
+	var transfer balanceDto.TransferResponse
+	require.NoError(c.t, json.Unmarshal(body, &transfer))
+	return status, &transfer
+}
+
+// Transfers returns the sent and received transfers, nil when there are none.
+func (c *Client) Transfers() []balanceDto.TransferResponse {
+	var transfers []balanceDto.TransferResponse
+	c.get("/api/user/balance/transfers", &transfers)
+	return transfers
+}
+
+// Health returns the status code of the health endpoint, 0 when the service does not respond.
+// CreatePromoCode creates the promo code with the admin token.
+func (c *Client) CreatePromoCode(promo promoDto.PromoCodeRequest) int {
+	status, _ := c.doJSON(http.MethodPost, "/api/admin/promo", promo, adminHeader())
+	return status
+}
+
+// RedeemPromo redeems the promo code, the redemption is nil unless the code is redeemed.
+func (c *Client) RedeemPromo(code string) (int, *promoDto.RedemptionResponse) {
+	status, body := c.doJSON(http.MethodPost, "/api/user/promo", promoDto.RedeemPromoRequest{Code: code}, nil)
+	if status != http.StatusOK {
+		return status, nil
+	}
+
+	var redemption promoDto.RedemptionResponse
+	require.NoError(c.t, json.Unmarshal(body, &redemption))
+	return status, &redemption
+}
+
+func (c *Client) Referrals() referralDto.ReferralsResponse {
+	var referrals referralDto.ReferralsResponse
+	c.get("/api/user/referrals", &referrals)
+	return referrals
+}
+
+func (c *Client) Health() int {
+	request, err := http.NewRequest(http.MethodGet, c.url+"/api/health", nil)
+	require.NoError(c.t, err)
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return 0
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode
+}
+
+// get decodes a 200 response into target and returns the status code.
+func (c *Client) get(path string, target any) int {
+	status, body := c.do(http.MethodGet, path, nil, nil)
+	if status == http.StatusOK {
+		require.NoError(c.t, json.Unmarshal(body, target), "unable to decode %s response", path)
+	}
+	return status
+}
+
+func (c *Client) doJSON(method string, path string, payload any, header http.Header) (int, []byte) {
+	body, err := json.Marshal(payload)
+	require.NoError(c.t, err)
+
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+
+	return c.do(method, path, header, bytes.NewReader(body))
+}
+
+func (c *Client) do(method string, path string, header http.Header, body io.Reader) (int, []byte) {
+	c.t.Helper()
+
+	request, err := http.NewRequest(method, c.url+path, body)
+	require.NoError(c.t, err)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+
+	response, err := c.http.Do(request)
+	require.NoError(c.t, err, "%s %s failed", method, path)
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	require.NoError(c.t, err)
+
+	return response.StatusCode, responseBody
+}
+
+func adminHeader() http.Header {
+	return http.Header{"X-Admin-Token": {AdminToken}}
+}
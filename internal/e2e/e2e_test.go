@@ -0,0 +1,451 @@
+package e2e
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/msmkdenis/yap-gophermart/internal/app/accrual"
+	balanceModel "github.com/msmkdenis/yap-gophermart/internal/balance/model"
+	"github.com/msmkdenis/yap-gophermart/internal/order/model"
+	promoDto "github.com/msmkdenis/yap-gophermart/internal/promo/handler/dto"
+	referralModel "github.com/msmkdenis/yap-gophermart/internal/referral/model"
+)
+
+func TestAccrualToWithdrawal(t *testing.T) {
+	h := Start(t, Config(t), AccrualConfig())
+	h.RewardRule("Bork", 10)
+
+	user := h.NewUser()
+
+	orderNumber := OrderNumber()
+	h.AccrualOrder(orderNumber, accrual.Good{Description: "Чайник Bork", Price: decimal.NewFromInt(7000)})
+	require.Equal(t, http.StatusAccepted, user.UploadOrder(orderNumber))
+	assert.Equal(t, http.StatusOK, user.UploadOrder(orderNumber))
+	assert.Equal(t, http.StatusConflict, h.NewUser().UploadOrder(orderNumber))
+
+	h.Eventually(func() bool {
+		return user.OrderStatus(orderNumber) == model.StatusProcessed
+	}, "order %s was not processed", orderNumber)
+
+	orders := user.Orders()
+	require.Len(t, orders, 1)
+	assert.True(t, decimal.NewFromInt(700).Equal(orders[0].Accrual))
+
+	order := user.Order(orderNumber)
+	require.NotNil(t, order)
+	require.NotNil(t, order.RawAccrual)
+	assert.True(t, decimal.NewFromInt(700).Equal(*order.RawAccrual), "new user accrual must not be multiplied")
+
+	balance := user.Balance()
+	assert.True(t, decimal.NewFromInt(700).Equal(balance.Current), "current: %s", balance.Current)
+	assert.True(t, decimal.Zero.Equal(balance.Withdrawn), "withdrawn: %s", balance.Withdrawn)
+	assert.Equal(t, "Bronze", balance.Tier)
+	assert.True(t, decimal.NewFromInt(1).Equal(balance.Multiplier), "multiplier: %s", balance.Multiplier)
+
+	assert.Equal(t, http.StatusPaymentRequired, user.Withdraw(OrderNumber(), decimal.NewFromInt(701)))
+	assert.Equal(t, http.StatusUnprocessableEntity, user.Withdraw("12345678904", decimal.NewFromInt(1)))
+
+	withdrawalOrder := OrderNumber()
+	require.Equal(t, http.StatusOK, user.Withdraw(withdrawalOrder, decimal.NewFromInt(250)))
+
+	balance = user.Balance()
+	assert.True(t, decimal.NewFromInt(450).Equal(balance.Current), "current: %s", balance.Current)
+	assert.True(t, decimal.NewFromInt(250).Equal(balance.Withdrawn), "withdrawn: %s", balance.Withdrawn)
+	require.Len(t, balance.Expirations, 1, "accrual lot must expire after the points lifetime")
+	assert.True(t, decimal.NewFromInt(450).Equal(balance.Expirations[0].Amount), "expiring: %s", balance.Expirations[0].Amount)
+
+	withdrawals := user.Withdrawals()
+	require.Len(t, withdrawals, 1)
+	assert.Equal(t, withdrawalOrder, withdrawals[0].OrderNumber)
+	assert.True(t, decimal.NewFromInt(250).Equal(withdrawals[0].Amount))
+
+	ledger := user.Ledger()
+	require.Len(t, ledger, 2)
+	assert.Equal(t, orderNumber, ledger[0].Reference)
+	assert.True(t, decimal.NewFromInt(700).Equal(ledger[0].Amount))
+	assert.Equal(t, withdrawalOrder, ledger[1].Reference)
+	assert.True(t, decimal.NewFromInt(-250).Equal(ledger[1].Amount))
+	assert.True(t, balance.Current.Equal(ledger[1].Balance), "ledger balance: %s", ledger[1].Balance)
+
+	for _, drift := range user.Consistency().Drifts {
+		assert.NotEqual(t, user.UserLogin, drift.UserLogin, "balance must equal its ledger sum")
+	}
+}
+
+func TestLoyaltyTierMultipliesAccrual(t *testing.T) {
+	h := Start(t, Config(t), AccrualConfig())
+	h.RewardRule("Miele", 10)
+	user := h.NewUser()
+
+	firstOrder := OrderNumber()
+	h.AccrualOrder(firstOrder, accrual.Good{Description: "Пылесос Miele", Price: decimal.NewFromInt(12000)})
+	require.Equal(t, http.StatusAccepted, user.UploadOrder(firstOrder))
+	h.Eventually(func() bool {
+		return user.OrderStatus(firstOrder) == model.StatusProcessed
+	}, "order %s was not processed", firstOrder)
+
+	require.Equal(t, http.StatusOK, user.RecalculateTiers())
+	balance := user.Balance()
+	assert.Equal(t, "Silver", balance.Tier)
+	assert.True(t, decimal.RequireFromString("1.1").Equal(balance.Multiplier), "multiplier: %s", balance.Multiplier)
+
+	secondOrder := OrderNumber()
+	h.AccrualOrder(secondOrder, accrual.Good{Description: "Утюг Miele", Price: decimal.NewFromInt(1000)})
+	require.Equal(t, http.StatusAccepted, user.UploadOrder(secondOrder))
+	h.Eventually(func() bool {
+		return user.OrderStatus(secondOrder) == model.StatusProcessed
+	}, "order %s was not processed", secondOrder)
+
+	order := user.Order(secondOrder)
+	require.NotNil(t, order)
+	require.NotNil(t, order.Accrual)
+	require.NotNil(t, order.RawAccrual)
+	assert.True(t, decimal.NewFromInt(100).Equal(*order.RawAccrual), "raw accrual: %s", order.RawAccrual)
+	assert.True(t, decimal.NewFromInt(110).Equal(*order.Accrual), "accrual: %s", order.Accrual)
+
+	balance = user.Balance()
+	assert.True(t, decimal.NewFromInt(1310).Equal(balance.Current), "current: %s", balance.Current)
+}
+
+func TestWithdrawalIdempotencyKey(t *testing.T) {
+	h := Start(t, Config(t), AccrualConfig())
+	h.RewardRule("Bosch", 10)
+	user := h.NewUser()
+
+	orderNumber := OrderNumber()
+	h.AccrualOrder(orderNumber, accrual.Good{Description: "Дрель Bosch", Price: decimal.NewFromInt(1000)})
+	require.Equal(t, http.StatusAccepted, user.UploadOrder(orderNumber))
+	h.Eventually(func() bool {
+		return user.OrderStatus(orderNumber) == model.StatusProcessed
+	}, "order %s was not processed", orderNumber)
+
+	key := uuid.NewString()
+	withdrawalOrder := OrderNumber()
+	require.Equal(t, http.StatusOK, user.WithdrawWithKey(key, withdrawalOrder, decimal.NewFromInt(40)))
+	assert.Equal(t, http.StatusOK, user.WithdrawWithKey(key, withdrawalOrder, decimal.RequireFromString("40.00")), "replay must return the original result")
+	assert.Equal(t, http.StatusUnprocessableEntity, user.WithdrawWithKey(key, withdrawalOrder, decimal.NewFromInt(41)))
+	assert.Equal(t, http.StatusConflict, user.Withdraw(withdrawalOrder, decimal.NewFromInt(40)), "order must be paid once")
+
+	failedKey := uuid.NewString()
+	failedOrder := OrderNumber()
+	assert.Equal(t, http.StatusPaymentRequired, user.WithdrawWithKey(failedKey, failedOrder, decimal.NewFromInt(100)))
+	assert.Equal(t, http.StatusPaymentRequired, user.WithdrawWithKey(failedKey, failedOrder, decimal.NewFromInt(100)))
+
+	balance := user.Balance()
+	assert.True(t, decimal.NewFromInt(60).Equal(balance.Current), "current: %s", balance.Current)
+	assert.True(t, decimal.NewFromInt(40).Equal(balance.Withdrawn), "withdrawn: %s", balance.Withdrawn)
+	assert.Len(t, user.Withdrawals(), 1)
+}
+
+func TestWithdrawalReversal(t *testing.T) {
+	h := Start(t, Config(t), AccrualConfig())
+	h.RewardRule("Philips", 10)
+	user := h.NewUser()
+
+	orderNumber := OrderNumber()
+	h.AccrualOrder(orderNumber, accrual.Good{Description: "Бритва Philips", Price: decimal.NewFromInt(3000)})
+	require.Equal(t, http.StatusAccepted, user.UploadOrder(orderNumber))
+	h.Eventually(func() bool {
+		return user.OrderStatus(orderNumber) == model.StatusProcessed
+	}, "order %s was not processed", orderNumber)
+
+	withdrawalOrder := OrderNumber()
+	require.Equal(t, http.StatusOK, user.Withdraw(withdrawalOrder, decimal.NewFromInt(120)))
+
+	assert.Equal(t, http.StatusNotFound, user.ReverseWithdrawal(OrderNumber(), "order cancelled"))
+	require.Equal(t, http.StatusOK, user.ReverseWithdrawal(withdrawalOrder, "order cancelled"))
+	assert.Equal(t, http.StatusConflict, user.ReverseWithdrawal(withdrawalOrder, "order cancelled"))
+
+	balance := user.Balance()
+	assert.True(t, decimal.NewFromInt(300).Equal(balance.Current), "current: %s", balance.Current)
+	assert.True(t, decimal.Zero.Equal(balance.Withdrawn), "withdrawn: %s", balance.Withdrawn)
+
+	withdrawals := user.Withdrawals()
+	require.Len(t, withdrawals, 1)
+	assert.NotEmpty(t, withdrawals[0].ReversedAt)
+
+	for _, drift := range user.Consistency().Drifts {
+		assert.NotEqual(t, user.UserLogin, drift.UserLogin, "balance must equal its ledger sum")
+	}
+}
+
+func TestBalanceHolds(t *testing.T) {
+	cfg := Config(t)
+	cfg.HoldTTL = 500 * time.Millisecond
+	h := Start(t, cfg, AccrualConfig())
+	h.RewardRule("Bosch", 10)
+	user := h.NewUser()
+
+	orderNumber := OrderNumber()
+	h.AccrualOrder(orderNumber, accrual.Good{Description: "Дрель Bosch", Price: decimal.NewFromInt(3000)})
+	require.Equal(t, http.StatusAccepted, user.UploadOrder(orderNumber))
+	h.Eventually(func() bool {
+		return user.OrderStatus(orderNumber) == model.StatusProcessed
+	}, "order %s was not processed", orderNumber)
+
+	status, captured := user.Hold(OrderNumber(), decimal.NewFromInt(100))
+	require.Equal(t, http.StatusCreated, status)
+	status, released := user.Hold(OrderNumber(), decimal.NewFromInt(50))
+	require.Equal(t, http.StatusCreated, status)
+	status, expired := user.Hold(OrderNumber(), decimal.NewFromInt(30))
+	require.Equal(t, http.StatusCreated, status)
+	status, _ = user.Hold(OrderNumber(), decimal.NewFromInt(121))
+	assert.Equal(t, http.StatusPaymentRequired, status)
+
+	balance := user.Balance()
+	assert.True(t, decimal.NewFromInt(120).Equal(balance.Current), "current: %s", balance.Current)
+	assert.True(t, decimal.NewFromInt(180).Equal(balance.Held), "held: %s", balance.Held)
+
+	require.Equal(t, http.StatusOK, user.CaptureHold(captured.ID))
+	require.Equal(t, http.StatusOK, user.ReleaseHold(released.ID))
+	assert.Equal(t, http.StatusConflict, user.ReleaseHold(captured.ID))
+	assert.Equal(t, http.StatusNotFound, user.CaptureHold("not-a-hold"))
+	assert.Equal(t, http.StatusNotFound, h.NewUser().CaptureHold(expired.ID))
+
+	h.Eventually(func() bool {
+		return user.Balance().Held.IsZero()
+	}, "hold %s was not released after its ttl", expired.ID)
+	assert.Equal(t, http.StatusConflict, user.CaptureHold(expired.ID))
+
+	balance = user.Balance()
+	assert.True(t, decimal.NewFromInt(200).Equal(balance.Current), "current: %s", balance.Current)
+	assert.True(t, decimal.NewFromInt(100).Equal(balance.Withdrawn), "withdrawn: %s", balance.Withdrawn)
+
+	withdrawals := user.Withdrawals()
+	require.Len(t, withdrawals, 1)
+	assert.Equal(t, captured.OrderNumber, withdrawals[0].OrderNumber)
+	assert.Len(t, user.Holds(), 3)
+
+	for _, drift := range user.Consistency().Drifts {
+		assert.NotEqual(t, user.UserLogin, drift.UserLogin, "balance must equal its ledger sum")
+	}
+}
+
+func TestBalanceTransfer(t *testing.T) {
+	cfg := Config(t)
+	cfg.TransferDailyCount = 2
+	h := Start(t, cfg, AccrualConfig())
+	h.RewardRule("Bork", 10)
+	sender := h.NewUser()
+	recipient := h.NewUser()
+
+	orderNumber := OrderNumber()
+	h.AccrualOrder(orderNumber, accrual.Good{Description: "Чайник Bork", Price: decimal.NewFromInt(3000)})
+	require.Equal(t, http.StatusAccepted, sender.UploadOrder(orderNumber))
+	h.Eventually(func() bool {
+		return sender.OrderStatus(orderNumber) == model.StatusProcessed
+	}, "order %s was not processed", orderNumber)
+
+	status, _ := sender.Transfer(sender.UserLogin, decimal.NewFromInt(10))
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	status, _ = sender.Transfer("user-"+uuid.NewString(), decimal.NewFromInt(10))
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = sender.Transfer(recipient.UserLogin, decimal.NewFromInt(301))
+	assert.Equal(t, http.StatusPaymentRequired, status)
+
+	status, transfer := sender.Transfer(recipient.UserLogin, decimal.NewFromInt(100))
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "out", transfer.Direction)
+	status, _ = sender.Transfer(recipient.UserLogin, decimal.NewFromInt(20))
+	require.Equal(t, http.StatusOK, status)
+	status, _ = sender.Transfer(recipient.UserLogin, decimal.NewFromInt(1))
+	assert.Equal(t, http.StatusTooManyRequests, status)
+
+	balance := sender.Balance()
+	assert.True(t, decimal.NewFromInt(180).Equal(balance.Current), "current: %s", balance.Current)
+	assert.True(t, decimal.Zero.Equal(balance.Withdrawn), "withdrawn: %s", balance.Withdrawn)
+	balance = recipient.Balance()
+	assert.True(t, decimal.NewFromInt(120).Equal(balance.Current), "current: %s", balance.Current)
+
+	received := recipient.Transfers()
+	require.Len(t, received, 2)
+	assert.Equal(t, transfer.ID, received[0].ID)
+	assert.Equal(t, "in", received[0].Direction)
+	assert.Equal(t, sender.UserLogin, received[0].Sender)
+	assert.Len(t, sender.Transfers(), 2)
+	assert.Nil(t, sender.Withdrawals(), "transfers are not withdrawals")
+
+	ledger := recipient.Ledger()
+	require.Len(t, ledger, 2)
+	assert.Equal(t, sender.UserLogin, ledger[0].Reference)
+	assert.True(t, decimal.NewFromInt(100).Equal(ledger[0].Amount))
+
+	consistency := sender.Consistency()
+	for _, drift := range consistency.Drifts {
+		assert.NotContains(t, []string{sender.UserLogin, recipient.UserLogin}, drift.UserLogin, "balance must equal its ledger sum")
+	}
+}
+
+func TestPromoCodeRedemption(t *testing.T) {
+	h := Start(t, Config(t), AccrualConfig())
+	user := h.NewUser()
+	another := h.NewUser()
+
+	code := strings.ToUpper("WELCOME-" + uuid.NewString())
+	promo := promoDto.PromoCodeRequest{
+		Code:           code,
+		Amount:         decimal.NewFromInt(50),
+		MaxRedemptions: 1,
+		ValidFrom:      time.Now().Add(-time.Hour),
+		ValidUntil:     time.Now().Add(time.Hour),
+	}
+	require.Equal(t, http.StatusCreated, user.CreatePromoCode(promo))
+	assert.Equal(t, http.StatusConflict, user.CreatePromoCode(promo))
+
+	expired := promo
+	expired.Code = "EXPIRED-" + uuid.NewString()
+	expired.ValidFrom, expired.ValidUntil = time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour)
+	require.Equal(t, http.StatusCreated, user.CreatePromoCode(expired))
+
+	status, _ := user.RedeemPromo("UNKNOWN-" + uuid.NewString())
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = user.RedeemPromo(expired.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+	status, redemption := user.RedeemPromo(strings.ToLower(code))
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, code, redemption.Code)
+	assert.True(t, decimal.NewFromInt(50).Equal(redemption.Amount))
+	status, _ = user.RedeemPromo(code)
+	assert.Equal(t, http.StatusConflict, status)
+	status, _ = another.RedeemPromo(code)
+	assert.Equal(t, http.StatusGone, status)
+
+	balance := user.Balance()
+	assert.True(t, decimal.NewFromInt(50).Equal(balance.Current), "current: %s", balance.Current)
+
+	ledger := user.Ledger()
+	require.Len(t, ledger, 1)
+	assert.Equal(t, balanceModel.ReasonPromo, ledger[0].Reason)
+	assert.Equal(t, code, ledger[0].Reference)
+	assert.True(t, decimal.NewFromInt(50).Equal(ledger[0].Amount))
+
+	consistency := user.Consistency()
+	for _, drift := range consistency.Drifts {
+		assert.NotEqual(t, user.UserLogin, drift.UserLogin, "balance must equal its ledger sum")
+	}
+}
+
+func TestReferralBonus(t *testing.T) {
+	cfg := Config(t)
+	cfg.ReferralLimit = 1
+	h := Start(t, cfg, AccrualConfig())
+	h.RewardRule("Bork", 10)
+	referrer := h.NewUser()
+
+	referralCode := referrer.Referrals().ReferralCode
+	require.NotEmpty(t, referralCode)
+
+	referee := h.Anonymous()
+	assert.Equal(t, http.StatusUnprocessableEntity, referee.RegisterWithReferral("user-"+uuid.NewString(), "password", "UNKNOWN"))
+	require.Equal(t, http.StatusOK, referee.RegisterWithReferral("user-"+uuid.NewString(), "password", strings.ToLower(referralCode)))
+	assert.Equal(t, http.StatusUnprocessableEntity, h.Anonymous().RegisterWithReferral("user-"+uuid.NewString(), "password", referralCode),
+		"referral limit must be exceeded")
+
+	referrals := referrer.Referrals().Referrals
+	require.Len(t, referrals, 1)
+	assert.Equal(t, referee.UserLogin, referrals[0].Login)
+	assert.Equal(t, referralModel.StatusPending, referrals[0].Status)
+
+	orderNumber := OrderNumber()
+	h.AccrualOrder(orderNumber, accrual.Good{Description: "Чайник Bork", Price: decimal.NewFromInt(3000)})
+	require.Equal(t, http.StatusAccepted, referee.UploadOrder(orderNumber))
+	h.Eventually(func() bool {
+		return referee.OrderStatus(orderNumber) == model.StatusProcessed
+	}, "order %s was not processed", orderNumber)
+
+	referrals = referrer.Referrals().Referrals
+	require.Len(t, referrals, 1)
+	assert.Equal(t, referralModel.StatusCredited, referrals[0].Status)
+	assert.True(t, decimal.NewFromInt(100).Equal(referrals[0].Bonus), "bonus: %s", referrals[0].Bonus)
+	assert.NotEmpty(t, referrals[0].CreditedAt)
+
+	balance := referee.Balance()
+	assert.True(t, decimal.NewFromInt(400).Equal(balance.Current), "current: %s", balance.Current)
+	balance = referrer.Balance()
+	assert.True(t, decimal.NewFromInt(100).Equal(balance.Current), "current: %s", balance.Current)
+
+	ledger := referrer.Ledger()
+	require.Len(t, ledger, 1)
+	assert.Equal(t, balanceModel.ReasonReferral, ledger[0].Reason)
+	assert.Equal(t, referee.UserLogin, ledger[0].Reference)
+
+	secondOrder := OrderNumber()
+	h.AccrualOrder(secondOrder, accrual.Good{Description: "Чайник Bork", Price: decimal.NewFromInt(1000)})
+	require.Equal(t, http.StatusAccepted, referee.UploadOrder(secondOrder))
+	h.Eventually(func() bool {
+		return referee.OrderStatus(secondOrder) == model.StatusProcessed
+	}, "order %s was not processed", secondOrder)
+
+	balance = referrer.Balance()
+	assert.True(t, decimal.NewFromInt(100).Equal(balance.Current), "bonus must be credited once, current: %s", balance.Current)
+	balance = referee.Balance()
+	assert.True(t, decimal.NewFromInt(500).Equal(balance.Current), "current: %s", balance.Current)
+}
+
+func TestInvalidOrderIsNotCredited(t *testing.T) {
+	h := Start(t, Config(t), AccrualConfig())
+	user := h.NewUser()
+
+	orderNumber := OrderNumber()
+	h.AccrualOrder(orderNumber, accrual.Good{Description: "Товар без вознаграждения", Price: decimal.NewFromInt(1000)})
+	require.Equal(t, http.StatusAccepted, user.UploadOrder(orderNumber))
+
+	h.Eventually(func() bool {
+		return user.OrderStatus(orderNumber) == model.StatusInvalid
+	}, "order %s was not invalidated", orderNumber)
+
+	assert.True(t, decimal.Zero.Equal(user.Balance().Current))
+	assert.Nil(t, user.Withdrawals())
+}
+
+func TestParallelWithdrawalsDoNotOverdraw(t *testing.T) {
+	h := Start(t, Config(t), AccrualConfig())
+	h.RewardRule("Miele", 5)
+	user := h.NewUser()
+
+	orderNumber := OrderNumber()
+	h.AccrualOrder(orderNumber, accrual.Good{Description: "Пылесос Miele", Price: decimal.NewFromInt(2000)})
+	require.Equal(t, http.StatusAccepted, user.UploadOrder(orderNumber))
+
+	h.Eventually(func() bool {
+		return user.Balance().Current.Equal(decimal.NewFromInt(100))
+	}, "order %s was not credited", orderNumber)
+
+	const attempts = 5
+	statuses := make(chan int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses <- user.Withdraw(OrderNumber(), decimal.NewFromInt(40))
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	succeeded := 0
+	for status := range statuses {
+		if status == http.StatusOK {
+			succeeded++
+		}
+	}
+
+	// concurrent withdrawals may also fail on serialization, but never overdraw the balance
+	assert.LessOrEqual(t, succeeded, 2)
+	assert.Len(t, user.Withdrawals(), succeeded)
+
+	withdrawn := decimal.NewFromInt(int64(40 * succeeded))
+	balance := user.Balance()
+	assert.True(t, withdrawn.Equal(balance.Withdrawn), "withdrawn: %s", balance.Withdrawn)
+	assert.True(t, decimal.NewFromInt(100).Sub(withdrawn).Equal(balance.Current), "current: %s", balance.Current)
+}
@@ -0,0 +1,185 @@
+// Package e2e starts gophermart and the accrual mock in-process so that tests can drive
+// the whole register → upload → accrual → balance → withdraw flow over HTTP.
+package e2e
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ShiraazMoollatjie/goluhn"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/app/accrual"
+	"github.com/msmkdenis/yap-gophermart/internal/app/gophermart"
+	"github.com/msmkdenis/yap-gophermart/internal/config"
+)
+
+const (
+	// DatabaseURIEnv names the variable with the PostgreSQL connection string used by the harness,
+	// the in-memory storage is used when it is not set.
+	DatabaseURIEnv = "E2E_DATABASE_URI"
+
+	AdminToken = "e2e-admin-token"
+
+	waitFor       = 10 * time.Second
+	tick          = 50 * time.Millisecond
+	shutdownAfter = 35 * time.Second
+)
+
+type Harness struct {
+	t          *testing.T
+	URL        string
+	Accrual    *accrual.Server
+	AccrualURL string
+}
+
+// Config returns the gophermart configuration the harness runs with: fast polling and backoff,
+// so that accruals show up within a fraction of a second.
+func Config(t *testing.T) config.Config {
+	t.Helper()
+
+	storage := config.StoragePostgres
+	databaseURI := os.Getenv(DatabaseURIEnv)
+	if databaseURI == "" {
+		storage = config.StorageMemory
+		t.Logf("%s is not set, running against the in-memory storage", DatabaseURIEnv)
+	}
+
+	return config.Config{
+		Storage:                   storage,
+		DatabaseURI:               databaseURI,
+		Secret:                    "e2e-secret",
+		TokenName:                 "token",
+		AdminToken:                AdminToken,
+		AccrualLeaseTimeout:       time.Minute,
+		AccrualBackoffBase:        50 * time.Millisecond,
+		AccrualBackoffMax:         time.Second,
+		AccrualMaxAttempts:        100,
+		AccrualWorkers:            2,
+		AccrualBatchSize:          10,
+		AccrualPollInterval:       50 * time.Millisecond,
+		AccrualTimeout:            2 * time.Second,
+		AccrualBreakerFailures:    5,
+		AccrualBreakerOpenTimeout: time.Second,
+		AccrualBreakerProbes:      1,
+		HoldTTL:                   time.Minute,
+		HoldExpiryInterval:        50 * time.Millisecond,
+		PointsLifetimeMonths:      12,
+		PointsExpiryInterval:      time.Hour,
+		TransferDailyLimit:        decimal.NewFromInt(1000),
+		TransferDailyCount:        10,
+		TierRecalculationAt:       3 * time.Hour,
+		ReferralBonus:             decimal.NewFromInt(100),
+		ReferralLimit:             10,
+	}
+}
+
+// AccrualConfig returns the accrual mock configuration the harness runs with.
+func AccrualConfig() accrual.Config {
+	return accrual.Config{
+		ProcessingDelay:    50 * time.Millisecond,
+		ProcessingDuration: 100 * time.Millisecond,
+	}
+}
+
+// Start runs the accrual mock and gophermart on random local ports, both are shut down when the test ends.
+// AccrualSystemAddress of cfg is overridden with the address of the mock.
+func Start(t *testing.T, cfg config.Config, accrualCfg accrual.Config) *Harness {
+	t.Helper()
+
+	accrualServer, err := accrual.NewServer(accrualCfg, zap.NewNop())
+	require.NoError(t, err)
+	accrualHTTP := httptest.NewServer(accrualServer)
+	t.Cleanup(accrualHTTP.Close)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	cfg.Address = listener.Addr().String()
+	cfg.AccrualSystemAddress = accrualHTTP.URL
+
+	quitSignal := make(chan os.Signal, 1)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		gophermart.RunWithConfig(cfg, quitSignal, listener)
+	}()
+
+	t.Cleanup(func() {
+		quitSignal <- syscall.SIGTERM
+		select {
+		case <-stopped:
+		case <-time.After(shutdownAfter):
+			t.Error("gophermart did not shut down in time")
+		}
+	})
+
+	h := &Harness{
+		t:          t,
+		URL:        "http://" + cfg.Address,
+		Accrual:    accrualServer,
+		AccrualURL: accrualHTTP.URL,
+	}
+	h.Eventually(func() bool {
+		return h.Anonymous().Health() != 0
+	}, "gophermart did not start")
+
+	return h
+}
+
+// NewUser registers a user with a unique login and returns a client authenticated as that user.
+func (h *Harness) NewUser() *Client {
+	h.t.Helper()
+
+	client := h.Anonymous()
+	login := "user-" + uuid.NewString()
+	require.Equal(h.t, http.StatusOK, client.Register(login, "password"), "unable to register %s", login)
+
+	return client
+}
+
+// Anonymous returns a client without credentials.
+func (h *Harness) Anonymous() *Client {
+	return newClient(h.t, h.URL)
+}
+
+// RewardRule registers a percentage reward for goods whose description contains match.
+func (h *Harness) RewardRule(match string, percent int64) {
+	h.t.Helper()
+
+	err := h.Accrual.Store.RegisterRule(accrual.RewardRule{
+		Match:      match,
+		Reward:     decimal.NewFromInt(percent),
+		RewardType: accrual.RewardPercent,
+	})
+	require.NoError(h.t, err)
+}
+
+// AccrualOrder registers the order in the accrual mock with the given goods.
+func (h *Harness) AccrualOrder(orderNumber string, goods ...accrual.Good) {
+	h.t.Helper()
+
+	err := h.Accrual.Store.RegisterOrder(accrual.RegisterOrderRequest{Order: orderNumber, Goods: goods})
+	require.NoError(h.t, err)
+}
+
+// Eventually waits until the condition holds, failing the test after a timeout long enough for
+// several accrual polling rounds.
+func (h *Harness) Eventually(condition func() bool, msgAndArgs ...interface{}) {
+	h.t.Helper()
+	require.Eventually(h.t, condition, waitFor, tick, msgAndArgs...)
+}
+
+// OrderNumber returns a random order number passing the Luhn check.
+func OrderNumber() string {
+	return goluhn.Generate(16)
+}
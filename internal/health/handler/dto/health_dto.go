@@ -0,0 +1,18 @@
+package dto
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+type HealthResponse struct {
+	Status  string                `json:"status"`
+	Accrual AccrualHealthResponse `json:"accrual"`
+}
+
+type AccrualHealthResponse struct {
+	CircuitBreaker      string `json:"circuit_breaker"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	OpenUntil           string `json:"open_until,omitempty"`
+	RejectedTransitions int64  `json:"rejected_transitions"`
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/health/handler/dto"
+)
+
+// HealthService mockgen --build_flags=--mod=mod -destination=internal/mocks/mock_health_service.go -package=mock github.com/msmkdenis/yap-gophermart/internal/health/handler HealthService
+type HealthService interface {
+	Check(ctx context.Context) dto.HealthResponse
+}
+
+type HealthHandler struct {
+	healthService HealthService
+	logger        *zap.Logger
+}
+
+func NewHealthHandler(e *echo.Echo, service HealthService, logger *zap.Logger) *HealthHandler {
+	handler := &HealthHandler{
+		healthService: service,
+		logger:        logger,
+	}
+
+	e.GET("/api/health", handler.GetHealth)
+
+	return handler
+}
+
+// @Summary       Get service health
+// @Description   Get the service health including the accrual system circuit breaker state. Degraded accrual does not fail the check.
+// @Tags          Health API
+// @Produce       json
+// @Success       200    {object}   dto.HealthResponse
+// @Router        /api/health [get]
+func (h *HealthHandler) GetHealth(c echo.Context) error {
+	health := h.healthService.Check(c.Request().Context())
+
+	if health.Status != dto.StatusOK {
+		h.logger.Warn("Service degraded", zap.String("circuit_breaker", health.Accrual.CircuitBreaker))
+	}
+
+	return c.JSON(http.StatusOK, health)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/health/handler/dto"
+	mock "github.com/msmkdenis/yap-gophermart/internal/mocks"
+)
+
+type HealthHandlersSuite struct {
+	suite.Suite
+	h             *HealthHandler
+	healthService *mock.MockHealthService
+	echo          *echo.Echo
+	ctrl          *gomock.Controller
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(HealthHandlersSuite))
+}
+
+func (s *HealthHandlersSuite) SetupTest() {
+	logger, _ := zap.NewProduction()
+	s.ctrl = gomock.NewController(s.T())
+	s.echo = echo.New()
+	s.healthService = mock.NewMockHealthService(s.ctrl)
+	s.h = NewHealthHandler(s.echo, s.healthService, logger)
+}
+
+func (s *HealthHandlersSuite) TestGetHealth() {
+	testCases := []struct {
+		name         string
+		health       dto.HealthResponse
+		expectedCode int
+	}{
+		{
+			name: "Healthy - 200",
+			health: dto.HealthResponse{
+				Status: dto.StatusOK,
+				Accrual: dto.AccrualHealthResponse{
+					CircuitBreaker: "closed",
+				},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Degraded - 200",
+			health: dto.HealthResponse{
+				Status: dto.StatusDegraded,
+				Accrual: dto.AccrualHealthResponse{
+					CircuitBreaker:      "open",
+					ConsecutiveFailures: 5,
+					OpenUntil:           "2024-01-10T12:00:30Z",
+					RejectedTransitions: 2,
+				},
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range testCases {
+		s.T().Run(test.name, func(t *testing.T) {
+			s.healthService.EXPECT().Check(gomock.Any()).Times(1).Return(test.health)
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/health", nil)
+			w := httptest.NewRecorder()
+			s.echo.ServeHTTP(w, request)
+
+			expectedBody, errMarshal := json.Marshal(test.health)
+			require.NoError(t, errMarshal)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			assert.JSONEq(t, string(expectedBody), w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	accrualHttp "github.com/msmkdenis/yap-gophermart/internal/accrual/http"
+	"github.com/msmkdenis/yap-gophermart/internal/health/handler/dto"
+)
+
+type AccrualBreaker interface {
+	Snapshot() accrualHttp.BreakerSnapshot
+}
+
+type AccrualWorker interface {
+	RejectedTransitions() int64
+}
+
+type HealthUseCase struct {
+	breaker AccrualBreaker
+	worker  AccrualWorker
+	logger  *zap.Logger
+}
+
+func NewHealthService(breaker AccrualBreaker, worker AccrualWorker, logger *zap.Logger) *HealthUseCase {
+	return &HealthUseCase{
+		breaker: breaker,
+		worker:  worker,
+		logger:  logger,
+	}
+}
+
+func (u *HealthUseCase) Check(ctx context.Context) dto.HealthResponse {
+	snapshot := u.breaker.Snapshot()
+
+	response := dto.HealthResponse{
+		Status: dto.StatusOK,
+		Accrual: dto.AccrualHealthResponse{
+			CircuitBreaker:      string(snapshot.State),
+			ConsecutiveFailures: snapshot.Failures,
+			RejectedTransitions: u.worker.RejectedTransitions(),
+		},
+	}
+
+	if snapshot.State != accrualHttp.StateClosed {
+		response.Status = dto.StatusDegraded
+		response.Accrual.OpenUntil = snapshot.OpenUntil.Format(time.RFC3339)
+	}
+
+	return response
+}
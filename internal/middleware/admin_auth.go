@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const adminTokenHeader = "X-Admin-Token"
+
+type AdminAuth struct {
+	token  string
+	logger *zap.Logger
+}
+
+func InitAdminAuth(token string, logger *zap.Logger) *AdminAuth {
+	a := &AdminAuth{
+		token:  token,
+		logger: logger,
+	}
+	return a
+}
+
+func (a *AdminAuth) AdminAuth() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if a.token == "" {
+				a.logger.Warn("admin authentification failed: admin token is not configured")
+				return c.NoContent(http.StatusForbidden)
+			}
+			token := c.Request().Header.Get(adminTokenHeader)
+			if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+				a.logger.Info("admin authentification failed")
+				return c.NoContent(http.StatusUnauthorized)
+			}
+			return next(c)
+		}
+	}
+}
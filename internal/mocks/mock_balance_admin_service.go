@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/msmkdenis/yap-gophermart/internal/balance/handler (interfaces: BalanceAdminService)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/msmkdenis/yap-gophermart/internal/balance/handler/dto"
+)
+
+// MockBalanceAdminService is a mock of BalanceAdminService interface.
+type MockBalanceAdminService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBalanceAdminServiceMockRecorder
+}
+
+// MockBalanceAdminServiceMockRecorder is the mock recorder for MockBalanceAdminService.
+type MockBalanceAdminServiceMockRecorder struct {
+	mock *MockBalanceAdminService
+}
+
+// NewMockBalanceAdminService creates a new mock instance.
+func NewMockBalanceAdminService(ctrl *gomock.Controller) *MockBalanceAdminService {
+	mock := &MockBalanceAdminService{ctrl: ctrl}
+	mock.recorder = &MockBalanceAdminServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBalanceAdminService) EXPECT() *MockBalanceAdminServiceMockRecorder {
+	return m.recorder
+}
+
+// CheckConsistency mocks base method.
+func (m *MockBalanceAdminService) CheckConsistency(arg0 context.Context) (*dto.ConsistencyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckConsistency", arg0)
+	ret0, _ := ret[0].(*dto.ConsistencyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckConsistency indicates an expected call of CheckConsistency.
+func (mr *MockBalanceAdminServiceMockRecorder) CheckConsistency(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckConsistency", reflect.TypeOf((*MockBalanceAdminService)(nil).CheckConsistency), arg0)
+}
+
+// RecalculateTiers mocks base method.
+func (m *MockBalanceAdminService) RecalculateTiers(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecalculateTiers", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecalculateTiers indicates an expected call of RecalculateTiers.
+func (mr *MockBalanceAdminServiceMockRecorder) RecalculateTiers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecalculateTiers", reflect.TypeOf((*MockBalanceAdminService)(nil).RecalculateTiers), arg0)
+}
+
+// ReverseWithdrawal mocks base method.
+func (m *MockBalanceAdminService) ReverseWithdrawal(arg0 context.Context, arg1, arg2 string) (*dto.WithdrawalReversalResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseWithdrawal", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.WithdrawalReversalResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseWithdrawal indicates an expected call of ReverseWithdrawal.
+func (mr *MockBalanceAdminServiceMockRecorder) ReverseWithdrawal(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseWithdrawal", reflect.TypeOf((*MockBalanceAdminService)(nil).ReverseWithdrawal), arg0, arg1, arg2)
+}
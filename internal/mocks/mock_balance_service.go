@@ -36,6 +36,21 @@ func (m *MockBalanceService) EXPECT() *MockBalanceServiceMockRecorder {
 	return m.recorder
 }
 
+// CaptureHold mocks base method.
+func (m *MockBalanceService) CaptureHold(arg0 context.Context, arg1, arg2 string) (*dto.HoldResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.HoldResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockBalanceServiceMockRecorder) CaptureHold(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockBalanceService)(nil).CaptureHold), arg0, arg1, arg2)
+}
+
 // GetByUser mocks base method.
 func (m *MockBalanceService) GetByUser(arg0 context.Context, arg1 string) (*dto.BalanceResponse, error) {
 	m.ctrl.T.Helper()
@@ -51,6 +66,51 @@ func (mr *MockBalanceServiceMockRecorder) GetByUser(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUser", reflect.TypeOf((*MockBalanceService)(nil).GetByUser), arg0, arg1)
 }
 
+// GetHolds mocks base method.
+func (m *MockBalanceService) GetHolds(arg0 context.Context, arg1 string) ([]dto.HoldResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHolds", arg0, arg1)
+	ret0, _ := ret[0].([]dto.HoldResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHolds indicates an expected call of GetHolds.
+func (mr *MockBalanceServiceMockRecorder) GetHolds(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHolds", reflect.TypeOf((*MockBalanceService)(nil).GetHolds), arg0, arg1)
+}
+
+// GetLedger mocks base method.
+func (m *MockBalanceService) GetLedger(arg0 context.Context, arg1 string) ([]dto.LedgerEntryResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLedger", arg0, arg1)
+	ret0, _ := ret[0].([]dto.LedgerEntryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLedger indicates an expected call of GetLedger.
+func (mr *MockBalanceServiceMockRecorder) GetLedger(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLedger", reflect.TypeOf((*MockBalanceService)(nil).GetLedger), arg0, arg1)
+}
+
+// GetTransfers mocks base method.
+func (m *MockBalanceService) GetTransfers(arg0 context.Context, arg1 string) ([]dto.TransferResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]dto.TransferResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfers indicates an expected call of GetTransfers.
+func (mr *MockBalanceServiceMockRecorder) GetTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfers", reflect.TypeOf((*MockBalanceService)(nil).GetTransfers), arg0, arg1)
+}
+
 // GetWithdrawals mocks base method.
 func (m *MockBalanceService) GetWithdrawals(arg0 context.Context, arg1 string) ([]dto.WithdrawalResponse, error) {
 	m.ctrl.T.Helper()
@@ -66,16 +126,61 @@ func (mr *MockBalanceServiceMockRecorder) GetWithdrawals(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawals", reflect.TypeOf((*MockBalanceService)(nil).GetWithdrawals), arg0, arg1)
 }
 
+// Hold mocks base method.
+func (m *MockBalanceService) Hold(arg0 context.Context, arg1, arg2 string, arg3 decimal.Decimal) (*dto.HoldResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hold", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*dto.HoldResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hold indicates an expected call of Hold.
+func (mr *MockBalanceServiceMockRecorder) Hold(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hold", reflect.TypeOf((*MockBalanceService)(nil).Hold), arg0, arg1, arg2, arg3)
+}
+
+// ReleaseHold mocks base method.
+func (m *MockBalanceService) ReleaseHold(arg0 context.Context, arg1, arg2 string) (*dto.HoldResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseHold", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.HoldResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseHold indicates an expected call of ReleaseHold.
+func (mr *MockBalanceServiceMockRecorder) ReleaseHold(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseHold", reflect.TypeOf((*MockBalanceService)(nil).ReleaseHold), arg0, arg1, arg2)
+}
+
+// Transfer mocks base method.
+func (m *MockBalanceService) Transfer(arg0 context.Context, arg1, arg2 string, arg3 decimal.Decimal) (*dto.TransferResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*dto.TransferResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockBalanceServiceMockRecorder) Transfer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockBalanceService)(nil).Transfer), arg0, arg1, arg2, arg3)
+}
+
 // Withdraw mocks base method.
-func (m *MockBalanceService) Withdraw(arg0 context.Context, arg1, arg2 string, arg3 decimal.Decimal) error {
+func (m *MockBalanceService) Withdraw(arg0 context.Context, arg1, arg2 string, arg3 decimal.Decimal, arg4 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Withdraw", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "Withdraw", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Withdraw indicates an expected call of Withdraw.
-func (mr *MockBalanceServiceMockRecorder) Withdraw(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockBalanceServiceMockRecorder) Withdraw(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdraw", reflect.TypeOf((*MockBalanceService)(nil).Withdraw), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdraw", reflect.TypeOf((*MockBalanceService)(nil).Withdraw), arg0, arg1, arg2, arg3, arg4)
 }
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/msmkdenis/yap-gophermart/internal/order/handler (interfaces: OrderAdminService)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/msmkdenis/yap-gophermart/internal/order/handler/dto"
+)
+
+// MockOrderAdminService is a mock of OrderAdminService interface.
+type MockOrderAdminService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderAdminServiceMockRecorder
+}
+
+// MockOrderAdminServiceMockRecorder is the mock recorder for MockOrderAdminService.
+type MockOrderAdminServiceMockRecorder struct {
+	mock *MockOrderAdminService
+}
+
+// NewMockOrderAdminService creates a new mock instance.
+func NewMockOrderAdminService(ctrl *gomock.Controller) *MockOrderAdminService {
+	mock := &MockOrderAdminService{ctrl: ctrl}
+	mock.recorder = &MockOrderAdminServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderAdminService) EXPECT() *MockOrderAdminServiceMockRecorder {
+	return m.recorder
+}
+
+// GetDeadLettered mocks base method.
+func (m *MockOrderAdminService) GetDeadLettered(arg0 context.Context) ([]dto.DeadLetteredOrderResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLettered", arg0)
+	ret0, _ := ret[0].([]dto.DeadLetteredOrderResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLettered indicates an expected call of GetDeadLettered.
+func (mr *MockOrderAdminServiceMockRecorder) GetDeadLettered(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLettered", reflect.TypeOf((*MockOrderAdminService)(nil).GetDeadLettered), arg0)
+}
+
+// GetLeased mocks base method.
+func (m *MockOrderAdminService) GetLeased(arg0 context.Context) ([]dto.LeasedOrderResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLeased", arg0)
+	ret0, _ := ret[0].([]dto.LeasedOrderResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLeased indicates an expected call of GetLeased.
+func (mr *MockOrderAdminServiceMockRecorder) GetLeased(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeased", reflect.TypeOf((*MockOrderAdminService)(nil).GetLeased), arg0)
+}
+
+// Requeue mocks base method.
+func (m *MockOrderAdminService) Requeue(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Requeue", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Requeue indicates an expected call of Requeue.
+func (mr *MockOrderAdminServiceMockRecorder) Requeue(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Requeue", reflect.TypeOf((*MockOrderAdminService)(nil).Requeue), arg0, arg1)
+}
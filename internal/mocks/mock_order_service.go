@@ -35,6 +35,21 @@ func (m *MockOrderService) EXPECT() *MockOrderServiceMockRecorder {
 	return m.recorder
 }
 
+// GetByNumber mocks base method.
+func (m *MockOrderService) GetByNumber(arg0 context.Context, arg1, arg2 string) (*dto.OrderDetailsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByNumber", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.OrderDetailsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByNumber indicates an expected call of GetByNumber.
+func (mr *MockOrderServiceMockRecorder) GetByNumber(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByNumber", reflect.TypeOf((*MockOrderService)(nil).GetByNumber), arg0, arg1, arg2)
+}
+
 // GetByUser mocks base method.
 func (m *MockOrderService) GetByUser(arg0 context.Context, arg1 string) ([]dto.OrderResponse, error) {
 	m.ctrl.T.Helper()
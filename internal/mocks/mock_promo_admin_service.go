@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/msmkdenis/yap-gophermart/internal/promo/handler (interfaces: PromoAdminService)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/msmkdenis/yap-gophermart/internal/promo/handler/dto"
+)
+
+// MockPromoAdminService is a mock of PromoAdminService interface.
+type MockPromoAdminService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromoAdminServiceMockRecorder
+}
+
+// MockPromoAdminServiceMockRecorder is the mock recorder for MockPromoAdminService.
+type MockPromoAdminServiceMockRecorder struct {
+	mock *MockPromoAdminService
+}
+
+// NewMockPromoAdminService creates a new mock instance.
+func NewMockPromoAdminService(ctrl *gomock.Controller) *MockPromoAdminService {
+	mock := &MockPromoAdminService{ctrl: ctrl}
+	mock.recorder = &MockPromoAdminServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromoAdminService) EXPECT() *MockPromoAdminServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPromoAdminService) Create(arg0 context.Context, arg1 dto.PromoCodeRequest) (*dto.PromoCodeResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(*dto.PromoCodeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPromoAdminServiceMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPromoAdminService)(nil).Create), arg0, arg1)
+}
+
+// GetAll mocks base method.
+func (m *MockPromoAdminService) GetAll(arg0 context.Context) ([]dto.PromoCodeResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", arg0)
+	ret0, _ := ret[0].([]dto.PromoCodeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockPromoAdminServiceMockRecorder) GetAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockPromoAdminService)(nil).GetAll), arg0)
+}
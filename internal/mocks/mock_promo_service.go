@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/msmkdenis/yap-gophermart/internal/promo/handler (interfaces: PromoService)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/msmkdenis/yap-gophermart/internal/promo/handler/dto"
+)
+
+// MockPromoService is a mock of PromoService interface.
+type MockPromoService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromoServiceMockRecorder
+}
+
+// MockPromoServiceMockRecorder is the mock recorder for MockPromoService.
+type MockPromoServiceMockRecorder struct {
+	mock *MockPromoService
+}
+
+// NewMockPromoService creates a new mock instance.
+func NewMockPromoService(ctrl *gomock.Controller) *MockPromoService {
+	mock := &MockPromoService{ctrl: ctrl}
+	mock.recorder = &MockPromoServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromoService) EXPECT() *MockPromoServiceMockRecorder {
+	return m.recorder
+}
+
+// Redeem mocks base method.
+func (m *MockPromoService) Redeem(arg0 context.Context, arg1, arg2 string) (*dto.RedemptionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeem", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.RedemptionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeem indicates an expected call of Redeem.
+func (mr *MockPromoServiceMockRecorder) Redeem(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeem", reflect.TypeOf((*MockPromoService)(nil).Redeem), arg0, arg1, arg2)
+}
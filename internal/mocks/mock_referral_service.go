@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/msmkdenis/yap-gophermart/internal/referral/handler (interfaces: ReferralService)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/msmkdenis/yap-gophermart/internal/referral/handler/dto"
+)
+
+// MockReferralService is a mock of ReferralService interface.
+type MockReferralService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReferralServiceMockRecorder
+}
+
+// MockReferralServiceMockRecorder is the mock recorder for MockReferralService.
+type MockReferralServiceMockRecorder struct {
+	mock *MockReferralService
+}
+
+// NewMockReferralService creates a new mock instance.
+func NewMockReferralService(ctrl *gomock.Controller) *MockReferralService {
+	mock := &MockReferralService{ctrl: ctrl}
+	mock.recorder = &MockReferralServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReferralService) EXPECT() *MockReferralServiceMockRecorder {
+	return m.recorder
+}
+
+// GetByUser mocks base method.
+func (m *MockReferralService) GetByUser(arg0 context.Context, arg1 string) (*dto.ReferralsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUser", arg0, arg1)
+	ret0, _ := ret[0].(*dto.ReferralsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUser indicates an expected call of GetByUser.
+func (mr *MockReferralServiceMockRecorder) GetByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUser", reflect.TypeOf((*MockReferralService)(nil).GetByUser), arg0, arg1)
+}
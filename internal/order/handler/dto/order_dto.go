@@ -25,6 +25,72 @@ func MapToOrderResponse(order model.Order) OrderResponse {
 	}
 }
 
+type OrderDetailsResponse struct {
+	Number            string                       `json:"number"`
+	Status            string                       `json:"status"`
+	Accrual           *decimal.Decimal             `json:"accrual,omitempty"`
+	RawAccrual        *decimal.Decimal             `json:"raw_accrual,omitempty"`
+	UploadedAt        string                       `json:"uploaded_at"`
+	AccrualCount      int                          `json:"accrual_count"`
+	AccrualStartedAt  string                       `json:"accrual_started_at,omitempty"`
+	AccrualFinishedAt string                       `json:"accrual_finished_at,omitempty"`
+	History           []OrderStatusHistoryResponse `json:"history"`
+}
+
+type OrderStatusHistoryResponse struct {
+	Status         string           `json:"status"`
+	PreviousStatus string           `json:"previous_status,omitempty"`
+	Accrual        *decimal.Decimal `json:"accrual,omitempty"`
+	Source         string           `json:"source"`
+	Attempt        int              `json:"attempt"`
+	ObservedAt     string           `json:"observed_at"`
+}
+
+func MapToOrderDetailsResponse(order model.OrderDetails) OrderDetailsResponse {
+	response := OrderDetailsResponse{
+		Number:       order.Number,
+		Status:       order.Status,
+		UploadedAt:   order.UploadedAt.Format(time.RFC3339),
+		AccrualCount: order.AccrualCount,
+		History:      make([]OrderStatusHistoryResponse, 0, len(order.History)),
+	}
+
+	if !order.Accrual.IsZero() {
+		response.Accrual = &order.Accrual
+	}
+
+	if !order.RawAccrual.IsZero() {
+		response.RawAccrual = &order.RawAccrual
+	}
+
+	if order.AccrualStartedAt != nil {
+		response.AccrualStartedAt = order.AccrualStartedAt.Format(time.RFC3339)
+	}
+
+	if order.AccrualFinishedAt != nil {
+		response.AccrualFinishedAt = order.AccrualFinishedAt.Format(time.RFC3339)
+	}
+
+	for _, h := range order.History {
+		historyResponse := OrderStatusHistoryResponse{
+			Status:     h.ToStatus,
+			Source:     h.Source,
+			Attempt:    h.Attempt,
+			ObservedAt: h.ObservedAt.Format(time.RFC3339),
+		}
+		if h.FromStatus != nil {
+			historyResponse.PreviousStatus = *h.FromStatus
+		}
+		if h.Accrual.Valid && !h.Accrual.Decimal.IsZero() {
+			accrual := h.Accrual.Decimal
+			historyResponse.Accrual = &accrual
+		}
+		response.History = append(response.History, historyResponse)
+	}
+
+	return response
+}
+
 func (o *OrderResponse) MarshalJSON() ([]byte, error) {
 	var jsonResponse []byte
 	var err error
@@ -53,3 +119,47 @@ func (o *OrderResponse) MarshalJSON() ([]byte, error) {
 	}
 	return jsonResponse, err
 }
+
+type LeasedOrderResponse struct {
+	Number          string `json:"number"`
+	UserLogin       string `json:"user_login"`
+	Status          string `json:"status"`
+	AccrualCount    int    `json:"accrual_count"`
+	LeasedAt        string `json:"leased_at"`
+	LeaseExpiresAt  string `json:"lease_expires_at"`
+	LeaseAgeSeconds int64  `json:"lease_age_seconds"`
+	Expired         bool   `json:"expired"`
+}
+
+func MapToLeasedOrderResponse(order model.LeasedOrder) LeasedOrderResponse {
+	return LeasedOrderResponse{
+		Number:          order.Number,
+		UserLogin:       order.UserLogin,
+		Status:          order.Status,
+		AccrualCount:    order.AccrualCount,
+		LeasedAt:        order.LeasedAt.Format(time.RFC3339),
+		LeaseExpiresAt:  order.LeaseExpiresAt.Format(time.RFC3339),
+		LeaseAgeSeconds: order.LeaseAgeSeconds,
+		Expired:         order.LeaseAgeSeconds > int64(order.LeaseExpiresAt.Sub(order.LeasedAt).Seconds()),
+	}
+}
+
+type DeadLetteredOrderResponse struct {
+	Number         string `json:"number"`
+	UserLogin      string `json:"user_login"`
+	Status         string `json:"status"`
+	AccrualCount   int    `json:"accrual_count"`
+	UploadedAt     string `json:"uploaded_at"`
+	DeadLetteredAt string `json:"dead_lettered_at"`
+}
+
+func MapToDeadLetteredOrderResponse(order model.DeadLetteredOrder) DeadLetteredOrderResponse {
+	return DeadLetteredOrderResponse{
+		Number:         order.Number,
+		UserLogin:      order.UserLogin,
+		Status:         order.Status,
+		AccrualCount:   order.AccrualCount,
+		UploadedAt:     order.UploadedAt.Format(time.RFC3339),
+		DeadLetteredAt: order.DeadLetteredAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+	"github.com/msmkdenis/yap-gophermart/internal/order/handler/dto"
+)
+
+// OrderAdminService mockgen --build_flags=--mod=mod -destination=internal/mocks/mock_order_admin_service.go -package=mock github.com/msmkdenis/yap-gophermart/internal/order/handler OrderAdminService
+type OrderAdminService interface {
+	GetLeased(ctx context.Context) ([]dto.LeasedOrderResponse, error)
+	GetDeadLettered(ctx context.Context) ([]dto.DeadLetteredOrderResponse, error)
+	Requeue(ctx context.Context, orderNumber string) error
+}
+
+type OrderAdminHandler struct {
+	orderService OrderAdminService
+	logger       *zap.Logger
+	adminAuth    *middleware.AdminAuth
+}
+
+func NewOrderAdminHandler(e *echo.Echo, service OrderAdminService, logger *zap.Logger, adminAuth *middleware.AdminAuth) *OrderAdminHandler {
+	handler := &OrderAdminHandler{
+		orderService: service,
+		logger:       logger,
+		adminAuth:    adminAuth,
+	}
+
+	adminOrders := e.Group("/api/admin/orders", adminAuth.AdminAuth())
+	adminOrders.GET("/leased", handler.GetLeasedOrders)
+	adminOrders.GET("/dead-letter", handler.GetDeadLetteredOrders)
+	adminOrders.POST("/:number/requeue", handler.RequeueOrder)
+
+	return handler
+}
+
+// @Summary       Get leased orders
+// @Description   Get a list of orders currently claimed by the accrual worker with their lease age.
+// @Tags          Admin API
+// @Produce       json
+// @Success       200    {array}    dto.LeasedOrderResponse
+// @Success       204
+// @Failure       401
+// @Failure       403
+// @Failure       500
+// @Security      AdminToken
+// @Router        /api/admin/orders/leased [get]
+func (h *OrderAdminHandler) GetLeasedOrders(c echo.Context) error {
+	orders, err := h.orderService.GetLeased(c.Request().Context())
+
+	if errors.Is(err, apperrors.ErrNoOrders) {
+		h.logger.Info("No leased orders", zap.Error(err))
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if err != nil {
+		h.logger.Error("Unknown error", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, orders)
+}
+
+// @Summary       Get dead-lettered orders
+// @Description   Get a list of orders whose accrual polling attempts were exhausted.
+// @Tags          Admin API
+// @Produce       json
+// @Success       200    {array}    dto.DeadLetteredOrderResponse
+// @Success       204
+// @Failure       401
+// @Failure       403
+// @Failure       500
+// @Security      AdminToken
+// @Router        /api/admin/orders/dead-letter [get]
+func (h *OrderAdminHandler) GetDeadLetteredOrders(c echo.Context) error {
+	orders, err := h.orderService.GetDeadLettered(c.Request().Context())
+
+	if errors.Is(err, apperrors.ErrNoOrders) {
+		h.logger.Info("No dead-lettered orders", zap.Error(err))
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if err != nil {
+		h.logger.Error("Unknown error", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, orders)
+}
+
+// @Summary       Requeue dead-lettered order
+// @Description   Return a dead-lettered order to accrual polling with a fresh attempt count.
+// @Tags          Admin API
+// @Param         number   path       string   true   "Order number."
+// @Success       200
+// @Failure       401
+// @Failure       403
+// @Failure       404
+// @Failure       500
+// @Security      AdminToken
+// @Router        /api/admin/orders/{number}/requeue [post]
+func (h *OrderAdminHandler) RequeueOrder(c echo.Context) error {
+	err := h.orderService.Requeue(c.Request().Context(), c.Param("number"))
+
+	if errors.Is(err, apperrors.ErrOrderNotFound) {
+		h.logger.Info("Dead-lettered order not found", zap.Error(err))
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		h.logger.Error("Unable to requeue order", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
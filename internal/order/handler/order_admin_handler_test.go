@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+	mock "github.com/msmkdenis/yap-gophermart/internal/mocks"
+	"github.com/msmkdenis/yap-gophermart/internal/order/handler/dto"
+)
+
+const adminTokenMock = "supersecretadmintoken"
+
+type OrderAdminHandlersSuite struct {
+	suite.Suite
+	h            *OrderAdminHandler
+	orderService *mock.MockOrderAdminService
+	echo         *echo.Echo
+	ctrl         *gomock.Controller
+}
+
+func TestAdminSuite(t *testing.T) {
+	suite.Run(t, new(OrderAdminHandlersSuite))
+}
+
+func (o *OrderAdminHandlersSuite) SetupTest() {
+	logger, _ := zap.NewProduction()
+	adminAuth := middleware.InitAdminAuth(adminTokenMock, logger)
+	o.ctrl = gomock.NewController(o.T())
+	o.echo = echo.New()
+	o.orderService = mock.NewMockOrderAdminService(o.ctrl)
+	o.h = NewOrderAdminHandler(o.echo, o.orderService, logger, adminAuth)
+}
+
+func (o *OrderAdminHandlersSuite) TestGetLeasedOrders() {
+	leasedOrders := []dto.LeasedOrderResponse{
+		{
+			Number:          "12345678903",
+			UserLogin:       "awesome_login",
+			Status:          "PROCESSING",
+			AccrualCount:    2,
+			LeasedAt:        "2024-01-10T12:00:00Z",
+			LeaseExpiresAt:  "2024-01-10T12:01:00Z",
+			LeaseAgeSeconds: 75,
+			Expired:         true,
+		},
+	}
+
+	response, errMarshal := json.Marshal(leasedOrders)
+	require.NoError(o.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		token        string
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name:  "Unauthorized - 401",
+			token: "wrong_token",
+			prepare: func() {
+				o.orderService.EXPECT().GetLeased(gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:  "Success - 200",
+			token: adminTokenMock,
+			prepare: func() {
+				o.orderService.EXPECT().GetLeased(gomock.Any()).Times(1).Return(leasedOrders, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+		{
+			name:  "No leased orders - 204",
+			token: adminTokenMock,
+			prepare: func() {
+				o.orderService.EXPECT().GetLeased(gomock.Any()).Times(1).Return(nil, apperrors.ErrNoOrders)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:  "InternalServerError - 500",
+			token: adminTokenMock,
+			prepare: func() {
+				o.orderService.EXPECT().GetLeased(gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		o.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/admin/orders/leased", nil)
+			request.Header.Set("X-Admin-Token", test.token)
+
+			w := httptest.NewRecorder()
+			o.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			} else {
+				assert.Equal(t, "", w.Body.String())
+			}
+		})
+	}
+}
+
+func (o *OrderAdminHandlersSuite) TestRequeueOrder() {
+	orderNumber := "12345678903"
+
+	testCases := []struct {
+		name         string
+		token        string
+		prepare      func()
+		expectedCode int
+	}{
+		{
+			name:  "Unauthorized - 401",
+			token: "",
+			prepare: func() {
+				o.orderService.EXPECT().Requeue(gomock.Any(), orderNumber).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:  "Success - 200",
+			token: adminTokenMock,
+			prepare: func() {
+				o.orderService.EXPECT().Requeue(gomock.Any(), orderNumber).Times(1).Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:  "Not dead-lettered - 404",
+			token: adminTokenMock,
+			prepare: func() {
+				o.orderService.EXPECT().Requeue(gomock.Any(), orderNumber).Times(1).Return(apperrors.ErrOrderNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:  "InternalServerError - 500",
+			token: adminTokenMock,
+			prepare: func() {
+				o.orderService.EXPECT().Requeue(gomock.Any(), orderNumber).Times(1).Return(errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		o.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "http://localhost:8000/api/admin/orders/"+orderNumber+"/requeue", nil)
+			request.Header.Set("X-Admin-Token", test.token)
+
+			w := httptest.NewRecorder()
+			o.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			assert.Equal(t, "", w.Body.String())
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 type OrderService interface {
 	Upload(ctx context.Context, orderNumber string, userLogin string) error
 	GetByUser(ctx context.Context, userLogin string) ([]dto.OrderResponse, error)
+	GetByNumber(ctx context.Context, orderNumber string, userLogin string) (*dto.OrderDetailsResponse, error)
 }
 
 type OrderHandler struct {
@@ -37,6 +38,7 @@ func NewOrderHandler(e *echo.Echo, service OrderService, logger *zap.Logger, jwt
 	protectedOrders := e.Group("/api/user/orders", jwtAuth.JWTAuth())
 	protectedOrders.POST("", handler.AddOrder)
 	protectedOrders.GET("", handler.GetOrders)
+	protectedOrders.GET("/:number", handler.GetOrder)
 
 	return handler
 }
@@ -131,6 +133,39 @@ func (h *OrderHandler) GetOrders(c echo.Context) error {
 	return c.JSON(http.StatusOK, orders)
 }
 
+// @Summary       Get uploaded order
+// @Description   Get the order uploaded by the user with its full status transition history.
+// @Tags          Order API
+// @Produce       json
+// @Param         number   path       string   true   "Order number."
+// @Success       200    {object}   dto.OrderDetailsResponse
+// @Failure       401
+// @Failure       404
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/orders/{number} [get]
+func (h *OrderHandler) GetOrder(c echo.Context) error {
+	userLogin, ok := c.Get("userLogin").(string)
+	if !ok {
+		h.logger.Error("Internal server error", zap.Error(apperrors.ErrUnableToGetUserLoginFromContext))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	order, err := h.orderService.GetByNumber(c.Request().Context(), c.Param("number"), userLogin)
+
+	if errors.Is(err, apperrors.ErrOrderNotFound) {
+		h.logger.Info("Order not found", zap.Error(err))
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if err != nil {
+		h.logger.Error("Unknown error", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
 func (h *OrderHandler) checkRequest(s string) error {
 	if len(s) == 0 {
 		return apperrors.NewValueError("Unable to handle empty request", utils.Caller(), apperrors.ErrEmptyOrderRequest)
@@ -299,6 +299,102 @@ func (o *OrderHandlersSuite) TestGetOrders() {
 	}
 }
 
+func (o *OrderHandlersSuite) TestGetOrder() {
+	login := "awesome_login"
+	orderNumber := "12345678903"
+
+	cookie, errCookie := o.createCookie(login)
+	require.NoError(o.T(), errCookie)
+
+	orderResponse := &dto.OrderDetailsResponse{
+		Number:       orderNumber,
+		Status:       "PROCESSING",
+		UploadedAt:   "2020-01-01T00:00:00Z",
+		AccrualCount: 1,
+		History: []dto.OrderStatusHistoryResponse{
+			{
+				Status:     "NEW",
+				Source:     "upload",
+				ObservedAt: "2020-01-01T00:00:00Z",
+			},
+			{
+				Status:         "PROCESSING",
+				PreviousStatus: "NEW",
+				Source:         "accrual",
+				Attempt:        1,
+				ObservedAt:     "2020-01-01T00:00:05Z",
+			},
+		},
+	}
+
+	response, errMarshal := json.Marshal(orderResponse)
+	require.NoError(o.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		cookie       *http.Cookie
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name: "Unauthorized - 401",
+			prepare: func() {
+				o.orderService.EXPECT().GetByNumber(gomock.Any(), orderNumber, login).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "Success - 200",
+			cookie: cookie,
+			prepare: func() {
+				o.orderService.EXPECT().GetByNumber(gomock.Any(), orderNumber, login).Times(1).Return(orderResponse, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+		{
+			name:   "NotFound - 404",
+			cookie: cookie,
+			prepare: func() {
+				o.orderService.EXPECT().GetByNumber(gomock.Any(), orderNumber, login).Times(1).Return(nil, apperrors.ErrOrderNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:   "InternalServerError - 500",
+			cookie: cookie,
+			prepare: func() {
+				o.orderService.EXPECT().GetByNumber(gomock.Any(), orderNumber, login).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		o.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/user/orders/"+orderNumber, nil)
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+
+			w := httptest.NewRecorder()
+			o.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			} else {
+				assert.Equal(t, "", w.Body.String())
+			}
+		})
+	}
+}
+
 func (o *OrderHandlersSuite) createCookie(login string) (*http.Cookie, error) {
 	token, err := o.jwtManager.BuildJWTString(login)
 
@@ -6,11 +6,50 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Order of the user. RawAccrual is the accrual returned by the accrual system,
+// Accrual is the accrual credited to the balance after the loyalty tier multiplier is applied.
 type Order struct {
-	ID         string          `db:"id"`
-	Number     string          `db:"number"`
-	UserLogin  string          `db:"user_login"`
-	UploadedAt time.Time       `db:"uploaded_at"`
-	Accrual    decimal.Decimal `db:"accrual"`
-	Status     string          `db:"status"`
+	ID           string          `db:"id"`
+	Number       string          `db:"number"`
+	UserLogin    string          `db:"user_login"`
+	UploadedAt   time.Time       `db:"uploaded_at"`
+	Accrual      decimal.Decimal `db:"accrual"`
+	Status       string          `db:"status"`
+	AccrualCount int             `db:"accrual_count"`
+	RawAccrual   decimal.Decimal `db:"raw_accrual"`
+}
+
+type LeasedOrder struct {
+	Number          string    `db:"number"`
+	UserLogin       string    `db:"user_login"`
+	Status          string    `db:"status"`
+	AccrualCount    int       `db:"accrual_count"`
+	LeasedAt        time.Time `db:"accrual_started_at"`
+	LeaseExpiresAt  time.Time `db:"accrual_lease_expires_at"`
+	LeaseAgeSeconds int64     `db:"lease_age"`
+}
+
+type DeadLetteredOrder struct {
+	Number         string    `db:"number"`
+	UserLogin      string    `db:"user_login"`
+	Status         string    `db:"status"`
+	AccrualCount   int       `db:"accrual_count"`
+	UploadedAt     time.Time `db:"uploaded_at"`
+	DeadLetteredAt time.Time `db:"accrual_dead_lettered_at"`
+}
+
+type OrderDetails struct {
+	Order
+	AccrualStartedAt  *time.Time
+	AccrualFinishedAt *time.Time
+	History           []StatusHistory
+}
+
+type StatusHistory struct {
+	FromStatus *string             `db:"from_status"`
+	ToStatus   string              `db:"to_status"`
+	Accrual    decimal.NullDecimal `db:"accrual"`
+	Source     string              `db:"source"`
+	Attempt    int                 `db:"attempt"`
+	ObservedAt time.Time           `db:"observed_at"`
 }
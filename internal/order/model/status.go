@@ -0,0 +1,48 @@
+package model
+
+const (
+	StatusNew        = "NEW"
+	StatusRegistered = "REGISTERED"
+	StatusProcessing = "PROCESSING"
+	StatusProcessed  = "PROCESSED"
+	StatusInvalid    = "INVALID"
+)
+
+const (
+	SourceUpload    = "upload"
+	SourceAccrual   = "accrual"
+	SourceMigration = "migration"
+)
+
+// transitions lists the statuses each non-final status may move to:
+// NEW → REGISTERED/PROCESSING → PROCESSED/INVALID, skipping intermediate statuses is allowed.
+var transitions = map[string][]string{
+	StatusNew:        {StatusRegistered, StatusProcessing, StatusProcessed, StatusInvalid},
+	StatusRegistered: {StatusProcessing, StatusProcessed, StatusInvalid},
+	StatusProcessing: {StatusProcessed, StatusInvalid},
+}
+
+func IsFinal(status string) bool {
+	return status == StatusProcessed || status == StatusInvalid
+}
+
+func IsKnown(status string) bool {
+	_, ok := transitions[status]
+	return ok || IsFinal(status)
+}
+
+// CanTransition reports whether an order may move from one status to another.
+// Staying in the same non-final status is allowed, a final status can never be left.
+func CanTransition(from string, to string) bool {
+	if from == to {
+		return !IsFinal(from) && IsKnown(from)
+	}
+
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
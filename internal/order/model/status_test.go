@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition(t *testing.T) {
+	testCases := []struct {
+		from     string
+		to       string
+		expected bool
+	}{
+		{from: StatusNew, to: StatusRegistered, expected: true},
+		{from: StatusNew, to: StatusProcessing, expected: true},
+		{from: StatusNew, to: StatusProcessed, expected: true},
+		{from: StatusNew, to: StatusInvalid, expected: true},
+		{from: StatusRegistered, to: StatusRegistered, expected: true},
+		{from: StatusRegistered, to: StatusProcessing, expected: true},
+		{from: StatusRegistered, to: StatusNew, expected: false},
+		{from: StatusProcessing, to: StatusRegistered, expected: false},
+		{from: StatusProcessing, to: StatusProcessed, expected: true},
+		{from: StatusProcessed, to: StatusProcessed, expected: false},
+		{from: StatusProcessed, to: StatusProcessing, expected: false},
+		{from: StatusInvalid, to: StatusProcessed, expected: false},
+		{from: StatusNew, to: "UNKNOWN", expected: false},
+		{from: "UNKNOWN", to: "UNKNOWN", expected: false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.from+" -> "+test.to, func(t *testing.T) {
+			assert.Equal(t, test.expected, CanTransition(test.from, test.to))
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	"github.com/msmkdenis/yap-gophermart/internal/order/model"
+	userModel "github.com/msmkdenis/yap-gophermart/internal/user/model"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+// MemoryOrder is a row of the order table with the accrual polling columns.
+// The balance repository reads the raw accruals of the table for the loyalty tiers.
+type MemoryOrder struct {
+	model.Order
+	AccrualReadiness  bool
+	AccrualStartedAt  *time.Time
+	AccrualFinishedAt *time.Time
+	LeaseExpiresAt    *time.Time
+	NextAttemptAt     time.Time
+	DeadLetteredAt    *time.Time
+}
+
+type MemoryOrderRepository struct {
+	storage *db.MemoryStorage
+	orders  *db.MemoryTable[string, MemoryOrder]
+	history *db.MemoryTable[string, []model.StatusHistory]
+	users   *db.MemoryTable[string, userModel.User]
+	logger  *zap.Logger
+	now     func() time.Time
+}
+
+func NewMemoryOrderRepository(storage *db.MemoryStorage, logger *zap.Logger) *MemoryOrderRepository {
+	return &MemoryOrderRepository{
+		storage: storage,
+		orders:  db.Table[string, MemoryOrder](storage, "order"),
+		history: db.Table[string, []model.StatusHistory](storage, "order_status_history"),
+		users:   db.Table[string, userModel.User](storage, "user"),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// UpdateOrder applies the accrual response to the order only if the order is still in previousStatus
+// and the transition is legal, status changes are recorded in the order history.
+func (r *MemoryOrderRepository) UpdateOrder(ctx context.Context, order model.Order, previousStatus string, retryDelay time.Duration) error {
+	if !model.CanTransition(previousStatus, order.Status) {
+		return apperrors.ErrIllegalStatusTransition
+	}
+
+	defer r.storage.Lock(ctx)()
+
+	row, ok := r.orders.Get(order.Number)
+	if !ok || row.Status != previousStatus || model.IsFinal(row.Status) {
+		return apperrors.ErrOrderStatusConflict
+	}
+
+	now := r.now()
+	row.Accrual = order.Accrual
+	row.RawAccrual = order.RawAccrual
+	row.Status = order.Status
+	row.AccrualReadiness = !model.IsFinal(order.Status)
+	row.LeaseExpiresAt = nil
+	row.NextAttemptAt = now.Add(retryDelay)
+	row.AccrualFinishedAt = &now
+	row.AccrualCount++
+	r.orders.Put(row.Number, row)
+
+	if previousStatus != order.Status {
+		from := previousStatus
+		r.appendHistory(order.Number, model.StatusHistory{
+			FromStatus: &from,
+			ToStatus:   order.Status,
+			Accrual:    decimal.NullDecimal{Decimal: order.Accrual, Valid: true},
+			Source:     model.SourceAccrual,
+			Attempt:    row.AccrualCount,
+			ObservedAt: now,
+		})
+	}
+
+	return nil
+}
+
+func (r *MemoryOrderRepository) Insert(ctx context.Context, order model.Order) error {
+	defer r.storage.Lock(ctx)()
+
+	if existing, ok := r.orders.Get(order.Number); ok {
+		if existing.UserLogin == order.UserLogin {
+			return apperrors.ErrOrderUploadedByUser
+		}
+		return apperrors.ErrOrderUploadedByAnotherUser
+	}
+
+	if _, ok := r.users.Get(order.UserLogin); !ok {
+		return apperrors.NewValueError("user not found", utils.Caller(), apperrors.ErrUserNotFound)
+	}
+
+	now := r.now()
+	order.UploadedAt = now
+	r.orders.Put(order.Number, MemoryOrder{
+		Order:            order,
+		AccrualReadiness: true,
+		NextAttemptAt:    now,
+	})
+	r.appendHistory(order.Number, model.StatusHistory{
+		ToStatus:   order.Status,
+		Source:     model.SourceUpload,
+		ObservedAt: now,
+	})
+
+	return nil
+}
+
+func (r *MemoryOrderRepository) SelectAll(ctx context.Context, userLogin string) ([]model.Order, error) {
+	defer r.storage.Lock(ctx)()
+
+	rows := r.orders.Select(func(row MemoryOrder) bool {
+		return row.UserLogin == userLogin
+	})
+	sortByUploadedAtDesc(rows)
+
+	orders := make([]model.Order, 0, len(rows))
+	for _, row := range rows {
+		orders = append(orders, row.Order)
+	}
+
+	return orders, nil
+}
+
+func (r *MemoryOrderRepository) ClaimOrders(ctx context.Context, limit int, leaseTimeout time.Duration) ([]model.Order, error) {
+	defer r.storage.Lock(ctx)()
+
+	now := r.now()
+	rows := r.orders.Select(func(row MemoryOrder) bool {
+		return !model.IsFinal(row.Status) &&
+			row.DeadLetteredAt == nil &&
+			!row.NextAttemptAt.After(now) &&
+			(row.AccrualReadiness || row.LeaseExpiresAt != nil && row.LeaseExpiresAt.Before(now))
+	})
+	sortByUploadedAtDesc(rows)
+
+	orders := make([]model.Order, 0, min(limit, len(rows)))
+	for _, row := range rows[:min(limit, len(rows))] {
+		leaseExpiresAt := now.Add(leaseTimeout)
+		row.AccrualReadiness = false
+		row.AccrualStartedAt = &now
+		row.LeaseExpiresAt = &leaseExpiresAt
+		r.orders.Put(row.Number, row)
+		orders = append(orders, row.Order)
+	}
+
+	if len(orders) == 0 {
+		return nil, apperrors.NewValueError("no orders to process", utils.Caller(), apperrors.ErrNoOrders)
+	}
+
+	return orders, nil
+}
+
+func (r *MemoryOrderRepository) ReleaseOrder(ctx context.Context, orderNumber string) error {
+	defer r.storage.Lock(ctx)()
+
+	row, ok := r.orders.Get(orderNumber)
+	if !ok || row.AccrualReadiness || row.DeadLetteredAt != nil || model.IsFinal(row.Status) {
+		return nil
+	}
+
+	row.AccrualReadiness = true
+	row.LeaseExpiresAt = nil
+	r.orders.Put(orderNumber, row)
+
+	return nil
+}
+
+func (r *MemoryOrderRepository) SelectLeased(ctx context.Context) ([]model.LeasedOrder, error) {
+	defer r.storage.Lock(ctx)()
+
+	rows := r.orders.Select(func(row MemoryOrder) bool {
+		return !row.AccrualReadiness && row.DeadLetteredAt == nil && !model.IsFinal(row.Status)
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		return timeOrZero(rows[i].AccrualStartedAt).Before(timeOrZero(rows[j].AccrualStartedAt))
+	})
+
+	now := r.now()
+	orders := make([]model.LeasedOrder, 0, len(rows))
+	for _, row := range rows {
+		leasedAt := timeOrZero(row.AccrualStartedAt)
+		orders = append(orders, model.LeasedOrder{
+			Number:          row.Number,
+			UserLogin:       row.UserLogin,
+			Status:          row.Status,
+			AccrualCount:    row.AccrualCount,
+			LeasedAt:        leasedAt,
+			LeaseExpiresAt:  timeOrZero(row.LeaseExpiresAt),
+			LeaseAgeSeconds: int64(now.Sub(leasedAt).Seconds()),
+		})
+	}
+
+	return orders, nil
+}
+
+func (r *MemoryOrderRepository) RetryOrder(ctx context.Context, orderNumber string, retryDelay time.Duration) error {
+	defer r.storage.Lock(ctx)()
+
+	row, ok := r.orders.Get(orderNumber)
+	if !ok || row.DeadLetteredAt != nil || model.IsFinal(row.Status) {
+		return nil
+	}
+
+	now := r.now()
+	row.AccrualReadiness = true
+	row.LeaseExpiresAt = nil
+	row.NextAttemptAt = now.Add(retryDelay)
+	row.AccrualFinishedAt = &now
+	row.AccrualCount++
+	r.orders.Put(orderNumber, row)
+
+	return nil
+}
+
+func (r *MemoryOrderRepository) DeadLetterOrder(ctx context.Context, orderNumber string) error {
+	defer r.storage.Lock(ctx)()
+
+	row, ok := r.orders.Get(orderNumber)
+	if !ok || model.IsFinal(row.Status) {
+		return nil
+	}
+
+	now := r.now()
+	row.AccrualReadiness = false
+	row.LeaseExpiresAt = nil
+	row.DeadLetteredAt = &now
+	r.orders.Put(orderNumber, row)
+
+	return nil
+}
+
+func (r *MemoryOrderRepository) Requeue(ctx context.Context, orderNumber string) error {
+	defer r.storage.Lock(ctx)()
+
+	row, ok := r.orders.Get(orderNumber)
+	if !ok || row.DeadLetteredAt == nil {
+		return apperrors.ErrOrderNotFound
+	}
+
+	row.AccrualReadiness = true
+	row.LeaseExpiresAt = nil
+	row.DeadLetteredAt = nil
+	row.NextAttemptAt = r.now()
+	row.AccrualCount = 0
+	r.orders.Put(orderNumber, row)
+
+	return nil
+}
+
+func (r *MemoryOrderRepository) SelectDeadLettered(ctx context.Context) ([]model.DeadLetteredOrder, error) {
+	defer r.storage.Lock(ctx)()
+
+	rows := r.orders.Select(func(row MemoryOrder) bool {
+		return row.DeadLetteredAt != nil
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].DeadLetteredAt.Before(*rows[j].DeadLetteredAt)
+	})
+
+	orders := make([]model.DeadLetteredOrder, 0, len(rows))
+	for _, row := range rows {
+		orders = append(orders, model.DeadLetteredOrder{
+			Number:         row.Number,
+			UserLogin:      row.UserLogin,
+			Status:         row.Status,
+			AccrualCount:   row.AccrualCount,
+			UploadedAt:     row.UploadedAt,
+			DeadLetteredAt: *row.DeadLetteredAt,
+		})
+	}
+
+	return orders, nil
+}
+
+func (r *MemoryOrderRepository) SelectByNumber(ctx context.Context, orderNumber string, userLogin string) (*model.OrderDetails, error) {
+	defer r.storage.Lock(ctx)()
+
+	row, ok := r.orders.Get(orderNumber)
+	if !ok || row.UserLogin != userLogin {
+		return nil, apperrors.ErrOrderNotFound
+	}
+
+	history, _ := r.history.Get(orderNumber)
+
+	return &model.OrderDetails{
+		Order:             row.Order,
+		AccrualStartedAt:  row.AccrualStartedAt,
+		AccrualFinishedAt: row.AccrualFinishedAt,
+		History:           append([]model.StatusHistory(nil), history...),
+	}, nil
+}
+
+func (r *MemoryOrderRepository) appendHistory(orderNumber string, entry model.StatusHistory) {
+	history, _ := r.history.Get(orderNumber)
+	r.history.Put(orderNumber, append(history[:len(history):len(history)], entry))
+}
+
+func sortByUploadedAtDesc(rows []MemoryOrder) {
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].UploadedAt.After(rows[j].UploadedAt)
+	})
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"errors"
+	"time"
 
 	trmpgx "github.com/avito-tech/go-transaction-manager/pgxv5"
 	"github.com/jackc/pgerrcode"
@@ -27,8 +28,8 @@ var selectAllOrders string
 //go:embed queries/is_order_uploaded_by_user.sql
 var isOrderUploadedByUser string
 
-//go:embed queries/select_ten_orders.sql
-var selectTenOrders string
+//go:embed queries/claim_orders.sql
+var claimOrders string
 
 //go:embed queries/update_order_by_order_number.sql
 var updateOrderByNumber string
@@ -36,6 +37,33 @@ var updateOrderByNumber string
 //go:embed queries/block_order_by_user.sql
 var blockOrderByUser string
 
+//go:embed queries/release_order.sql
+var releaseOrder string
+
+//go:embed queries/select_leased_orders.sql
+var selectLeasedOrders string
+
+//go:embed queries/retry_order.sql
+var retryOrder string
+
+//go:embed queries/dead_letter_order.sql
+var deadLetterOrder string
+
+//go:embed queries/requeue_order.sql
+var requeueOrder string
+
+//go:embed queries/select_dead_lettered_orders.sql
+var selectDeadLetteredOrders string
+
+//go:embed queries/insert_order_status_history.sql
+var insertOrderStatusHistory string
+
+//go:embed queries/select_order_by_number.sql
+var selectOrderByNumber string
+
+//go:embed queries/select_order_status_history.sql
+var selectOrderStatusHistory string
+
 type PostgresOrderRepository struct {
 	postgresPool *db.PostgresPool
 	logger       *zap.Logger
@@ -50,20 +78,43 @@ func NewPostgresOrderRepository(postgresPool *db.PostgresPool, logger *zap.Logge
 	}
 }
 
-func (r *PostgresOrderRepository) UpdateOrder(ctx context.Context, order model.Order) error {
+// UpdateOrder applies the accrual response to the order only if the order is still in previousStatus
+// and the transition is legal, status changes are recorded in the order history. Must be called within a transaction.
+func (r *PostgresOrderRepository) UpdateOrder(ctx context.Context, order model.Order, previousStatus string, retryDelay time.Duration) error {
+	if !model.CanTransition(previousStatus, order.Status) {
+		return apperrors.ErrIllegalStatusTransition
+	}
+
 	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
 
 	batch := &pgx.Batch{}
 	batch.Queue(blockOrderByUser, order.UserLogin)
-	batch.Queue(updateOrderByNumber, order.Accrual, order.Status, order.Number)
+	batch.Queue(updateOrderByNumber, order.Accrual, order.Status, order.Number, retryDelay.Seconds(), previousStatus, order.RawAccrual)
 	result := conn.SendBatch(ctx, batch)
 
+	var attempt int
+	_, errBlock := result.Exec()
+	errUpdate := result.QueryRow().Scan(&attempt)
+
 	err := result.Close()
+	if errors.Is(errUpdate, pgx.ErrNoRows) && errBlock == nil && err == nil {
+		return apperrors.ErrOrderStatusConflict
+	}
+
+	if err = errors.Join(errBlock, errUpdate, err); err != nil {
+		return apperrors.NewValueError("batch failed", utils.Caller(), err)
+	}
+
+	if previousStatus == order.Status {
+		return nil
+	}
+
+	_, err = conn.Exec(ctx, insertOrderStatusHistory, order.Number, previousStatus, order.Status, order.Accrual, model.SourceAccrual, attempt)
 	if err != nil {
-		return apperrors.NewValueError("close failed", utils.Caller(), err)
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
 	}
 
-	return err
+	return nil
 }
 
 func (r *PostgresOrderRepository) Insert(ctx context.Context, order model.Order) error {
@@ -77,7 +128,10 @@ func (r *PostgresOrderRepository) Insert(ctx context.Context, order model.Order)
 		return apperrors.ErrOrderUploadedByUser
 	}
 
-	_, err := r.postgresPool.DB.Exec(ctx, insertOrder, order.ID, order.Number, order.UserLogin, order.Status)
+	batch := &pgx.Batch{}
+	batch.Queue(insertOrder, order.ID, order.Number, order.UserLogin, order.Status)
+	batch.Queue(insertOrderStatusHistory, order.Number, nil, order.Status, nil, model.SourceUpload, 0)
+	err := r.postgresPool.DB.SendBatch(ctx, batch).Close()
 
 	var e *pgconn.PgError
 	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
@@ -102,8 +156,8 @@ func (r *PostgresOrderRepository) SelectAll(ctx context.Context, userLogin strin
 	return orders, nil
 }
 
-func (r *PostgresOrderRepository) SelectTenOrders(ctx context.Context) ([]model.Order, error) {
-	queryRows, err := r.postgresPool.DB.Query(ctx, selectTenOrders)
+func (r *PostgresOrderRepository) ClaimOrders(ctx context.Context, limit int, leaseTimeout time.Duration) ([]model.Order, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, claimOrders, leaseTimeout.Seconds(), limit)
 	if err != nil {
 		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
 	}
@@ -120,3 +174,114 @@ func (r *PostgresOrderRepository) SelectTenOrders(ctx context.Context) ([]model.
 
 	return orders, nil
 }
+
+func (r *PostgresOrderRepository) ReleaseOrder(ctx context.Context, orderNumber string) error {
+	_, err := r.postgresPool.DB.Exec(ctx, releaseOrder, orderNumber)
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+func (r *PostgresOrderRepository) SelectLeased(ctx context.Context) ([]model.LeasedOrder, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectLeasedOrders)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	orders, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.LeasedOrder])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	return orders, nil
+}
+
+func (r *PostgresOrderRepository) RetryOrder(ctx context.Context, orderNumber string, retryDelay time.Duration) error {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	_, err := conn.Exec(ctx, retryOrder, orderNumber, retryDelay.Seconds())
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+func (r *PostgresOrderRepository) DeadLetterOrder(ctx context.Context, orderNumber string) error {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	_, err := conn.Exec(ctx, deadLetterOrder, orderNumber)
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+func (r *PostgresOrderRepository) Requeue(ctx context.Context, orderNumber string) error {
+	tag, err := r.postgresPool.DB.Exec(ctx, requeueOrder, orderNumber)
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrOrderNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresOrderRepository) SelectDeadLettered(ctx context.Context) ([]model.DeadLetteredOrder, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectDeadLetteredOrders)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	orders, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.DeadLetteredOrder])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	return orders, nil
+}
+
+func (r *PostgresOrderRepository) SelectByNumber(ctx context.Context, orderNumber string, userLogin string) (*model.OrderDetails, error) {
+	var order model.OrderDetails
+	err := r.postgresPool.DB.QueryRow(ctx, selectOrderByNumber, orderNumber, userLogin).Scan(
+		&order.ID,
+		&order.Number,
+		&order.UserLogin,
+		&order.UploadedAt,
+		&order.Accrual,
+		&order.Status,
+		&order.AccrualCount,
+		&order.AccrualStartedAt,
+		&order.AccrualFinishedAt,
+		&order.RawAccrual,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = apperrors.ErrOrderNotFound
+		} else {
+			err = apperrors.NewValueError("query failed", utils.Caller(), err)
+		}
+		return nil, err
+	}
+
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectOrderStatusHistory, orderNumber)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	order.History, err = pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.StatusHistory])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	return &order, nil
+}
@@ -0,0 +1,18 @@
+update gophermart.order
+set
+    accrual_readiness = false,
+    accrual_started_at = now(),
+    accrual_lease_expires_at = now() + make_interval(secs => $1)
+where id in
+      (select id
+      from gophermart."order"
+      where status not in ('INVALID', 'PROCESSED')
+        and accrual_dead_lettered_at is null
+        and accrual_next_attempt_at <= now()
+        and (accrual_readiness = true or accrual_lease_expires_at < now())
+      order by uploaded_at desc
+      for update skip locked
+      limit $2)
+returning
+    id, number, user_login, uploaded_at, coalesce(accrual, 0), status, accrual_count, coalesce(raw_accrual, 0);
+
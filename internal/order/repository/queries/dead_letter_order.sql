@@ -0,0 +1,6 @@
+update gophermart.order
+set
+    accrual_readiness = false,
+    accrual_lease_expires_at = null,
+    accrual_dead_lettered_at = now()
+where number = $1 and status not in ('INVALID', 'PROCESSED');
\ No newline at end of file
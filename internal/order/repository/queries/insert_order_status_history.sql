@@ -0,0 +1,3 @@
+insert into gophermart.order_status_history
+    (order_number, from_status, to_status, accrual, source, attempt)
+values ($1, $2, $3, $4, $5, $6);
\ No newline at end of file
@@ -0,0 +1,8 @@
+update gophermart.order
+set
+    accrual_readiness = true,
+    accrual_lease_expires_at = null
+where number = $1
+    and accrual_readiness = false
+    and accrual_dead_lettered_at is null
+    and status not in ('INVALID', 'PROCESSED');
\ No newline at end of file
@@ -0,0 +1,8 @@
+update gophermart.order
+set
+    accrual_readiness = true,
+    accrual_lease_expires_at = null,
+    accrual_dead_lettered_at = null,
+    accrual_next_attempt_at = now(),
+    accrual_count = 0
+where number = $1 and accrual_dead_lettered_at is not null;
\ No newline at end of file
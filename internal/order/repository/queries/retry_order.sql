@@ -0,0 +1,10 @@
+update gophermart.order
+set
+    accrual_readiness = true,
+    accrual_lease_expires_at = null,
+    accrual_next_attempt_at = now() + make_interval(secs => $2),
+    accrual_finished_at = now(),
+    accrual_count = accrual_count + 1
+where number = $1
+    and accrual_dead_lettered_at is null
+    and status not in ('INVALID', 'PROCESSED');
\ No newline at end of file
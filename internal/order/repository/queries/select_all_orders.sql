@@ -4,7 +4,9 @@ select
     user_login,
     uploaded_at,
     coalesce(accrual, 0),
-    status
+    status,
+    accrual_count,
+    coalesce(raw_accrual, 0)
 from gophermart."order"
 where user_login = $1
 order by uploaded_at desc;
\ No newline at end of file
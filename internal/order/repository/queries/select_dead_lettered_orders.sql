@@ -0,0 +1,10 @@
+select
+    number,
+    user_login,
+    status,
+    accrual_count,
+    uploaded_at,
+    accrual_dead_lettered_at
+from gophermart."order"
+where accrual_dead_lettered_at is not null
+order by accrual_dead_lettered_at;
\ No newline at end of file
@@ -0,0 +1,13 @@
+select
+    number,
+    user_login,
+    status,
+    accrual_count,
+    accrual_started_at,
+    accrual_lease_expires_at,
+    extract(epoch from now() - accrual_started_at)::bigint
+from gophermart."order"
+where accrual_readiness = false
+    and accrual_dead_lettered_at is null
+    and status not in ('INVALID', 'PROCESSED')
+order by accrual_started_at;
\ No newline at end of file
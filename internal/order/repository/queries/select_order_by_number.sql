@@ -0,0 +1,13 @@
+select
+    id,
+    number,
+    user_login,
+    uploaded_at,
+    coalesce(accrual, 0),
+    status,
+    accrual_count,
+    accrual_started_at,
+    accrual_finished_at,
+    coalesce(raw_accrual, 0)
+from gophermart."order"
+where number = $1 and user_login = $2;
\ No newline at end of file
@@ -0,0 +1,10 @@
+select
+    from_status,
+    to_status,
+    accrual,
+    source,
+    attempt,
+    observed_at
+from gophermart.order_status_history
+where order_number = $1
+order by observed_at, attempt;
\ No newline at end of file
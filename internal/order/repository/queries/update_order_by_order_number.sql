@@ -1,12 +1,18 @@
 update gophermart.order
 set
     accrual = $1,
+    raw_accrual = $6,
     status = $2,
     accrual_readiness =
         case
             when $2::gophermart.order_status in ('PROCESSED', 'INVALID') then false
             else true
         end,
+    accrual_lease_expires_at = null,
+    accrual_next_attempt_at = now() + make_interval(secs => $4),
     accrual_finished_at = now(),
     accrual_count = accrual_count + 1
-where number = $3;
\ No newline at end of file
+where number = $3
+    and status = $5
+    and status not in ('PROCESSED', 'INVALID')
+returning accrual_count;
\ No newline at end of file
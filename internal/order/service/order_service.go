@@ -17,6 +17,10 @@ import (
 type OrderRepository interface {
 	Insert(ctx context.Context, order model.Order) error
 	SelectAll(ctx context.Context, userLogin string) ([]model.Order, error)
+	SelectByNumber(ctx context.Context, orderNumber string, userLogin string) (*model.OrderDetails, error)
+	SelectLeased(ctx context.Context) ([]model.LeasedOrder, error)
+	SelectDeadLettered(ctx context.Context) ([]model.DeadLetteredOrder, error)
+	Requeue(ctx context.Context, orderNumber string) error
 }
 
 type OrderUseCase struct {
@@ -41,7 +45,7 @@ func (u *OrderUseCase) Upload(ctx context.Context, orderNumber string, userLogin
 		ID:        uuid.New().String(),
 		Number:    orderNumber,
 		UserLogin: userLogin,
-		Status:    "NEW",
+		Status:    model.StatusNew,
 	}
 
 	if err := u.repository.Insert(ctx, order); err != nil {
@@ -68,3 +72,58 @@ func (u *OrderUseCase) GetByUser(ctx context.Context, userLogin string) ([]dto.O
 
 	return orderResponse, nil
 }
+
+func (u *OrderUseCase) GetByNumber(ctx context.Context, orderNumber string, userLogin string) (*dto.OrderDetailsResponse, error) {
+	order, err := u.repository.SelectByNumber(ctx, orderNumber, userLogin)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	orderDetailsResponse := dto.MapToOrderDetailsResponse(*order)
+
+	return &orderDetailsResponse, nil
+}
+
+func (u *OrderUseCase) GetLeased(ctx context.Context) ([]dto.LeasedOrderResponse, error) {
+	orders, err := u.repository.SelectLeased(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	if len(orders) == 0 {
+		return nil, apperrors.ErrNoOrders
+	}
+
+	leasedOrderResponse := make([]dto.LeasedOrderResponse, 0, len(orders))
+	for _, v := range orders {
+		leasedOrderResponse = append(leasedOrderResponse, dto.MapToLeasedOrderResponse(v))
+	}
+
+	return leasedOrderResponse, nil
+}
+
+func (u *OrderUseCase) GetDeadLettered(ctx context.Context) ([]dto.DeadLetteredOrderResponse, error) {
+	orders, err := u.repository.SelectDeadLettered(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	if len(orders) == 0 {
+		return nil, apperrors.ErrNoOrders
+	}
+
+	deadLetteredOrderResponse := make([]dto.DeadLetteredOrderResponse, 0, len(orders))
+	for _, v := range orders {
+		deadLetteredOrderResponse = append(deadLetteredOrderResponse, dto.MapToDeadLetteredOrderResponse(v))
+	}
+
+	return deadLetteredOrderResponse, nil
+}
+
+func (u *OrderUseCase) Requeue(ctx context.Context, orderNumber string) error {
+	if err := u.repository.Requeue(ctx, orderNumber); err != nil {
+		return fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+
+	"github.com/msmkdenis/yap-gophermart/internal/promo/model"
+)
+
+type PromoCodeRequest struct {
+	Code           string          `json:"code" validate:"required,max=64"`
+	Amount         decimal.Decimal `json:"sum" validate:"required,positive_amount"`
+	MaxRedemptions int             `json:"max_redemptions" validate:"required,min=1"`
+	PerUserLimit   int             `json:"per_user_limit" validate:"omitempty,min=1"`
+	ValidFrom      time.Time       `json:"valid_from" validate:"required"`
+	ValidUntil     time.Time       `json:"valid_until" validate:"required,gtfield=ValidFrom"`
+}
+
+type PromoCodeResponse struct {
+	Code           string          `json:"code"`
+	Amount         decimal.Decimal `json:"sum"`
+	MaxRedemptions int             `json:"max_redemptions"`
+	PerUserLimit   int             `json:"per_user_limit"`
+	ValidFrom      string          `json:"valid_from"`
+	ValidUntil     string          `json:"valid_until"`
+	Redemptions    int             `json:"redemptions"`
+	CreatedAt      string          `json:"created_at"`
+}
+
+func MapToPromoCodeResponse(promo model.PromoCode) PromoCodeResponse {
+	return PromoCodeResponse{
+		Code:           promo.Code,
+		Amount:         promo.Amount,
+		MaxRedemptions: promo.MaxRedemptions,
+		PerUserLimit:   promo.PerUserLimit,
+		ValidFrom:      promo.ValidFrom.Format(time.RFC3339),
+		ValidUntil:     promo.ValidUntil.Format(time.RFC3339),
+		Redemptions:    promo.Redemptions,
+		CreatedAt:      promo.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+type RedeemPromoRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type RedemptionResponse struct {
+	Code       string          `json:"code"`
+	Amount     decimal.Decimal `json:"sum"`
+	RedeemedAt string          `json:"redeemed_at"`
+}
+
+func MapToRedemptionResponse(redemption model.Redemption) RedemptionResponse {
+	return RedemptionResponse{
+		Code:       redemption.Code,
+		Amount:     redemption.Amount,
+		RedeemedAt: redemption.RedeemedAt.Format(time.RFC3339),
+	}
+}
+
+func PositiveAmount(fl validator.FieldLevel) bool {
+	data, ok := fl.Field().Interface().(decimal.Decimal)
+	if !ok {
+		return false
+	}
+	return data.GreaterThan(decimal.Zero)
+}
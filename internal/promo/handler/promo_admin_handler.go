@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+	"github.com/msmkdenis/yap-gophermart/internal/promo/handler/dto"
+)
+
+// PromoAdminService mockgen --build_flags=--mod=mod -destination=internal/mocks/mock_promo_admin_service.go -package=mock github.com/msmkdenis/yap-gophermart/internal/promo/handler PromoAdminService
+type PromoAdminService interface {
+	Create(ctx context.Context, request dto.PromoCodeRequest) (*dto.PromoCodeResponse, error)
+	GetAll(ctx context.Context) ([]dto.PromoCodeResponse, error)
+}
+
+type PromoAdminHandler struct {
+	promoService PromoAdminService
+	logger       *zap.Logger
+	adminAuth    *middleware.AdminAuth
+}
+
+func NewPromoAdminHandler(e *echo.Echo, service PromoAdminService, logger *zap.Logger, adminAuth *middleware.AdminAuth) *PromoAdminHandler {
+	handler := &PromoAdminHandler{
+		promoService: service,
+		logger:       logger,
+		adminAuth:    adminAuth,
+	}
+
+	adminPromo := e.Group("/api/admin/promo", adminAuth.AdminAuth())
+	adminPromo.POST("", handler.Create)
+	adminPromo.GET("", handler.GetAll)
+
+	return handler
+}
+
+// @Summary       Create promo code
+// @Description   Create a promo code crediting the sum to the balance of a user who redeems it within the validity window. Codes are case-insensitive, per_user_limit defaults to 1.
+// @Tags          Admin API
+// @Accept        json
+// @Produce       json
+// @Param         promo   body       dto.PromoCodeRequest   true   "Promo code, sum, limits and validity window."
+// @Success       201     {object}   dto.PromoCodeResponse
+// @Failure       400
+// @Failure       401
+// @Failure       403
+// @Failure       409
+// @Failure       415
+// @Failure       500
+// @Security      AdminToken
+// @Router        /api/admin/promo [post]
+func (h *PromoAdminHandler) Create(c echo.Context) error {
+	header := c.Request().Header.Get("Content-Type")
+	if header != "application/json" {
+		msg := "Content-Type header is not application/json"
+		h.logger.Error("StatusUnsupportedMediaType: " + msg)
+		return c.String(http.StatusUnsupportedMediaType, msg)
+	}
+
+	request := new(dto.PromoCodeRequest)
+	if bindErr := c.Bind(request); bindErr != nil {
+		h.logger.Warn("Unable to bind data", zap.Error(bindErr))
+		return c.String(http.StatusBadRequest, "Bad request")
+	}
+
+	requestValidator := validator.New()
+	errRegisterValidator := requestValidator.RegisterValidation("positive_amount", dto.PositiveAmount)
+	if errRegisterValidator != nil {
+		h.logger.Warn("Unable to register validator", zap.Error(errRegisterValidator))
+	}
+
+	if validateErr := requestValidator.Struct(request); validateErr != nil {
+		h.logger.Warn("Bad Request: invalid request", zap.Error(validateErr))
+		return c.String(http.StatusBadRequest, "Invalid request data")
+	}
+
+	promo, err := h.promoService.Create(c.Request().Context(), *request)
+
+	if errors.Is(err, apperrors.ErrInvalidPromoCode) {
+		h.logger.Warn("Bad Request: invalid promo code", zap.Error(err))
+		return c.String(http.StatusBadRequest, "Invalid request data")
+	}
+
+	if errors.Is(err, apperrors.ErrPromoCodeAlreadyExists) {
+		h.logger.Warn("Promo code already exists", zap.Error(err))
+		return c.NoContent(http.StatusConflict)
+	}
+
+	if err != nil {
+		h.logger.Error("Unable to create promo code", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, promo)
+}
+
+// @Summary       Get promo codes
+// @Description   Get all promo codes with the number of their redemptions, newest first.
+// @Tags          Admin API
+// @Produce       json
+// @Success       200    {array}    dto.PromoCodeResponse
+// @Success       204
+// @Failure       401
+// @Failure       403
+// @Failure       500
+// @Security      AdminToken
+// @Router        /api/admin/promo [get]
+func (h *PromoAdminHandler) GetAll(c echo.Context) error {
+	promoCodes, err := h.promoService.GetAll(c.Request().Context())
+	if errors.Is(err, apperrors.ErrNoPromoCodes) {
+		h.logger.Info("No promo codes found", zap.Error(err))
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if err != nil {
+		h.logger.Error("Unable to get promo codes", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, promoCodes)
+}
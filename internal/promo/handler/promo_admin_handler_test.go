@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+	mock "github.com/msmkdenis/yap-gophermart/internal/mocks"
+	"github.com/msmkdenis/yap-gophermart/internal/promo/handler/dto"
+)
+
+const adminTokenMock = "supersecretadmintoken"
+
+type PromoAdminHandlersSuite struct {
+	suite.Suite
+	h            *PromoAdminHandler
+	promoService *mock.MockPromoAdminService
+	echo         *echo.Echo
+	ctrl         *gomock.Controller
+}
+
+func TestAdminSuite(t *testing.T) {
+	suite.Run(t, new(PromoAdminHandlersSuite))
+}
+
+func (p *PromoAdminHandlersSuite) SetupTest() {
+	logger, _ := zap.NewProduction()
+	adminAuth := middleware.InitAdminAuth(adminTokenMock, logger)
+	p.ctrl = gomock.NewController(p.T())
+	p.echo = echo.New()
+	p.promoService = mock.NewMockPromoAdminService(p.ctrl)
+	p.h = NewPromoAdminHandler(p.echo, p.promoService, logger, adminAuth)
+}
+
+func (p *PromoAdminHandlersSuite) TestCreate() {
+	validFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	validUntil := validFrom.AddDate(0, 1, 0)
+
+	promoRequest := dto.PromoCodeRequest{
+		Code:           "WELCOME",
+		Amount:         decimal.NewFromInt(50),
+		MaxRedemptions: 100,
+		ValidFrom:      validFrom,
+		ValidUntil:     validUntil,
+	}
+
+	validReq, errMarshal := json.Marshal(promoRequest)
+	require.NoError(p.T(), errMarshal)
+
+	negativeReq := promoRequest
+	negativeReq.Amount = decimal.NewFromInt(-50)
+	negativeAmountReq, errMarshal := json.Marshal(negativeReq)
+	require.NoError(p.T(), errMarshal)
+
+	reversedReq := promoRequest
+	reversedReq.ValidFrom, reversedReq.ValidUntil = validUntil, validFrom
+	reversedWindowReq, errMarshal := json.Marshal(reversedReq)
+	require.NoError(p.T(), errMarshal)
+
+	promoResponse := &dto.PromoCodeResponse{
+		Code:           "WELCOME",
+		Amount:         decimal.NewFromInt(50),
+		MaxRedemptions: 100,
+		PerUserLimit:   1,
+		ValidFrom:      validFrom.Format(time.RFC3339),
+		ValidUntil:     validUntil.Format(time.RFC3339),
+		CreatedAt:      validFrom.Format(time.RFC3339),
+	}
+
+	response, errMarshal := json.Marshal(promoResponse)
+	require.NoError(p.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		token        string
+		contentType  string
+		body         string
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name:        "Unauthorized - 401",
+			token:       "wrong_token",
+			contentType: "application/json",
+			body:        string(validReq),
+			prepare: func() {
+				p.promoService.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:        "UnsupportedMediaType - 415",
+			token:       adminTokenMock,
+			contentType: "text/plain",
+			body:        string(validReq),
+			prepare: func() {
+				p.promoService.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Negative amount - 400",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        string(negativeAmountReq),
+			prepare: func() {
+				p.promoService.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Reversed validity window - 400",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        string(reversedWindowReq),
+			prepare: func() {
+				p.promoService.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Invalid promo code - 400",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        string(validReq),
+			prepare: func() {
+				p.promoService.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrInvalidPromoCode)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Already exists - 409",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        string(validReq),
+			prepare: func() {
+				p.promoService.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrPromoCodeAlreadyExists)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:        "InternalServerError - 500",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        string(validReq),
+			prepare: func() {
+				p.promoService.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:        "Success - 201",
+			token:       adminTokenMock,
+			contentType: "application/json",
+			body:        string(validReq),
+			prepare: func() {
+				p.promoService.EXPECT().Create(gomock.Any(), promoRequest).Times(1).Return(promoResponse, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: response,
+		},
+	}
+
+	for _, test := range testCases {
+		p.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "http://localhost:8000/api/admin/promo", strings.NewReader(test.body))
+			request.Header.Set("X-Admin-Token", test.token)
+			request.Header.Set("Content-Type", test.contentType)
+
+			w := httptest.NewRecorder()
+			p.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			}
+		})
+	}
+}
+
+func (p *PromoAdminHandlersSuite) TestGetAll() {
+	promoCodes := []dto.PromoCodeResponse{
+		{
+			Code:           "WELCOME",
+			Amount:         decimal.NewFromInt(50),
+			MaxRedemptions: 100,
+			PerUserLimit:   1,
+			ValidFrom:      "2024-01-01T00:00:00Z",
+			ValidUntil:     "2024-02-01T00:00:00Z",
+			Redemptions:    12,
+			CreatedAt:      "2024-01-01T00:00:00Z",
+		},
+	}
+
+	response, errMarshal := json.Marshal(promoCodes)
+	require.NoError(p.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		token        string
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name:  "Unauthorized - 401",
+			token: "wrong_token",
+			prepare: func() {
+				p.promoService.EXPECT().GetAll(gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:  "Success - 200",
+			token: adminTokenMock,
+			prepare: func() {
+				p.promoService.EXPECT().GetAll(gomock.Any()).Times(1).Return(promoCodes, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+		{
+			name:  "No promo codes - 204",
+			token: adminTokenMock,
+			prepare: func() {
+				p.promoService.EXPECT().GetAll(gomock.Any()).Times(1).Return(nil, apperrors.ErrNoPromoCodes)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:  "InternalServerError - 500",
+			token: adminTokenMock,
+			prepare: func() {
+				p.promoService.EXPECT().GetAll(gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		p.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/admin/promo", nil)
+			request.Header.Set("X-Admin-Token", test.token)
+
+			w := httptest.NewRecorder()
+			p.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			} else {
+				assert.Equal(t, "", w.Body.String())
+			}
+		})
+	}
+}
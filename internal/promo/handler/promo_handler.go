@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+	"github.com/msmkdenis/yap-gophermart/internal/promo/handler/dto"
+)
+
+// PromoService mockgen --build_flags=--mod=mod -destination=internal/mocks/mock_promo_service.go -package=mock github.com/msmkdenis/yap-gophermart/internal/promo/handler PromoService
+type PromoService interface {
+	Redeem(ctx context.Context, userLogin string, code string) (*dto.RedemptionResponse, error)
+}
+
+type PromoHandler struct {
+	promoService PromoService
+	logger       *zap.Logger
+	jwtAuth      *middleware.JWTAuth
+}
+
+func NewPromoHandler(e *echo.Echo, service PromoService, logger *zap.Logger, jwtAuth *middleware.JWTAuth) *PromoHandler {
+	handler := &PromoHandler{
+		promoService: service,
+		logger:       logger,
+		jwtAuth:      jwtAuth,
+	}
+
+	protectedPromo := e.Group("/api/user", jwtAuth.JWTAuth())
+	protectedPromo.POST("/promo", handler.Redeem)
+
+	return handler
+}
+
+// @Summary       Redeem promo code
+// @Description   Credit the points of the promo code to the user's loyalty points account. The redemption is posted to the ledger with the reason "promo".
+// @Tags          Promo API
+// @Accept        json
+// @Produce       json
+// @Param         promo   body       dto.RedeemPromoRequest   true   "Promo code to redeem."
+// @Success       200     {object}   dto.RedemptionResponse
+// @Failure       400
+// @Failure       401
+// @Failure       404
+// @Failure       409
+// @Failure       410
+// @Failure       415
+// @Failure       422
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/promo [post]
+func (h *PromoHandler) Redeem(c echo.Context) error {
+	userLogin, ok := c.Get("userLogin").(string)
+	if !ok {
+		h.logger.Error("Internal server error", zap.Error(apperrors.ErrUnableToGetUserLoginFromContext))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	header := c.Request().Header.Get("Content-Type")
+	if header != "application/json" {
+		msg := "Content-Type header is not application/json"
+		h.logger.Error("StatusUnsupportedMediaType: " + msg)
+		return c.String(http.StatusUnsupportedMediaType, msg)
+	}
+
+	request := new(dto.RedeemPromoRequest)
+	if bindErr := c.Bind(request); bindErr != nil {
+		h.logger.Warn("Unable to bind data", zap.Error(bindErr))
+		return c.String(http.StatusBadRequest, "Bad request")
+	}
+
+	if validateErr := validator.New().Struct(request); validateErr != nil {
+		h.logger.Warn("Bad Request: invalid request", zap.Error(validateErr))
+		return c.String(http.StatusBadRequest, "Invalid request data")
+	}
+
+	redemption, err := h.promoService.Redeem(c.Request().Context(), userLogin, request.Code)
+
+	if errors.Is(err, apperrors.ErrPromoCodeNotFound) {
+		h.logger.Info("Promo code not found", zap.Error(err))
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	if errors.Is(err, apperrors.ErrPromoCodeAlreadyRedeemed) {
+		h.logger.Warn("Promo code already redeemed", zap.Error(err))
+		return c.NoContent(http.StatusConflict)
+	}
+
+	if errors.Is(err, apperrors.ErrPromoCodeExhausted) {
+		h.logger.Warn("Promo code exhausted", zap.Error(err))
+		return c.NoContent(http.StatusGone)
+	}
+
+	if errors.Is(err, apperrors.ErrPromoCodeNotActive) {
+		h.logger.Warn("Unprocessable entity: promo code is not active", zap.Error(err))
+		return c.NoContent(http.StatusUnprocessableEntity)
+	}
+
+	if err != nil {
+		h.logger.Error("Internal server error", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, redemption)
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/config"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+	mock "github.com/msmkdenis/yap-gophermart/internal/mocks"
+	"github.com/msmkdenis/yap-gophermart/internal/promo/handler/dto"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+var cfgMock = &config.Config{
+	Address:              "localhost:8000",
+	DatabaseURI:          "user=postgres password=postgres host=localhost database=yap-gophermart sslmode=disable",
+	AccrualSystemAddress: "http://localhost:8080",
+	Secret:               "supersecretkey",
+	TokenName:            "token",
+}
+
+type PromoHandlersSuite struct {
+	suite.Suite
+	h            *PromoHandler
+	promoService *mock.MockPromoService
+	echo         *echo.Echo
+	ctrl         *gomock.Controller
+	jwtManager   *utils.JWTManager
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(PromoHandlersSuite))
+}
+
+func (p *PromoHandlersSuite) SetupTest() {
+	logger, _ := zap.NewProduction()
+	jwtManager := utils.InitJWTManager(cfgMock.TokenName, cfgMock.Secret, logger)
+	jwtAuth := middleware.InitJWTAuth(jwtManager, logger)
+	p.jwtManager = jwtManager
+	p.ctrl = gomock.NewController(p.T())
+	p.echo = echo.New()
+	p.promoService = mock.NewMockPromoService(p.ctrl)
+	p.h = NewPromoHandler(p.echo, p.promoService, logger, jwtAuth)
+}
+
+func (p *PromoHandlersSuite) TestRedeem() {
+	login := "awesome_login"
+
+	cookie, errCookie := p.createCookie(login)
+	require.NoError(p.T(), errCookie)
+
+	validReq, errMarshal := json.Marshal(dto.RedeemPromoRequest{Code: "WELCOME"})
+	require.NoError(p.T(), errMarshal)
+
+	noCodeReq, errMarshal := json.Marshal(dto.RedeemPromoRequest{})
+	require.NoError(p.T(), errMarshal)
+
+	redemptionResponse := &dto.RedemptionResponse{
+		Code:       "WELCOME",
+		Amount:     decimal.NewFromInt(50),
+		RedeemedAt: time.Now().Format(time.RFC3339),
+	}
+
+	response, errMarshal := json.Marshal(redemptionResponse)
+	require.NoError(p.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		header       http.Header
+		cookie       *http.Cookie
+		prepare      func()
+		expectedCode int
+		body         string
+		expectedBody []byte
+	}{
+		{
+			name: "Unauthorized - 401",
+			prepare: func() {
+				p.promoService.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+			body:         string(validReq),
+		},
+		{
+			name:   "UnsupportedMediaType - 415",
+			header: map[string][]string{"Content-Type": {""}},
+			cookie: cookie,
+			prepare: func() {
+				p.promoService.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusUnsupportedMediaType,
+			body:         string(validReq),
+		},
+		{
+			name:   "Bad Request without code - 400",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				p.promoService.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusBadRequest,
+			body:         string(noCodeReq),
+		},
+		{
+			name:   "Promo code not found - 404",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				p.promoService.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrPromoCodeNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			body:         string(validReq),
+		},
+		{
+			name:   "Already redeemed - 409",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				p.promoService.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrPromoCodeAlreadyRedeemed)
+			},
+			expectedCode: http.StatusConflict,
+			body:         string(validReq),
+		},
+		{
+			name:   "Exhausted - 410",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				p.promoService.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrPromoCodeExhausted)
+			},
+			expectedCode: http.StatusGone,
+			body:         string(validReq),
+		},
+		{
+			name:   "Not active - 422",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				p.promoService.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, apperrors.ErrPromoCodeNotActive)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+			body:         string(validReq),
+		},
+		{
+			name:   "InternalServerError - 500",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				p.promoService.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			body:         string(validReq),
+		},
+		{
+			name:   "Success - 200",
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			cookie: cookie,
+			prepare: func() {
+				p.promoService.EXPECT().Redeem(gomock.Any(), login, "WELCOME").Times(1).Return(redemptionResponse, nil)
+			},
+			expectedCode: http.StatusOK,
+			body:         string(validReq),
+			expectedBody: response,
+		},
+	}
+
+	for _, test := range testCases {
+		p.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "http://localhost:8000/api/user/promo", strings.NewReader(test.body))
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+			request.Header.Set("Content-Type", test.header.Get("Content-Type"))
+			w := httptest.NewRecorder()
+			p.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			}
+		})
+	}
+}
+
+func (p *PromoHandlersSuite) createCookie(login string) (*http.Cookie, error) {
+	token, err := p.jwtManager.BuildJWTString(login)
+
+	cookie := &http.Cookie{
+		Name:  p.jwtManager.TokenName,
+		Value: token,
+	}
+
+	return cookie, err
+}
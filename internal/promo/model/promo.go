@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PromoCode credits Amount points to the balance of a user who redeems it within [ValidFrom, ValidUntil).
+// The code is redeemed at most MaxRedemptions times in total and at most PerUserLimit times by one user.
+type PromoCode struct {
+	ID             string          `db:"id"`
+	Code           string          `db:"code"`
+	Amount         decimal.Decimal `db:"amount"`
+	MaxRedemptions int             `db:"max_redemptions"`
+	PerUserLimit   int             `db:"per_user_limit"`
+	ValidFrom      time.Time       `db:"valid_from"`
+	ValidUntil     time.Time       `db:"valid_until"`
+	Redemptions    int             `db:"redemptions"`
+	CreatedAt      time.Time       `db:"created_at"`
+}
+
+// Active reports whether the code may be redeemed at now.
+func (p PromoCode) Active(now time.Time) bool {
+	return !now.Before(p.ValidFrom) && now.Before(p.ValidUntil)
+}
+
+// Exhausted reports whether the code was redeemed MaxRedemptions times.
+func (p PromoCode) Exhausted() bool {
+	return p.Redemptions >= p.MaxRedemptions
+}
+
+type Redemption struct {
+	ID          string
+	PromoCodeID string
+	Code        string
+	UserLogin   string
+	Amount      decimal.Decimal
+	RedeemedAt  time.Time
+}
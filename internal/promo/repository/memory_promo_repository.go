@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	"github.com/msmkdenis/yap-gophermart/internal/promo/model"
+)
+
+type MemoryPromoRepository struct {
+	storage     *db.MemoryStorage
+	promoCodes  *db.MemoryTable[string, model.PromoCode]
+	redemptions *db.MemoryTable[string, model.Redemption]
+	logger      *zap.Logger
+	now         func() time.Time
+}
+
+func NewMemoryPromoRepository(storage *db.MemoryStorage, logger *zap.Logger) *MemoryPromoRepository {
+	return &MemoryPromoRepository{
+		storage:     storage,
+		promoCodes:  db.Table[string, model.PromoCode](storage, "promo_code"),
+		redemptions: db.Table[string, model.Redemption](storage, "promo_redemption"),
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+func (r *MemoryPromoRepository) Insert(ctx context.Context, promo *model.PromoCode) error {
+	defer r.storage.Lock(ctx)()
+
+	if _, ok := r.promoCodes.Get(promo.Code); ok {
+		return apperrors.ErrPromoCodeAlreadyExists
+	}
+
+	promo.CreatedAt = r.now()
+	r.promoCodes.Put(promo.Code, *promo)
+
+	return nil
+}
+
+func (r *MemoryPromoRepository) SelectAll(ctx context.Context) ([]model.PromoCode, error) {
+	defer r.storage.Lock(ctx)()
+
+	promoCodes := r.promoCodes.Select(func(model.PromoCode) bool { return true })
+	sort.Slice(promoCodes, func(i, j int) bool {
+		return promoCodes[i].CreatedAt.After(promoCodes[j].CreatedAt)
+	})
+
+	if len(promoCodes) == 0 {
+		return nil, apperrors.ErrNoPromoCodes
+	}
+
+	return promoCodes, nil
+}
+
+// BlockByCode returns the promo code, its redemptions are serialized by the transaction holding the storage lock.
+func (r *MemoryPromoRepository) BlockByCode(ctx context.Context, code string) (*model.PromoCode, error) {
+	defer r.storage.Lock(ctx)()
+
+	promo, ok := r.promoCodes.Get(code)
+	if !ok {
+		return nil, apperrors.ErrPromoCodeNotFound
+	}
+
+	return &promo, nil
+}
+
+func (r *MemoryPromoRepository) CountRedemptionsByUser(ctx context.Context, promoCodeID string, userLogin string) (int, error) {
+	defer r.storage.Lock(ctx)()
+
+	redemptions := r.redemptions.Select(func(redemption model.Redemption) bool {
+		return redemption.PromoCodeID == promoCodeID && redemption.UserLogin == userLogin
+	})
+
+	return len(redemptions), nil
+}
+
+// InsertRedemption records the redemption and counts it to the redemptions of the promo code.
+func (r *MemoryPromoRepository) InsertRedemption(ctx context.Context, redemption *model.Redemption) error {
+	defer r.storage.Lock(ctx)()
+
+	promo, ok := r.promoCodes.Get(redemption.Code)
+	if !ok {
+		return apperrors.ErrPromoCodeNotFound
+	}
+
+	if promo.Exhausted() {
+		return apperrors.ErrPromoCodeExhausted
+	}
+
+	promo.Redemptions++
+	r.promoCodes.Put(promo.Code, promo)
+
+	redemption.RedeemedAt = r.now()
+	r.redemptions.Put(redemption.ID, *redemption)
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+
+	trmpgx "github.com/avito-tech/go-transaction-manager/pgxv5"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	"github.com/msmkdenis/yap-gophermart/internal/promo/model"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+//go:embed queries/insert_promo_code.sql
+var insertPromoCode string
+
+//go:embed queries/select_all_promo_codes.sql
+var selectAllPromoCodes string
+
+//go:embed queries/block_promo_code.sql
+var blockPromoCode string
+
+//go:embed queries/count_redemptions_by_user.sql
+var countRedemptionsByUser string
+
+//go:embed queries/insert_promo_redemption.sql
+var insertPromoRedemption string
+
+//go:embed queries/increment_promo_code_redemptions.sql
+var incrementPromoCodeRedemptions string
+
+type PostgresPromoRepository struct {
+	postgresPool *db.PostgresPool
+	logger       *zap.Logger
+	getter       *trmpgx.CtxGetter
+}
+
+func NewPostgresPromoRepository(postgresPool *db.PostgresPool, logger *zap.Logger) *PostgresPromoRepository {
+	return &PostgresPromoRepository{
+		postgresPool: postgresPool,
+		logger:       logger,
+		getter:       trmpgx.DefaultCtxGetter,
+	}
+}
+
+func (r *PostgresPromoRepository) Insert(ctx context.Context, promo *model.PromoCode) error {
+	err := r.postgresPool.DB.QueryRow(ctx, insertPromoCode,
+		promo.ID, promo.Code, promo.Amount, promo.MaxRedemptions, promo.PerUserLimit, promo.ValidFrom, promo.ValidUntil,
+	).Scan(&promo.CreatedAt)
+
+	var e *pgconn.PgError
+	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
+		return apperrors.ErrPromoCodeAlreadyExists
+	}
+
+	if err != nil {
+		return apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+func (r *PostgresPromoRepository) SelectAll(ctx context.Context) ([]model.PromoCode, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectAllPromoCodes)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	promoCodes, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.PromoCode])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	if len(promoCodes) == 0 {
+		return nil, apperrors.ErrNoPromoCodes
+	}
+
+	return promoCodes, nil
+}
+
+// BlockByCode locks the promo code until the end of the transaction, so that its redemptions are serialized.
+// Must be called within a transaction.
+func (r *PostgresPromoRepository) BlockByCode(ctx context.Context, code string) (*model.PromoCode, error) {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	queryRows, err := conn.Query(ctx, blockPromoCode, code)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	promo, err := pgx.CollectOneRow(queryRows, pgx.RowToStructByPos[model.PromoCode])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrPromoCodeNotFound
+	}
+
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect row", utils.Caller(), err)
+	}
+
+	return &promo, nil
+}
+
+func (r *PostgresPromoRepository) CountRedemptionsByUser(ctx context.Context, promoCodeID string, userLogin string) (int, error) {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	var redemptions int
+	err := conn.QueryRow(ctx, countRedemptionsByUser, promoCodeID, userLogin).Scan(&redemptions)
+	if err != nil {
+		return 0, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	return redemptions, nil
+}
+
+// InsertRedemption records the redemption and counts it to the redemptions of the promo code.
+// Must be called within a transaction.
+func (r *PostgresPromoRepository) InsertRedemption(ctx context.Context, redemption *model.Redemption) error {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	batch := &pgx.Batch{}
+	batch.Queue(insertPromoRedemption, redemption.ID, redemption.PromoCodeID, redemption.UserLogin, redemption.Amount).QueryRow(func(row pgx.Row) error {
+		return row.Scan(&redemption.RedeemedAt)
+	})
+	batch.Queue(incrementPromoCodeRedemptions, redemption.PromoCodeID)
+
+	err := conn.SendBatch(ctx, batch).Close()
+
+	var e *pgconn.PgError
+	if errors.As(err, &e) && e.Code == pgerrcode.CheckViolation && e.ConstraintName == "redemptions_within_limit" {
+		return apperrors.ErrPromoCodeExhausted
+	}
+
+	if err != nil {
+		return apperrors.NewValueError("close failed", utils.Caller(), err)
+	}
+
+	return nil
+}
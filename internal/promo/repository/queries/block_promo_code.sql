@@ -0,0 +1,13 @@
+select
+    id,
+    code,
+    amount,
+    max_redemptions,
+    per_user_limit,
+    valid_from,
+    valid_until,
+    redemptions,
+    created_at
+from gophermart.promo_code
+where code = $1
+for update;
\ No newline at end of file
@@ -0,0 +1,3 @@
+select count(*)
+from gophermart.promo_redemption
+where promo_code_id = $1 and user_login = $2;
\ No newline at end of file
@@ -0,0 +1,3 @@
+update gophermart.promo_code
+set redemptions = redemptions + 1
+where id = $1;
\ No newline at end of file
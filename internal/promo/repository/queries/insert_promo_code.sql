@@ -0,0 +1,4 @@
+insert into gophermart.promo_code
+    (id, code, amount, max_redemptions, per_user_limit, valid_from, valid_until)
+values ($1, $2, $3, $4, $5, $6, $7)
+returning created_at;
\ No newline at end of file
@@ -0,0 +1,4 @@
+insert into gophermart.promo_redemption
+    (id, promo_code_id, user_login, amount)
+values ($1, $2, $3, $4)
+returning redeemed_at;
\ No newline at end of file
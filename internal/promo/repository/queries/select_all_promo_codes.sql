@@ -0,0 +1,12 @@
+select
+    id,
+    code,
+    amount,
+    max_redemptions,
+    per_user_limit,
+    valid_from,
+    valid_until,
+    redemptions,
+    created_at
+from gophermart.promo_code
+order by created_at desc;
\ No newline at end of file
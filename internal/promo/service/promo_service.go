@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/avito-tech/go-transaction-manager/trm/manager"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	balanceModel "github.com/msmkdenis/yap-gophermart/internal/balance/model"
+	"github.com/msmkdenis/yap-gophermart/internal/promo/handler/dto"
+	"github.com/msmkdenis/yap-gophermart/internal/promo/model"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+type PromoRepository interface {
+	Insert(ctx context.Context, promo *model.PromoCode) error
+	SelectAll(ctx context.Context) ([]model.PromoCode, error)
+	BlockByCode(ctx context.Context, code string) (*model.PromoCode, error)
+	CountRedemptionsByUser(ctx context.Context, promoCodeID string, userLogin string) (int, error)
+	InsertRedemption(ctx context.Context, redemption *model.Redemption) error
+}
+
+type BalanceRepository interface {
+	UpdateBalance(ctx context.Context, userLogin string, amount decimal.Decimal, reason string, reference string) error
+}
+
+type PromoUseCase struct {
+	repository        PromoRepository
+	balanceRepository BalanceRepository
+	trManager         *manager.Manager
+	logger            *zap.Logger
+	now               func() time.Time
+}
+
+func NewPromoService(repository PromoRepository, balanceRepository BalanceRepository, trManager *manager.Manager, logger *zap.Logger) *PromoUseCase {
+	return &PromoUseCase{
+		repository:        repository,
+		balanceRepository: balanceRepository,
+		trManager:         trManager,
+		logger:            logger,
+		now:               time.Now,
+	}
+}
+
+func (u *PromoUseCase) Create(ctx context.Context, request dto.PromoCodeRequest) (*dto.PromoCodeResponse, error) {
+	promo := model.PromoCode{
+		ID:             uuid.New().String(),
+		Code:           normalizeCode(request.Code),
+		Amount:         request.Amount,
+		MaxRedemptions: request.MaxRedemptions,
+		PerUserLimit:   request.PerUserLimit,
+		ValidFrom:      request.ValidFrom.UTC(),
+		ValidUntil:     request.ValidUntil.UTC(),
+	}
+	if promo.PerUserLimit == 0 {
+		promo.PerUserLimit = 1
+	}
+
+	if promo.Code == "" || !promo.Amount.IsPositive() || promo.MaxRedemptions < 1 || promo.PerUserLimit < 1 ||
+		!promo.ValidFrom.Before(promo.ValidUntil) {
+		return nil, apperrors.ErrInvalidPromoCode
+	}
+
+	if err := u.repository.Insert(ctx, &promo); err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	u.logger.Info("Promo code created", zap.String("code", promo.Code), zap.String("sum", promo.Amount.String()))
+
+	promoResponse := dto.MapToPromoCodeResponse(promo)
+
+	return &promoResponse, nil
+}
+
+func (u *PromoUseCase) GetAll(ctx context.Context) ([]dto.PromoCodeResponse, error) {
+	promoCodes, err := u.repository.SelectAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	promoResponses := make([]dto.PromoCodeResponse, 0, len(promoCodes))
+	for _, v := range promoCodes {
+		promoResponses = append(promoResponses, dto.MapToPromoCodeResponse(v))
+	}
+
+	return promoResponses, nil
+}
+
+// Redeem credits the promo code amount to the user balance. The promo code is blocked for the transaction,
+// so concurrent redemptions can't exceed its limits.
+func (u *PromoUseCase) Redeem(ctx context.Context, userLogin string, code string) (*dto.RedemptionResponse, error) {
+	redemption := model.Redemption{
+		ID:        uuid.New().String(),
+		Code:      normalizeCode(code),
+		UserLogin: userLogin,
+	}
+
+	err := u.trManager.Do(ctx, func(ctx context.Context) error {
+		promo, err := u.repository.BlockByCode(ctx, redemption.Code)
+		if err != nil {
+			return err
+		}
+
+		if !promo.Active(u.now()) {
+			return apperrors.ErrPromoCodeNotActive
+		}
+
+		redeemed, err := u.repository.CountRedemptionsByUser(ctx, promo.ID, userLogin)
+		if err != nil {
+			return err
+		}
+
+		if redeemed >= promo.PerUserLimit {
+			return apperrors.ErrPromoCodeAlreadyRedeemed
+		}
+
+		if promo.Exhausted() {
+			return apperrors.ErrPromoCodeExhausted
+		}
+
+		redemption.PromoCodeID = promo.ID
+		redemption.Amount = promo.Amount
+		if err = u.repository.InsertRedemption(ctx, &redemption); err != nil {
+			return err
+		}
+
+		return u.balanceRepository.UpdateBalance(ctx, userLogin, redemption.Amount, balanceModel.ReasonPromo, redemption.Code)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	u.logger.Info("Promo code redeemed", zap.String("code", redemption.Code), zap.String("user", userLogin))
+
+	redemptionResponse := dto.MapToRedemptionResponse(redemption)
+
+	return &redemptionResponse, nil
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
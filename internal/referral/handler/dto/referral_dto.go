@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/msmkdenis/yap-gophermart/internal/referral/model"
+)
+
+type ReferralsResponse struct {
+	ReferralCode string             `json:"referral_code"`
+	Referrals    []ReferralResponse `json:"referrals"`
+}
+
+type ReferralResponse struct {
+	Login        string          `json:"login"`
+	Status       string          `json:"status"`
+	Bonus        decimal.Decimal `json:"bonus"`
+	RegisteredAt string          `json:"registered_at"`
+	CreditedAt   string          `json:"credited_at,omitempty"`
+}
+
+func MapToReferralResponse(referral model.Referral) ReferralResponse {
+	response := ReferralResponse{
+		Login:        referral.RefereeLogin,
+		Status:       referral.Status,
+		Bonus:        referral.Bonus,
+		RegisteredAt: referral.RegisteredAt.Format(time.RFC3339),
+	}
+	if referral.CreditedAt != nil {
+		response.CreditedAt = referral.CreditedAt.Format(time.RFC3339)
+	}
+	return response
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+	"github.com/msmkdenis/yap-gophermart/internal/referral/handler/dto"
+)
+
+// ReferralService mockgen --build_flags=--mod=mod -destination=internal/mocks/mock_referral_service.go -package=mock github.com/msmkdenis/yap-gophermart/internal/referral/handler ReferralService
+type ReferralService interface {
+	GetByUser(ctx context.Context, userLogin string) (*dto.ReferralsResponse, error)
+}
+
+type ReferralHandler struct {
+	referralService ReferralService
+	logger          *zap.Logger
+	jwtAuth         *middleware.JWTAuth
+}
+
+func NewReferralHandler(e *echo.Echo, service ReferralService, logger *zap.Logger, jwtAuth *middleware.JWTAuth) *ReferralHandler {
+	handler := &ReferralHandler{
+		referralService: service,
+		logger:          logger,
+		jwtAuth:         jwtAuth,
+	}
+
+	protectedReferral := e.Group("/api/user", jwtAuth.JWTAuth())
+	protectedReferral.GET("/referrals", handler.GetReferrals)
+
+	return handler
+}
+
+// @Summary       Get user referrals
+// @Description   Get the personal referral code of the user and the users registered with it, oldest first. A referral is PENDING until the first order of the referee is processed, then both users are credited the bonus and it becomes CREDITED.
+// @Tags          Referral API
+// @Produce       json
+// @Success       200    {object}   dto.ReferralsResponse
+// @Failure       401
+// @Failure       500
+// @Security      JWT
+// @Router        /api/user/referrals [get]
+func (h *ReferralHandler) GetReferrals(c echo.Context) error {
+	userLogin, ok := c.Get("userLogin").(string)
+	if !ok {
+		h.logger.Error("Internal server error", zap.Error(apperrors.ErrUnableToGetUserLoginFromContext))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	referrals, err := h.referralService.GetByUser(c.Request().Context(), userLogin)
+	if err != nil {
+		h.logger.Error("Internal server error: unable to get referrals", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, referrals)
+}
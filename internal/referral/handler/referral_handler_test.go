@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/config"
+	"github.com/msmkdenis/yap-gophermart/internal/middleware"
+	mock "github.com/msmkdenis/yap-gophermart/internal/mocks"
+	"github.com/msmkdenis/yap-gophermart/internal/referral/handler/dto"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+var cfgMock = &config.Config{
+	Address:              "localhost:8000",
+	DatabaseURI:          "user=postgres password=postgres host=localhost database=yap-gophermart sslmode=disable",
+	AccrualSystemAddress: "http://localhost:8080",
+	Secret:               "supersecretkey",
+	TokenName:            "token",
+}
+
+type ReferralHandlersSuite struct {
+	suite.Suite
+	h               *ReferralHandler
+	referralService *mock.MockReferralService
+	echo            *echo.Echo
+	ctrl            *gomock.Controller
+	jwtManager      *utils.JWTManager
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(ReferralHandlersSuite))
+}
+
+func (r *ReferralHandlersSuite) SetupTest() {
+	logger, _ := zap.NewProduction()
+	jwtManager := utils.InitJWTManager(cfgMock.TokenName, cfgMock.Secret, logger)
+	jwtAuth := middleware.InitJWTAuth(jwtManager, logger)
+	r.jwtManager = jwtManager
+	r.ctrl = gomock.NewController(r.T())
+	r.echo = echo.New()
+	r.referralService = mock.NewMockReferralService(r.ctrl)
+	r.h = NewReferralHandler(r.echo, r.referralService, logger, jwtAuth)
+}
+
+func (r *ReferralHandlersSuite) TestGetReferrals() {
+	login := "awesome_login"
+
+	cookie, errCookie := r.createCookie(login)
+	require.NoError(r.T(), errCookie)
+
+	referralsResponse := &dto.ReferralsResponse{
+		ReferralCode: "AWESOMECODE1",
+		Referrals: []dto.ReferralResponse{
+			{
+				Login:        "referred_login",
+				Status:       "CREDITED",
+				Bonus:        decimal.NewFromInt(100),
+				RegisteredAt: time.Now().Add(-time.Hour).Format(time.RFC3339),
+				CreditedAt:   time.Now().Format(time.RFC3339),
+			},
+			{
+				Login:        "another_referred_login",
+				Status:       "PENDING",
+				Bonus:        decimal.Zero,
+				RegisteredAt: time.Now().Format(time.RFC3339),
+			},
+		},
+	}
+
+	response, errMarshal := json.Marshal(referralsResponse)
+	require.NoError(r.T(), errMarshal)
+
+	testCases := []struct {
+		name         string
+		cookie       *http.Cookie
+		prepare      func()
+		expectedCode int
+		expectedBody []byte
+	}{
+		{
+			name: "Unauthorized - 401",
+			prepare: func() {
+				r.referralService.EXPECT().GetByUser(gomock.Any(), login).Times(0)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "Success - 200",
+			cookie: cookie,
+			prepare: func() {
+				r.referralService.EXPECT().GetByUser(gomock.Any(), login).Times(1).Return(referralsResponse, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: response,
+		},
+		{
+			name:   "InternalServerError - 500",
+			cookie: cookie,
+			prepare: func() {
+				r.referralService.EXPECT().GetByUser(gomock.Any(), login).Times(1).Return(nil, errors.New("some error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		r.T().Run(test.name, func(t *testing.T) {
+			if test.prepare != nil {
+				test.prepare()
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/user/referrals", nil)
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+			w := httptest.NewRecorder()
+			r.echo.ServeHTTP(w, request)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedBody != nil {
+				assert.JSONEq(t, string(test.expectedBody), w.Body.String())
+			}
+		})
+	}
+}
+
+func (r *ReferralHandlersSuite) createCookie(login string) (*http.Cookie, error) {
+	token, err := r.jwtManager.BuildJWTString(login)
+
+	cookie := &http.Cookie{
+		Name:  r.jwtManager.TokenName,
+		Value: token,
+	}
+
+	return cookie, err
+}
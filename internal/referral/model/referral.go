@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Referral statuses: the bonus is credited to both the referrer and the referee
+// once the first order of the referee is processed.
+const (
+	StatusPending  = "PENDING"
+	StatusCredited = "CREDITED"
+)
+
+type Referral struct {
+	RefereeLogin  string          `db:"referee_login"`
+	ReferrerLogin string          `db:"referrer_login"`
+	Status        string          `db:"status"`
+	Bonus         decimal.Decimal `db:"bonus"`
+	RegisteredAt  time.Time       `db:"registered_at"`
+	CreditedAt    *time.Time      `db:"credited_at"`
+}
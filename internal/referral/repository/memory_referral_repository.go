@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	"github.com/msmkdenis/yap-gophermart/internal/referral/model"
+	userModel "github.com/msmkdenis/yap-gophermart/internal/user/model"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+type MemoryReferralRepository struct {
+	storage   *db.MemoryStorage
+	users     *db.MemoryTable[string, userModel.User]
+	referrals *db.MemoryTable[string, model.Referral]
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewMemoryReferralRepository(storage *db.MemoryStorage, logger *zap.Logger) *MemoryReferralRepository {
+	return &MemoryReferralRepository{
+		storage:   storage,
+		users:     db.Table[string, userModel.User](storage, "user"),
+		referrals: db.Table[string, model.Referral](storage, "referral"),
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// BlockReferrer returns the login of the user owning the referral code, the referrals of one referrer
+// are serialized by the transaction holding the storage lock.
+func (r *MemoryReferralRepository) BlockReferrer(ctx context.Context, referralCode string) (string, error) {
+	defer r.storage.Lock(ctx)()
+
+	users := r.users.Select(func(user userModel.User) bool {
+		return user.ReferralCode == referralCode
+	})
+	if len(users) == 0 {
+		return "", apperrors.ErrReferralCodeNotFound
+	}
+
+	return users[0].Login, nil
+}
+
+func (r *MemoryReferralRepository) CountByReferrer(ctx context.Context, referrerLogin string) (int, error) {
+	defer r.storage.Lock(ctx)()
+
+	referrals := r.referrals.Select(func(referral model.Referral) bool {
+		return referral.ReferrerLogin == referrerLogin
+	})
+
+	return len(referrals), nil
+}
+
+func (r *MemoryReferralRepository) Insert(ctx context.Context, referral model.Referral) error {
+	defer r.storage.Lock(ctx)()
+
+	if referral.RefereeLogin == referral.ReferrerLogin {
+		return apperrors.ErrSelfReferral
+	}
+
+	referral.Status = model.StatusPending
+	referral.Bonus = decimal.Zero
+	referral.RegisteredAt = r.now()
+	r.referrals.Put(referral.RefereeLogin, referral)
+
+	return nil
+}
+
+func (r *MemoryReferralRepository) SelectByReferrer(ctx context.Context, referrerLogin string) ([]model.Referral, error) {
+	defer r.storage.Lock(ctx)()
+
+	referrals := r.referrals.Select(func(referral model.Referral) bool {
+		return referral.ReferrerLogin == referrerLogin
+	})
+	sort.Slice(referrals, func(i, j int) bool {
+		return referrals[i].RegisteredAt.Before(referrals[j].RegisteredAt)
+	})
+
+	return referrals, nil
+}
+
+func (r *MemoryReferralRepository) SelectReferralCode(ctx context.Context, userLogin string) (string, error) {
+	defer r.storage.Lock(ctx)()
+
+	user, ok := r.users.Get(userLogin)
+	if !ok {
+		return "", apperrors.NewValueError("user not found", utils.Caller(), apperrors.ErrUserNotFound)
+	}
+
+	return user.ReferralCode, nil
+}
+
+// Credit marks the pending referral of the referee as credited with the bonus and returns the login of the referrer.
+func (r *MemoryReferralRepository) Credit(ctx context.Context, refereeLogin string, bonus decimal.Decimal) (string, error) {
+	defer r.storage.Lock(ctx)()
+
+	referral, ok := r.referrals.Get(refereeLogin)
+	if !ok || referral.Status != model.StatusPending {
+		return "", apperrors.ErrNoPendingReferral
+	}
+
+	creditedAt := r.now()
+	referral.Status = model.StatusCredited
+	referral.Bonus = bonus
+	referral.CreditedAt = &creditedAt
+	r.referrals.Put(refereeLogin, referral)
+
+	return referral.ReferrerLogin, nil
+}
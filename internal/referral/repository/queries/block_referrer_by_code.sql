@@ -0,0 +1,4 @@
+select login
+from gophermart.user
+where referral_code = $1
+for update;
\ No newline at end of file
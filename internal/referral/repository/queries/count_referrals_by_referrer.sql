@@ -0,0 +1,3 @@
+select count(*)
+from gophermart.referral
+where referrer_login = $1;
\ No newline at end of file
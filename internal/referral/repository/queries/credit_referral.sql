@@ -0,0 +1,4 @@
+update gophermart.referral
+set status = 'CREDITED', bonus = $2, credited_at = now()
+where referee_login = $1 and status = 'PENDING'
+returning referrer_login;
\ No newline at end of file
@@ -0,0 +1,3 @@
+insert into gophermart.referral
+    (referee_login, referrer_login)
+values ($1, $2);
\ No newline at end of file
@@ -0,0 +1,3 @@
+select referral_code
+from gophermart.user
+where login = $1;
\ No newline at end of file
@@ -0,0 +1,4 @@
+select referee_login, referrer_login, status, coalesce(bonus, 0), registered_at, credited_at
+from gophermart.referral
+where referrer_login = $1
+order by registered_at;
\ No newline at end of file
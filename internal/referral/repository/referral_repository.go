@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+
+	trmpgx "github.com/avito-tech/go-transaction-manager/pgxv5"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	"github.com/msmkdenis/yap-gophermart/internal/referral/model"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+//go:embed queries/block_referrer_by_code.sql
+var blockReferrerByCode string
+
+//go:embed queries/count_referrals_by_referrer.sql
+var countReferralsByReferrer string
+
+//go:embed queries/insert_referral.sql
+var insertReferral string
+
+//go:embed queries/select_referrals_by_referrer.sql
+var selectReferralsByReferrer string
+
+//go:embed queries/select_referral_code_by_user.sql
+var selectReferralCodeByUser string
+
+//go:embed queries/credit_referral.sql
+var creditReferral string
+
+type PostgresReferralRepository struct {
+	postgresPool *db.PostgresPool
+	logger       *zap.Logger
+	getter       *trmpgx.CtxGetter
+}
+
+func NewPostgresReferralRepository(postgresPool *db.PostgresPool, logger *zap.Logger) *PostgresReferralRepository {
+	return &PostgresReferralRepository{
+		postgresPool: postgresPool,
+		logger:       logger,
+		getter:       trmpgx.DefaultCtxGetter,
+	}
+}
+
+// BlockReferrer returns the login of the user owning the referral code and locks the user until the end of the transaction,
+// so that the referrals of one referrer are counted and inserted one at a time. Must be called within a transaction.
+func (r *PostgresReferralRepository) BlockReferrer(ctx context.Context, referralCode string) (string, error) {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	var referrerLogin string
+	err := conn.QueryRow(ctx, blockReferrerByCode, referralCode).Scan(&referrerLogin)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", apperrors.ErrReferralCodeNotFound
+	}
+
+	if err != nil {
+		return "", apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	return referrerLogin, nil
+}
+
+func (r *PostgresReferralRepository) CountByReferrer(ctx context.Context, referrerLogin string) (int, error) {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	var referrals int
+	err := conn.QueryRow(ctx, countReferralsByReferrer, referrerLogin).Scan(&referrals)
+	if err != nil {
+		return 0, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	return referrals, nil
+}
+
+func (r *PostgresReferralRepository) Insert(ctx context.Context, referral model.Referral) error {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	_, err := conn.Exec(ctx, insertReferral, referral.RefereeLogin, referral.ReferrerLogin)
+
+	var e *pgconn.PgError
+	if errors.As(err, &e) && e.Code == pgerrcode.CheckViolation && e.ConstraintName == "no_self_referral" {
+		return apperrors.ErrSelfReferral
+	}
+
+	if err != nil {
+		return apperrors.NewValueError("exec failed", utils.Caller(), err)
+	}
+
+	return nil
+}
+
+func (r *PostgresReferralRepository) SelectByReferrer(ctx context.Context, referrerLogin string) ([]model.Referral, error) {
+	queryRows, err := r.postgresPool.DB.Query(ctx, selectReferralsByReferrer, referrerLogin)
+	if err != nil {
+		return nil, apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+	defer queryRows.Close()
+
+	referrals, err := pgx.CollectRows(queryRows, pgx.RowToStructByPos[model.Referral])
+	if err != nil {
+		return nil, apperrors.NewValueError("unable to collect rows", utils.Caller(), err)
+	}
+
+	return referrals, nil
+}
+
+func (r *PostgresReferralRepository) SelectReferralCode(ctx context.Context, userLogin string) (string, error) {
+	var referralCode string
+	err := r.postgresPool.DB.QueryRow(ctx, selectReferralCodeByUser, userLogin).Scan(&referralCode)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", apperrors.NewValueError("user not found", utils.Caller(), apperrors.ErrUserNotFound)
+	}
+
+	if err != nil {
+		return "", apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	return referralCode, nil
+}
+
+// Credit marks the pending referral of the referee as credited with the bonus and returns the login of the referrer.
+// A referral is credited at most once: ErrNoPendingReferral is returned when the referee was not referred or is already credited.
+// Must be called within the transaction that credits the bonus.
+func (r *PostgresReferralRepository) Credit(ctx context.Context, refereeLogin string, bonus decimal.Decimal) (string, error) {
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	var referrerLogin string
+	err := conn.QueryRow(ctx, creditReferral, refereeLogin, bonus).Scan(&referrerLogin)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", apperrors.ErrNoPendingReferral
+	}
+
+	if err != nil {
+		return "", apperrors.NewValueError("query failed", utils.Caller(), err)
+	}
+
+	return referrerLogin, nil
+}
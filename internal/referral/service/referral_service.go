@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/referral/handler/dto"
+	"github.com/msmkdenis/yap-gophermart/internal/referral/model"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+type ReferralRepository interface {
+	SelectByReferrer(ctx context.Context, referrerLogin string) ([]model.Referral, error)
+	SelectReferralCode(ctx context.Context, userLogin string) (string, error)
+}
+
+type ReferralUseCase struct {
+	repository ReferralRepository
+	logger     *zap.Logger
+}
+
+func NewReferralService(repository ReferralRepository, logger *zap.Logger) *ReferralUseCase {
+	return &ReferralUseCase{
+		repository: repository,
+		logger:     logger,
+	}
+}
+
+// GetByUser returns the personal referral code of the user and the users registered with it.
+func (u *ReferralUseCase) GetByUser(ctx context.Context, userLogin string) (*dto.ReferralsResponse, error) {
+	referralCode, err := u.repository.SelectReferralCode(ctx, userLogin)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	referrals, err := u.repository.SelectByReferrer(ctx, userLogin)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", utils.Caller(), err)
+	}
+
+	referralsResponse := dto.ReferralsResponse{
+		ReferralCode: referralCode,
+		Referrals:    make([]dto.ReferralResponse, 0, len(referrals)),
+	}
+	for _, v := range referrals {
+		referralsResponse.Referrals = append(referralsResponse.Referrals, dto.MapToReferralResponse(v))
+	}
+
+	return &referralsResponse, nil
+}
@@ -0,0 +1,121 @@
+// Package scheduler runs the periodic background tasks of the service, such as the release of expired holds
+// or the nightly recalculation of loyalty tiers.
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Task is a periodic task. An error is logged and the task runs again at its next tick.
+type Task func(ctx context.Context) error
+
+type job struct {
+	name string
+	next func(now time.Time) time.Time
+	task Task
+}
+
+type Scheduler struct {
+	logger *zap.Logger
+	jobs   []job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		logger: logger,
+	}
+}
+
+// Every schedules the task to run every interval once the scheduler is started. Must be called before Start.
+// A non-positive interval disables the task.
+func (s *Scheduler) Every(name string, interval time.Duration, task Task) {
+	if interval <= 0 {
+		s.logger.Warn("Scheduled task disabled", zap.String("task", name), zap.Duration("interval", interval))
+		return
+	}
+
+	s.jobs = append(s.jobs, job{
+		name: name,
+		next: func(now time.Time) time.Time {
+			return now.Add(interval)
+		},
+		task: task,
+	})
+}
+
+// Daily schedules the task to run once a day, at the given offset from the local midnight. Must be called before Start.
+func (s *Scheduler) Daily(name string, at time.Duration, task Task) {
+	s.jobs = append(s.jobs, job{
+		name: name,
+		next: func(now time.Time) time.Time {
+			return nextDaily(now, at)
+		},
+		task: task,
+	})
+}
+
+// Start runs every scheduled task in its own goroutine until Stop is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.run(ctx, j)
+	}
+}
+
+// Stop stops the tasks and waits for the running ones to finish or ctx to expire.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Info("scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, j job) {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(time.Until(j.next(time.Now())))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if err := j.task(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Scheduled task failed", zap.String("task", j.name), zap.Error(err))
+			}
+			timer.Reset(time.Until(j.next(time.Now())))
+		}
+	}
+}
+
+// nextDaily returns the first moment after now that is at the offset from a midnight.
+func nextDaily(now time.Time, at time.Duration) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	next := midnight.Add(at)
+	if !next.After(now) {
+		next = midnight.AddDate(0, 0, 1).Add(at)
+	}
+
+	return next
+}
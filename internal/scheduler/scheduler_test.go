@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSchedulerRunsTasksUntilStopped(t *testing.T) {
+	s := NewScheduler(zap.NewNop())
+
+	var succeeding, failing atomic.Int32
+	s.Every("succeeding", 10*time.Millisecond, func(ctx context.Context) error {
+		succeeding.Add(1)
+		return nil
+	})
+	s.Every("failing", 10*time.Millisecond, func(ctx context.Context) error {
+		failing.Add(1)
+		return errors.New("some error")
+	})
+	s.Start(context.Background())
+
+	require.Eventually(t, func() bool {
+		return succeeding.Load() >= 3 && failing.Load() >= 3
+	}, time.Second, 5*time.Millisecond, "failed task must run again")
+
+	require.NoError(t, s.Stop(context.Background()))
+	stoppedAt := succeeding.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stoppedAt, succeeding.Load(), "task must not run after Stop")
+}
+
+func TestSchedulerStopWaitsForRunningTask(t *testing.T) {
+	s := NewScheduler(zap.NewNop())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s.Every("slow", 10*time.Millisecond, func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+	s.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, s.Stop(context.Background()))
+}
+
+func TestNextDaily(t *testing.T) {
+	at := 3 * time.Hour
+
+	testCases := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "before the time of day",
+			now:      time.Date(2023, 12, 31, 1, 30, 0, 0, time.UTC),
+			expected: time.Date(2023, 12, 31, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "at the time of day",
+			now:      time.Date(2023, 12, 31, 3, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "after the time of day",
+			now:      time.Date(2023, 12, 31, 22, 15, 0, 0, time.UTC),
+			expected: time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, nextDaily(test.now, at))
+		})
+	}
+}
+
+func TestSchedulerSkipsNonPositiveInterval(t *testing.T) {
+	s := NewScheduler(zap.NewNop())
+
+	var runs atomic.Int32
+	s.Every("zero", 0, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	s.Every("negative", -time.Second, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	s.Start(context.Background())
+
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, s.Stop(context.Background()))
+	assert.Equal(t, int32(0), runs.Load(), "task with a non-positive interval must not run")
+}
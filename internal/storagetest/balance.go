@@ -0,0 +1,836 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ShiraazMoollatjie/goluhn"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/balance/model"
+	orderModel "github.com/msmkdenis/yap-gophermart/internal/order/model"
+)
+
+func runBalanceContract(t *testing.T, repositories Repositories) {
+	ctx := context.Background()
+
+	t.Run("credit accrual once", func(t *testing.T) {
+		login := newUser(t, repositories)
+		orderNumber := newOrder(t, repositories, login)
+
+		require.NoError(t, creditAccrual(repositories, orderNumber, login, decimal.NewFromInt(300)))
+		assert.ErrorIs(t, creditAccrual(repositories, orderNumber, login, decimal.NewFromInt(300)), apperrors.ErrAccrualAlreadyCredited)
+
+		assertBalance(t, repositories, login, decimal.NewFromInt(300), decimal.Zero)
+	})
+
+	t.Run("unknown user has no balance", func(t *testing.T) {
+		_, err := repositories.Balance.SelectByUserLogin(ctx, "contract-unknown")
+		assert.ErrorIs(t, err, apperrors.ErrBalanceNotFound)
+	})
+
+	t.Run("withdraw", func(t *testing.T) {
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+
+		_, err := repositories.Balance.SelectWithdrawalsByUserLogin(ctx, login)
+		assert.ErrorIs(t, err, apperrors.ErrNoWithdrawals)
+
+		orderNumber := goluhn.Generate(16)
+		require.NoError(t, repositories.Balance.Withdraw(ctx, orderNumber, login, decimal.NewFromFloat(40.25), nil))
+		assertBalance(t, repositories, login, decimal.NewFromFloat(59.75), decimal.NewFromFloat(40.25))
+
+		withdrawals, err := repositories.Balance.SelectWithdrawalsByUserLogin(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, withdrawals, 1)
+		assert.Equal(t, orderNumber, withdrawals[0].OrderNumber)
+		assert.Equal(t, login, withdrawals[0].UserLogin)
+		assert.True(t, decimal.NewFromFloat(40.25).Equal(withdrawals[0].Amount))
+		assert.False(t, withdrawals[0].ProcessedAt.IsZero())
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+
+		err := repositories.Balance.Withdraw(ctx, goluhn.Generate(16), login, decimal.NewFromFloat(100.01), nil)
+		assert.ErrorIs(t, err, apperrors.ErrInsufficientFunds)
+
+		assertBalance(t, repositories, login, decimal.NewFromInt(100), decimal.Zero)
+		_, err = repositories.Balance.SelectWithdrawalsByUserLogin(ctx, login)
+		assert.ErrorIs(t, err, apperrors.ErrNoWithdrawals)
+	})
+
+	t.Run("withdraw for order once", func(t *testing.T) {
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+
+		orderNumber := goluhn.Generate(16)
+		require.NoError(t, repositories.Balance.Withdraw(ctx, orderNumber, login, decimal.NewFromInt(10), nil))
+		err := repositories.Balance.Withdraw(ctx, orderNumber, login, decimal.NewFromInt(10), nil)
+		assert.ErrorIs(t, err, apperrors.ErrWithdrawalAlreadyExists)
+
+		assertBalance(t, repositories, login, decimal.NewFromInt(90), decimal.NewFromInt(10))
+	})
+
+	t.Run("idempotency key", func(t *testing.T) {
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+		key := model.IdempotencyKey{UserLogin: login, Key: uuid.NewString(), Fingerprint: "withdraw 10"}
+
+		record, err := repositories.Balance.ReserveIdempotencyKey(ctx, key)
+		require.NoError(t, err)
+		assert.Nil(t, record, "new key must be reserved")
+
+		record, err = repositories.Balance.ReserveIdempotencyKey(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, record, "reserved key must not be reserved again")
+		assert.Equal(t, key.Fingerprint, record.Fingerprint)
+		assert.Nil(t, record.Result)
+
+		require.NoError(t, repositories.Balance.Withdraw(ctx, goluhn.Generate(16), login, decimal.NewFromInt(10), &key))
+		record, err = repositories.Balance.ReserveIdempotencyKey(ctx, model.IdempotencyKey{UserLogin: login, Key: key.Key, Fingerprint: "another"})
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, key.Fingerprint, record.Fingerprint)
+		require.NotNil(t, record.Result)
+		assert.Equal(t, model.ResultWithdrawn, *record.Result)
+
+		record, err = repositories.Balance.ReserveIdempotencyKey(ctx, model.IdempotencyKey{UserLogin: newUser(t, repositories), Key: key.Key, Fingerprint: "another"})
+		require.NoError(t, err)
+		assert.Nil(t, record, "keys of different users must not collide")
+
+		failedKey := model.IdempotencyKey{UserLogin: login, Key: uuid.NewString(), Fingerprint: "withdraw 1000"}
+		_, err = repositories.Balance.ReserveIdempotencyKey(ctx, failedKey)
+		require.NoError(t, err)
+		require.NoError(t, repositories.Balance.CompleteIdempotencyKey(ctx, failedKey, model.ResultInsufficientFunds))
+		require.NoError(t, repositories.Balance.ReleaseIdempotencyKey(ctx, failedKey), "completed key must not be released")
+		record, err = repositories.Balance.ReserveIdempotencyKey(ctx, failedKey)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		require.NotNil(t, record.Result)
+		assert.Equal(t, model.ResultInsufficientFunds, *record.Result)
+
+		releasedKey := model.IdempotencyKey{UserLogin: login, Key: uuid.NewString(), Fingerprint: "withdraw 5"}
+		_, err = repositories.Balance.ReserveIdempotencyKey(ctx, releasedKey)
+		require.NoError(t, err)
+		require.NoError(t, repositories.Balance.ReleaseIdempotencyKey(ctx, releasedKey))
+		record, err = repositories.Balance.ReserveIdempotencyKey(ctx, releasedKey)
+		require.NoError(t, err)
+		assert.Nil(t, record, "released key must be reserved again")
+
+		assertBalance(t, repositories, login, decimal.NewFromInt(90), decimal.NewFromInt(10))
+	})
+
+	t.Run("reverse withdrawal", func(t *testing.T) {
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+
+		_, err := repositories.Balance.ReverseWithdrawal(ctx, goluhn.Generate(16), "cancelled")
+		assert.ErrorIs(t, err, apperrors.ErrWithdrawalNotFound)
+
+		orderNumber := goluhn.Generate(16)
+		require.NoError(t, repositories.Balance.Withdraw(ctx, orderNumber, login, decimal.NewFromInt(70), nil))
+
+		reversal, err := repositories.Balance.ReverseWithdrawal(ctx, orderNumber, "cancelled")
+		require.NoError(t, err)
+		assert.Equal(t, orderNumber, reversal.OrderNumber)
+		assert.Equal(t, login, reversal.UserLogin)
+		assert.Equal(t, "cancelled", reversal.Reason)
+		assert.True(t, decimal.NewFromInt(70).Equal(reversal.Amount))
+		assert.False(t, reversal.ReversedAt.IsZero())
+
+		_, err = repositories.Balance.ReverseWithdrawal(ctx, orderNumber, "cancelled")
+		assert.ErrorIs(t, err, apperrors.ErrWithdrawalAlreadyReversed)
+
+		assertBalance(t, repositories, login, decimal.NewFromInt(100), decimal.Zero)
+
+		withdrawals, err := repositories.Balance.SelectWithdrawalsByUserLogin(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, withdrawals, 1)
+		assert.Equal(t, reversal.WithdrawalID, withdrawals[0].ID)
+		assert.NotNil(t, withdrawals[0].ReversedAt)
+
+		entries, err := repositories.Balance.SelectLedgerByUserLogin(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, model.ReasonReversal, entries[2].Reason)
+		assert.Equal(t, reversal.ID, entries[2].TransactionID)
+		assert.True(t, decimal.NewFromInt(100).Equal(entries[2].Balance))
+
+		drifts, err := repositories.Balance.SelectBalanceDrifts(ctx)
+		require.NoError(t, err)
+		for _, drift := range drifts {
+			assert.NotEqual(t, login, drift.UserLogin, "balance must equal its ledger sum")
+		}
+	})
+
+	t.Run("parallel reversals of one withdrawal", func(t *testing.T) {
+		const attempts = 10
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+		orderNumber := goluhn.Generate(16)
+		require.NoError(t, repositories.Balance.Withdraw(ctx, orderNumber, login, decimal.NewFromInt(60), nil))
+
+		errs := make(chan error, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repositories.Balance.ReverseWithdrawal(ctx, orderNumber, "cancelled")
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, apperrors.ErrWithdrawalAlreadyReversed)
+		}
+
+		assert.Equal(t, 1, succeeded)
+		assertBalance(t, repositories, login, decimal.NewFromInt(100), decimal.Zero)
+	})
+
+	t.Run("parallel withdrawals never overdraw", func(t *testing.T) {
+		const attempts = 10
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+
+		errs := make(chan error, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- repositories.Balance.Withdraw(ctx, goluhn.Generate(16), login, decimal.NewFromInt(30), nil)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+			}
+		}
+
+		// withdrawals racing for the balance may also fail on serialization, but never overdraw it
+		assert.Positive(t, succeeded)
+		assert.LessOrEqual(t, succeeded, 3)
+
+		withdrawn := decimal.NewFromInt(int64(30 * succeeded))
+		assertBalance(t, repositories, login, decimal.NewFromInt(100).Sub(withdrawn), withdrawn)
+
+		withdrawals, err := repositories.Balance.SelectWithdrawalsByUserLogin(ctx, login)
+		require.NoError(t, err)
+		assert.Len(t, withdrawals, succeeded)
+	})
+
+	t.Run("ledger", func(t *testing.T) {
+		login := newUser(t, repositories)
+
+		_, err := repositories.Balance.SelectLedgerByUserLogin(ctx, login)
+		assert.ErrorIs(t, err, apperrors.ErrNoLedgerEntries)
+
+		orderNumber := newOrder(t, repositories, login)
+		require.NoError(t, creditAccrual(repositories, orderNumber, login, decimal.NewFromInt(100)))
+		withdrawalNumber := goluhn.Generate(16)
+		require.NoError(t, repositories.Balance.Withdraw(ctx, withdrawalNumber, login, decimal.NewFromInt(30), nil))
+		require.NoError(t, updateBalance(repositories, login, decimal.NewFromInt(-5), model.ReasonAdjustment, "contract"))
+		assert.ErrorIs(t, updateBalance(repositories, login, decimal.NewFromInt(-100), model.ReasonAdjustment, "contract"),
+			apperrors.ErrInsufficientFunds)
+
+		entries, err := repositories.Balance.SelectLedgerByUserLogin(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+
+		expected := []struct {
+			amount    int64
+			reason    string
+			reference string
+			balance   int64
+		}{
+			{100, model.ReasonAccrual, orderNumber, 100},
+			{-30, model.ReasonWithdrawal, withdrawalNumber, 70},
+			{-5, model.ReasonAdjustment, "contract", 65},
+		}
+		for i, entry := range entries {
+			assert.True(t, decimal.NewFromInt(expected[i].amount).Equal(entry.Amount), "amount of entry %d", i)
+			assert.Equal(t, expected[i].reason, entry.Reason)
+			assert.Equal(t, expected[i].reference, entry.Reference)
+			assert.True(t, decimal.NewFromInt(expected[i].balance).Equal(entry.Balance), "balance after entry %d", i)
+			assert.NotEmpty(t, entry.TransactionID)
+		}
+
+		withdrawals, err := repositories.Balance.SelectWithdrawalsByUserLogin(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, withdrawals, 1)
+		assert.Equal(t, withdrawals[0].ID, entries[1].TransactionID, "withdrawal must be its own ledger transaction")
+
+		assertBalance(t, repositories, login, decimal.NewFromInt(65), decimal.NewFromInt(30))
+
+		drifts, err := repositories.Balance.SelectBalanceDrifts(ctx)
+		require.NoError(t, err)
+		for _, drift := range drifts {
+			assert.NotEqual(t, login, drift.UserLogin, "balance must equal its ledger sum")
+		}
+
+		unbalanced, err := repositories.Balance.SelectUnbalancedTransactions(ctx)
+		require.NoError(t, err)
+		for _, transaction := range unbalanced {
+			for _, entry := range entries {
+				assert.NotEqual(t, entry.TransactionID, transaction.TransactionID, "ledger transaction must sum to zero")
+			}
+		}
+	})
+
+	t.Run("hold capture and release", func(t *testing.T) {
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+
+		_, err := repositories.Balance.SelectHoldsByUserLogin(ctx, login)
+		assert.ErrorIs(t, err, apperrors.ErrNoHolds)
+
+		captured := newHold(login, goluhn.Generate(16), decimal.NewFromInt(30))
+		require.NoError(t, repositories.Balance.InsertHold(ctx, &captured, time.Hour))
+		assert.False(t, captured.CreatedAt.IsZero())
+		assert.True(t, captured.ExpiresAt.After(captured.CreatedAt))
+
+		duplicate := newHold(login, captured.OrderNumber, decimal.NewFromInt(10))
+		assert.ErrorIs(t, repositories.Balance.InsertHold(ctx, &duplicate, time.Hour), apperrors.ErrHoldAlreadyExists)
+
+		tooLarge := newHold(login, goluhn.Generate(16), decimal.NewFromInt(71))
+		assert.ErrorIs(t, repositories.Balance.InsertHold(ctx, &tooLarge, time.Hour), apperrors.ErrInsufficientFunds)
+
+		released := newHold(login, goluhn.Generate(16), decimal.NewFromInt(20))
+		require.NoError(t, repositories.Balance.InsertHold(ctx, &released, time.Hour))
+		assertHeld(t, repositories, login, decimal.NewFromInt(50), decimal.NewFromInt(50))
+
+		_, err = repositories.Balance.CaptureHold(ctx, newUser(t, repositories), captured.ID)
+		assert.ErrorIs(t, err, apperrors.ErrHoldNotFound, "hold of another user must not be found")
+
+		capture, err := repositories.Balance.CaptureHold(ctx, login, captured.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.HoldStatusCaptured, capture.Status)
+		require.NotNil(t, capture.WithdrawalID)
+		require.NotNil(t, capture.FinishedAt)
+
+		_, err = repositories.Balance.CaptureHold(ctx, login, captured.ID)
+		assert.ErrorIs(t, err, apperrors.ErrHoldNotActive)
+		_, err = repositories.Balance.ReleaseHold(ctx, login, captured.ID)
+		assert.ErrorIs(t, err, apperrors.ErrHoldNotActive)
+
+		release, err := repositories.Balance.ReleaseHold(ctx, login, released.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.HoldStatusReleased, release.Status)
+		assert.Nil(t, release.WithdrawalID)
+
+		assertBalance(t, repositories, login, decimal.NewFromInt(70), decimal.NewFromInt(30))
+		assertHeld(t, repositories, login, decimal.NewFromInt(70), decimal.Zero)
+
+		withdrawals, err := repositories.Balance.SelectWithdrawalsByUserLogin(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, withdrawals, 1)
+		assert.Equal(t, captured.OrderNumber, withdrawals[0].OrderNumber)
+		assert.Equal(t, *capture.WithdrawalID, withdrawals[0].ID)
+
+		holds, err := repositories.Balance.SelectHoldsByUserLogin(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, holds, 2)
+		statuses := []string{holds[0].Status, holds[1].Status}
+		assert.ElementsMatch(t, []string{model.HoldStatusCaptured, model.HoldStatusReleased}, statuses)
+
+		again := newHold(login, released.OrderNumber, decimal.NewFromInt(20))
+		require.NoError(t, repositories.Balance.InsertHold(ctx, &again, time.Hour), "order of a released hold may be held again")
+
+		drifts, err := repositories.Balance.SelectBalanceDrifts(ctx)
+		require.NoError(t, err)
+		for _, drift := range drifts {
+			assert.NotEqual(t, login, drift.UserLogin, "current and held must equal the ledger sum")
+		}
+	})
+
+	t.Run("expired holds are released", func(t *testing.T) {
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+
+		expired := newHold(login, goluhn.Generate(16), decimal.NewFromInt(40))
+		require.NoError(t, repositories.Balance.InsertHold(ctx, &expired, 0))
+		active := newHold(login, goluhn.Generate(16), decimal.NewFromInt(10))
+		require.NoError(t, repositories.Balance.InsertHold(ctx, &active, time.Hour))
+
+		_, err := repositories.Balance.CaptureHold(ctx, login, expired.ID)
+		assert.ErrorIs(t, err, apperrors.ErrHoldNotActive, "expired hold must not be captured")
+
+		released, err := repositories.Balance.ReleaseExpiredHolds(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, released, 1)
+		assertHeld(t, repositories, login, decimal.NewFromInt(90), decimal.NewFromInt(10))
+
+		holds, err := repositories.Balance.SelectHoldsByUserLogin(ctx, login)
+		require.NoError(t, err)
+		for _, hold := range holds {
+			if hold.ID == expired.ID {
+				assert.Equal(t, model.HoldStatusExpired, hold.Status)
+				assert.NotNil(t, hold.FinishedAt)
+			}
+		}
+	})
+
+	t.Run("parallel holds never overdraw", func(t *testing.T) {
+		const attempts = 10
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+
+		errs := make(chan error, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				hold := newHold(login, goluhn.Generate(16), decimal.NewFromInt(30))
+				errs <- repositories.Balance.InsertHold(ctx, &hold, time.Hour)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, apperrors.ErrInsufficientFunds)
+		}
+
+		assert.Equal(t, 3, succeeded)
+		held := decimal.NewFromInt(int64(30 * succeeded))
+		assertHeld(t, repositories, login, decimal.NewFromInt(100).Sub(held), held)
+	})
+
+	t.Run("lots are consumed oldest first and expire", func(t *testing.T) {
+		login := newUser(t, repositories)
+		oldest := newOrder(t, repositories, login)
+		newest := newOrder(t, repositories, login)
+		require.NoError(t, creditAccrual(repositories, oldest, login, decimal.NewFromInt(100)))
+		require.NoError(t, creditAccrual(repositories, newest, login, decimal.NewFromInt(50)))
+		assertExpiring(t, repositories, login, decimal.NewFromInt(150))
+
+		require.NoError(t, repositories.Balance.Withdraw(ctx, goluhn.Generate(16), login, decimal.NewFromInt(120), nil))
+		assertExpiring(t, repositories, login, decimal.NewFromInt(30))
+
+		hold := newHold(login, goluhn.Generate(16), decimal.NewFromInt(10))
+		require.NoError(t, repositories.Balance.InsertHold(ctx, &hold, time.Hour))
+
+		expired, err := repositories.Balance.ExpireLotsByUserLogin(ctx, login, 12)
+		require.NoError(t, err)
+		assert.True(t, expired.IsZero(), "lots credited now must not expire after 12 months")
+
+		userLogins, err := repositories.Balance.SelectUsersWithExpiredLots(ctx, 0)
+		require.NoError(t, err)
+		assert.Contains(t, userLogins, login)
+
+		expired, err = repositories.Balance.ExpireLotsByUserLogin(ctx, login, 0)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(20).Equal(expired), "held points must not expire, expired %s", expired)
+		assertHeld(t, repositories, login, decimal.Zero, decimal.NewFromInt(10))
+
+		entries, err := repositories.Balance.SelectLedgerByUserLogin(ctx, login)
+		require.NoError(t, err)
+		last := entries[len(entries)-1]
+		assert.Equal(t, model.ReasonExpiration, last.Reason)
+		assert.Equal(t, newest, last.Reference, "the oldest lot must be consumed by the withdrawal")
+		assert.True(t, decimal.NewFromInt(-20).Equal(last.Amount))
+
+		_, err = repositories.Balance.ReleaseHold(ctx, login, hold.ID)
+		require.NoError(t, err)
+		expired, err = repositories.Balance.ExpireLotsByUserLogin(ctx, login, 0)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(10).Equal(expired), "released points must expire, expired %s", expired)
+		assertBalance(t, repositories, login, decimal.Zero, decimal.NewFromInt(120))
+		assertExpiring(t, repositories, login, decimal.Zero)
+
+		userLogins, err = repositories.Balance.SelectUsersWithExpiredLots(ctx, 0)
+		require.NoError(t, err)
+		assert.NotContains(t, userLogins, login)
+
+		drifts, err := repositories.Balance.SelectBalanceDrifts(ctx)
+		require.NoError(t, err)
+		for _, drift := range drifts {
+			assert.NotEqual(t, login, drift.UserLogin, "balance must equal its ledger sum")
+		}
+	})
+
+	t.Run("refunds and adjustments are lots", func(t *testing.T) {
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(100)))
+
+		orderNumber := goluhn.Generate(16)
+		require.NoError(t, repositories.Balance.Withdraw(ctx, orderNumber, login, decimal.NewFromInt(100), nil))
+		assertExpiring(t, repositories, login, decimal.Zero)
+
+		_, err := repositories.Balance.ReverseWithdrawal(ctx, orderNumber, "cancelled")
+		require.NoError(t, err)
+		require.NoError(t, updateBalance(repositories, login, decimal.NewFromInt(15), model.ReasonAdjustment, "contract"))
+		require.NoError(t, updateBalance(repositories, login, decimal.NewFromInt(-5), model.ReasonAdjustment, "contract"))
+		assertExpiring(t, repositories, login, decimal.NewFromInt(110))
+	})
+
+	t.Run("reversed points keep their credit date", func(t *testing.T) {
+		login := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(30)))
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, login), login, decimal.NewFromInt(50)))
+
+		credited, err := repositories.Balance.SelectLotsByUserLogin(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, credited, 2)
+		time.Sleep(10 * time.Millisecond)
+
+		withdrawn := goluhn.Generate(16)
+		require.NoError(t, repositories.Balance.Withdraw(ctx, withdrawn, login, decimal.NewFromInt(40), nil))
+		hold := newHold(login, goluhn.Generate(16), decimal.NewFromInt(40))
+		require.NoError(t, repositories.Balance.InsertHold(ctx, &hold, time.Hour))
+		_, err = repositories.Balance.CaptureHold(ctx, login, hold.ID)
+		require.NoError(t, err)
+
+		_, err = repositories.Balance.ReverseWithdrawal(ctx, withdrawn, "cancelled")
+		require.NoError(t, err)
+		_, err = repositories.Balance.ReverseWithdrawal(ctx, hold.OrderNumber, "cancelled")
+		require.NoError(t, err)
+		assertBalance(t, repositories, login, decimal.NewFromInt(80), decimal.Zero)
+
+		refunded, err := repositories.Balance.SelectLotsByUserLogin(ctx, login)
+		require.NoError(t, err)
+		remaining := make(map[time.Time]decimal.Decimal)
+		for _, lot := range refunded {
+			assert.Equal(t, model.ReasonReversal, lot.Reason)
+			remaining[lot.CreditedAt.UTC()] = remaining[lot.CreditedAt.UTC()].Add(lot.Remaining)
+		}
+		require.Len(t, remaining, 2, "refunded points must keep the dates of the consumed lots")
+		for _, lot := range credited {
+			assert.True(t, lot.Amount.Equal(remaining[lot.CreditedAt.UTC()]),
+				"refunded on %s: expected %s, got %s", lot.CreditedAt, lot.Amount, remaining[lot.CreditedAt.UTC()])
+		}
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		sender := newUser(t, repositories)
+		recipient := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, sender), sender, decimal.NewFromInt(100)))
+
+		_, err := repositories.Balance.SelectTransfersByUserLogin(ctx, sender)
+		assert.ErrorIs(t, err, apperrors.ErrNoTransfers)
+
+		transfer := newTransfer(sender, recipient, decimal.NewFromInt(40))
+		require.NoError(t, repositories.Balance.Transfer(ctx, &transfer, model.TransferLimits{}))
+		assert.False(t, transfer.TransferredAt.IsZero())
+		assertBalance(t, repositories, sender, decimal.NewFromInt(60), decimal.Zero)
+		assertBalance(t, repositories, recipient, decimal.NewFromInt(40), decimal.Zero)
+		assertExpiring(t, repositories, sender, decimal.NewFromInt(60))
+		assertExpiring(t, repositories, recipient, decimal.NewFromInt(40))
+
+		overdraw := newTransfer(sender, recipient, decimal.NewFromInt(61))
+		assert.ErrorIs(t, repositories.Balance.Transfer(ctx, &overdraw, model.TransferLimits{}), apperrors.ErrInsufficientFunds)
+		unknown := newTransfer(sender, "contract-"+uuid.NewString(), decimal.NewFromInt(10))
+		assert.ErrorIs(t, repositories.Balance.Transfer(ctx, &unknown, model.TransferLimits{}), apperrors.ErrTransferRecipientNotFound)
+		assertBalance(t, repositories, sender, decimal.NewFromInt(60), decimal.Zero)
+
+		for _, userLogin := range []string{sender, recipient} {
+			transfers, errTransfers := repositories.Balance.SelectTransfersByUserLogin(ctx, userLogin)
+			require.NoError(t, errTransfers)
+			require.Len(t, transfers, 1, "transfer must be visible to both users")
+			assert.Equal(t, transfer.ID, transfers[0].ID)
+			assert.Equal(t, sender, transfers[0].SenderLogin)
+			assert.Equal(t, recipient, transfers[0].RecipientLogin)
+			assert.True(t, decimal.NewFromInt(40).Equal(transfers[0].Amount))
+		}
+
+		_, err = repositories.Balance.SelectWithdrawalsByUserLogin(ctx, sender)
+		assert.ErrorIs(t, err, apperrors.ErrNoWithdrawals, "transfers are not withdrawals")
+
+		senderEntries, err := repositories.Balance.SelectLedgerByUserLogin(ctx, sender)
+		require.NoError(t, err)
+		require.Len(t, senderEntries, 2)
+		assert.Equal(t, model.ReasonTransfer, senderEntries[1].Reason)
+		assert.Equal(t, recipient, senderEntries[1].Reference)
+		assert.True(t, decimal.NewFromInt(-40).Equal(senderEntries[1].Amount))
+		assert.Equal(t, transfer.ID, senderEntries[1].TransactionID)
+
+		recipientEntries, err := repositories.Balance.SelectLedgerByUserLogin(ctx, recipient)
+		require.NoError(t, err)
+		require.Len(t, recipientEntries, 1)
+		assert.Equal(t, model.ReasonTransfer, recipientEntries[0].Reason)
+		assert.Equal(t, sender, recipientEntries[0].Reference)
+		assert.True(t, decimal.NewFromInt(40).Equal(recipientEntries[0].Amount))
+		assert.Equal(t, transfer.ID, recipientEntries[0].TransactionID, "both legs must be one ledger transaction")
+
+		drifts, err := repositories.Balance.SelectBalanceDrifts(ctx)
+		require.NoError(t, err)
+		for _, drift := range drifts {
+			assert.NotContains(t, []string{sender, recipient}, drift.UserLogin, "balance must equal its ledger sum")
+		}
+
+		unbalanced, err := repositories.Balance.SelectUnbalancedTransactions(ctx)
+		require.NoError(t, err)
+		for _, transaction := range unbalanced {
+			assert.NotEqual(t, transfer.ID, transaction.TransactionID, "ledger transaction must sum to zero")
+		}
+	})
+
+	t.Run("transfer daily limits", func(t *testing.T) {
+		sender := newUser(t, repositories)
+		recipient := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, sender), sender, decimal.NewFromInt(100)))
+
+		limits := model.TransferLimits{DailySum: decimal.NewFromInt(50), DailyCount: 2}
+		first := newTransfer(sender, recipient, decimal.NewFromInt(30))
+		require.NoError(t, repositories.Balance.Transfer(ctx, &first, limits))
+
+		overSum := newTransfer(sender, recipient, decimal.NewFromInt(21))
+		assert.ErrorIs(t, repositories.Balance.Transfer(ctx, &overSum, limits), apperrors.ErrTransferLimitExceeded)
+
+		second := newTransfer(sender, recipient, decimal.NewFromInt(10))
+		require.NoError(t, repositories.Balance.Transfer(ctx, &second, limits))
+
+		overCount := newTransfer(sender, recipient, decimal.NewFromInt(1))
+		assert.ErrorIs(t, repositories.Balance.Transfer(ctx, &overCount, limits), apperrors.ErrTransferLimitExceeded)
+
+		back := newTransfer(recipient, sender, decimal.NewFromInt(40))
+		require.NoError(t, repositories.Balance.Transfer(ctx, &back, limits), "received transfers must not count to the limits")
+
+		assertBalance(t, repositories, sender, decimal.NewFromInt(100), decimal.Zero)
+		assertBalance(t, repositories, recipient, decimal.Zero, decimal.Zero)
+	})
+
+	t.Run("transferred points keep their credit date", func(t *testing.T) {
+		sender := newUser(t, repositories)
+		recipient := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, sender), sender, decimal.NewFromInt(30)))
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, sender), sender, decimal.NewFromInt(50)))
+
+		credited, err := repositories.Balance.SelectLotsByUserLogin(ctx, sender)
+		require.NoError(t, err)
+		require.Len(t, credited, 2)
+		time.Sleep(10 * time.Millisecond)
+
+		transfer := newTransfer(sender, recipient, decimal.NewFromInt(40))
+		require.NoError(t, repositories.Balance.Transfer(ctx, &transfer, model.TransferLimits{}))
+
+		received, err := repositories.Balance.SelectLotsByUserLogin(ctx, recipient)
+		require.NoError(t, err)
+		require.Len(t, received, 2, "every consumed lot must be transferred with its date")
+		for i, amount := range []int64{30, 10} {
+			assert.True(t, decimal.NewFromInt(amount).Equal(received[i].Remaining), "remaining: %s", received[i].Remaining)
+			assert.True(t, credited[i].CreditedAt.Equal(received[i].CreditedAt),
+				"transferred points must expire on the original date: %s, got %s", credited[i].CreditedAt, received[i].CreditedAt)
+			assert.Equal(t, model.ReasonTransfer, received[i].Reason)
+		}
+
+		senderExpirations, err := repositories.Balance.SelectExpirationsByUserLogin(ctx, sender, 12)
+		require.NoError(t, err)
+		recipientExpirations, err := repositories.Balance.SelectExpirationsByUserLogin(ctx, recipient, 12)
+		require.NoError(t, err)
+		require.Len(t, recipientExpirations, 1)
+		assert.True(t, senderExpirations[0].ExpiresAt.Equal(recipientExpirations[0].ExpiresAt))
+
+		expired, err := repositories.Balance.ExpireLotsByUserLogin(ctx, recipient, 0)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(40).Equal(expired), "expired %s", expired)
+	})
+
+	t.Run("parallel opposite transfers", func(t *testing.T) {
+		const attempts = 10
+		first := newUser(t, repositories)
+		second := newUser(t, repositories)
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, first), first, decimal.NewFromInt(100)))
+		require.NoError(t, creditAccrual(repositories, newOrder(t, repositories, second), second, decimal.NewFromInt(100)))
+
+		errs := make(chan error, 2*attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			for _, pair := range [][2]string{{first, second}, {second, first}} {
+				wg.Add(1)
+				go func(sender string, recipient string) {
+					defer wg.Done()
+					transfer := newTransfer(sender, recipient, decimal.NewFromInt(10))
+					errs <- repositories.Balance.Transfer(ctx, &transfer, model.TransferLimits{})
+				}(pair[0], pair[1])
+			}
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		assertBalance(t, repositories, first, decimal.NewFromInt(100), decimal.Zero)
+		assertBalance(t, repositories, second, decimal.NewFromInt(100), decimal.Zero)
+	})
+
+	t.Run("loyalty tiers", func(t *testing.T) {
+		login := newUser(t, repositories)
+
+		balance, err := repositories.Balance.SelectByUserLogin(ctx, login)
+		require.NoError(t, err)
+		assert.Equal(t, model.DefaultTier, balance.Tier)
+		assert.True(t, decimal.NewFromInt(1).Equal(balance.Multiplier), "multiplier: %s", balance.Multiplier)
+
+		processOrder(t, repositories, login, decimal.NewFromInt(600), decimal.NewFromInt(600))
+		processOrder(t, repositories, login, decimal.NewFromInt(350), decimal.NewFromInt(385))
+		require.NoError(t, updateBalance(repositories, login, decimal.NewFromInt(5000), model.ReasonAdjustment, "contract"))
+
+		_, err = repositories.Balance.RecalculateTiers(ctx, 12)
+		require.NoError(t, err)
+		balance, err = repositories.Balance.SelectByUserLogin(ctx, login)
+		require.NoError(t, err)
+		assert.Equal(t, model.DefaultTier, balance.Tier, "only raw accruals count towards the tier")
+
+		processOrder(t, repositories, login, decimal.NewFromInt(50), decimal.NewFromInt(50))
+		changed, err := repositories.Balance.RecalculateTiers(ctx, 12)
+		require.NoError(t, err)
+		assert.Positive(t, changed)
+
+		balance, err = repositories.Balance.SelectByUserLogin(ctx, login)
+		require.NoError(t, err)
+		assert.Equal(t, "Silver", balance.Tier)
+		assert.True(t, decimal.RequireFromString("1.1").Equal(balance.Multiplier), "multiplier: %s", balance.Multiplier)
+
+		var multiplier decimal.Decimal
+		err = repositories.TrManager.Do(ctx, func(ctx context.Context) error {
+			multiplier, err = repositories.Balance.SelectAccrualMultiplier(ctx, login)
+			return err
+		})
+		require.NoError(t, err)
+		assert.True(t, balance.Multiplier.Equal(multiplier), "multiplier: %s", multiplier)
+
+		_, err = repositories.Balance.RecalculateTiers(ctx, 0)
+		require.NoError(t, err)
+		balance, err = repositories.Balance.SelectByUserLogin(ctx, login)
+		require.NoError(t, err)
+		assert.Equal(t, model.DefaultTier, balance.Tier, "accruals before the period must not count")
+	})
+
+	t.Run("parallel credits of one order", func(t *testing.T) {
+		const attempts = 10
+		login := newUser(t, repositories)
+		orderNumber := newOrder(t, repositories, login)
+
+		errs := make(chan error, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- creditAccrual(repositories, orderNumber, login, decimal.NewFromInt(50))
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+			}
+		}
+
+		assert.Equal(t, 1, succeeded)
+		assertBalance(t, repositories, login, decimal.NewFromInt(50), decimal.Zero)
+	})
+}
+
+func creditAccrual(repositories Repositories, orderNumber string, userLogin string, amount decimal.Decimal) error {
+	return repositories.TrManager.Do(context.Background(), func(ctx context.Context) error {
+		return repositories.Balance.CreditAccrual(ctx, orderNumber, userLogin, amount)
+	})
+}
+
+// processOrder moves a new order of the user to PROCESSED with the raw accrual and credits the accrual,
+// as the accrual worker does.
+func processOrder(t *testing.T, repositories Repositories, userLogin string, rawAccrual decimal.Decimal, accrual decimal.Decimal) {
+	t.Helper()
+
+	orderNumber := newOrder(t, repositories, userLogin)
+	err := repositories.TrManager.Do(context.Background(), func(ctx context.Context) error {
+		order := orderModel.Order{Number: orderNumber, UserLogin: userLogin, Status: orderModel.StatusProcessed, RawAccrual: rawAccrual, Accrual: accrual}
+		if err := repositories.Order.UpdateOrder(ctx, order, orderModel.StatusNew, 0); err != nil {
+			return err
+		}
+		return repositories.Balance.CreditAccrual(ctx, orderNumber, userLogin, accrual)
+	})
+	require.NoError(t, err)
+}
+
+func updateBalance(repositories Repositories, userLogin string, amount decimal.Decimal, reason string, reference string) error {
+	return repositories.TrManager.Do(context.Background(), func(ctx context.Context) error {
+		return repositories.Balance.UpdateBalance(ctx, userLogin, amount, reason, reference)
+	})
+}
+
+func newHold(userLogin string, orderNumber string, amount decimal.Decimal) model.Hold {
+	return model.Hold{
+		ID:          uuid.NewString(),
+		UserLogin:   userLogin,
+		OrderNumber: orderNumber,
+		Amount:      amount,
+		Status:      model.HoldStatusHeld,
+	}
+}
+
+func newTransfer(senderLogin string, recipientLogin string, amount decimal.Decimal) model.Transfer {
+	return model.Transfer{
+		ID:             uuid.NewString(),
+		SenderLogin:    senderLogin,
+		RecipientLogin: recipientLogin,
+		Amount:         amount,
+	}
+}
+
+func assertHeld(t *testing.T, repositories Repositories, userLogin string, current decimal.Decimal, held decimal.Decimal) {
+	t.Helper()
+
+	balance, err := repositories.Balance.SelectByUserLogin(context.Background(), userLogin)
+	require.NoError(t, err)
+	assert.True(t, current.Equal(balance.Current), "current: expected %s, got %s", current, balance.Current)
+	assert.True(t, held.Equal(balance.Held), "held: expected %s, got %s", held, balance.Held)
+}
+
+// assertExpiring checks that the points of the user expiring after 12 months sum to expected.
+func assertExpiring(t *testing.T, repositories Repositories, userLogin string, expected decimal.Decimal) {
+	t.Helper()
+
+	expirations, err := repositories.Balance.SelectExpirationsByUserLogin(context.Background(), userLogin, 12)
+	require.NoError(t, err)
+
+	sum := decimal.Zero
+	for _, expiration := range expirations {
+		assert.True(t, expiration.ExpiresAt.After(time.Now().AddDate(0, 11, 0)), "points must expire in 12 months")
+		sum = sum.Add(expiration.Amount)
+	}
+	assert.True(t, expected.Equal(sum), "expiring: expected %s, got %s", expected, sum)
+}
+
+func assertBalance(t *testing.T, repositories Repositories, userLogin string, current decimal.Decimal, withdrawn decimal.Decimal) {
+	t.Helper()
+
+	balance, err := repositories.Balance.SelectByUserLogin(context.Background(), userLogin)
+	require.NoError(t, err)
+	assert.True(t, current.Equal(balance.Current), "current: expected %s, got %s", current, balance.Current)
+	assert.True(t, withdrawn.Equal(balance.Withdrawn), "withdrawn: expected %s, got %s", withdrawn, balance.Withdrawn)
+}
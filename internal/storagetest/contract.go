@@ -0,0 +1,108 @@
+// Package storagetest is the contract test suite of the repositories: every storage backend
+// must pass it to be used by the services and the accrual worker.
+package storagetest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ShiraazMoollatjie/goluhn"
+	"github.com/avito-tech/go-transaction-manager/trm/manager"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	accrualService "github.com/msmkdenis/yap-gophermart/internal/accrual/service"
+	balanceModel "github.com/msmkdenis/yap-gophermart/internal/balance/model"
+	balanceService "github.com/msmkdenis/yap-gophermart/internal/balance/service"
+	orderModel "github.com/msmkdenis/yap-gophermart/internal/order/model"
+	orderService "github.com/msmkdenis/yap-gophermart/internal/order/service"
+	promoService "github.com/msmkdenis/yap-gophermart/internal/promo/service"
+	referralService "github.com/msmkdenis/yap-gophermart/internal/referral/service"
+	userModel "github.com/msmkdenis/yap-gophermart/internal/user/model"
+	userService "github.com/msmkdenis/yap-gophermart/internal/user/service"
+)
+
+type OrderRepository interface {
+	orderService.OrderRepository
+	accrualService.OrderRepository
+}
+
+type ReferralRepository interface {
+	userService.ReferralRepository
+	referralService.ReferralRepository
+	accrualService.ReferralRepository
+}
+
+type BalanceRepository interface {
+	balanceService.BalanceRepository
+	accrualService.BalanceRepository
+	UpdateBalance(ctx context.Context, userLogin string, amount decimal.Decimal, reason string, reference string) error
+	SelectLotsByUserLogin(ctx context.Context, userLogin string) ([]balanceModel.Lot, error)
+}
+
+// Repositories is a storage backend under test. The repositories must share the storage,
+// TrManager must open transactions of that storage.
+type Repositories struct {
+	User      userService.UserRepository
+	Order     OrderRepository
+	Balance   BalanceRepository
+	Promo     promoService.PromoRepository
+	Referral  ReferralRepository
+	TrManager *manager.Manager
+}
+
+// Run runs the contract against the backend. The storage may be shared between runs and contain
+// other data, so every test works with its own users and orders only.
+func Run(t *testing.T, newRepositories func(t *testing.T) Repositories) {
+	t.Run("user", func(t *testing.T) {
+		runUserContract(t, newRepositories(t))
+	})
+	t.Run("order", func(t *testing.T) {
+		runOrderContract(t, newRepositories(t))
+	})
+	t.Run("balance", func(t *testing.T) {
+		runBalanceContract(t, newRepositories(t))
+	})
+	t.Run("promo", func(t *testing.T) {
+		runPromoContract(t, newRepositories(t))
+	})
+	t.Run("referral", func(t *testing.T) {
+		runReferralContract(t, newRepositories(t))
+	})
+}
+
+func newUser(t *testing.T, repositories Repositories) string {
+	t.Helper()
+
+	login := "contract-" + uuid.NewString()
+	err := repositories.User.Insert(context.Background(), userModel.User{
+		ID:           uuid.NewString(),
+		Login:        login,
+		Password:     []byte("password-hash"),
+		ReferralCode: newReferralCode(),
+	})
+	require.NoError(t, err)
+
+	return login
+}
+
+func newReferralCode() string {
+	return strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:12])
+}
+
+func newOrder(t *testing.T, repositories Repositories, userLogin string) string {
+	t.Helper()
+
+	orderNumber := goluhn.Generate(16)
+	err := repositories.Order.Insert(context.Background(), orderModel.Order{
+		ID:        uuid.NewString(),
+		Number:    orderNumber,
+		UserLogin: userLogin,
+		Status:    orderModel.StatusNew,
+	})
+	require.NoError(t, err)
+
+	return orderNumber
+}
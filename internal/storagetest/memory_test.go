@@ -0,0 +1,31 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/avito-tech/go-transaction-manager/trm/manager"
+	"go.uber.org/zap"
+
+	balanceRepository "github.com/msmkdenis/yap-gophermart/internal/balance/repository"
+	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	orderRepository "github.com/msmkdenis/yap-gophermart/internal/order/repository"
+	promoRepository "github.com/msmkdenis/yap-gophermart/internal/promo/repository"
+	referralRepository "github.com/msmkdenis/yap-gophermart/internal/referral/repository"
+	userRepository "github.com/msmkdenis/yap-gophermart/internal/user/repository"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	Run(t, func(t *testing.T) Repositories {
+		logger := zap.NewNop()
+		storage := db.NewMemoryStorage(logger)
+
+		return Repositories{
+			User:      userRepository.NewMemoryUserRepository(storage, logger),
+			Order:     orderRepository.NewMemoryOrderRepository(storage, logger),
+			Balance:   balanceRepository.NewMemoryBalanceRepository(storage, logger),
+			Promo:     promoRepository.NewMemoryPromoRepository(storage, logger),
+			Referral:  referralRepository.NewMemoryReferralRepository(storage, logger),
+			TrManager: manager.Must(storage.TrFactory()),
+		}
+	})
+}
@@ -0,0 +1,264 @@
+package storagetest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/order/model"
+)
+
+const claimLimit = 100
+
+func runOrderContract(t *testing.T, repositories Repositories) {
+	ctx := context.Background()
+
+	t.Run("duplicate order", func(t *testing.T) {
+		login := newUser(t, repositories)
+		orderNumber := newOrder(t, repositories, login)
+
+		err := repositories.Order.Insert(ctx, model.Order{ID: uuid.NewString(), Number: orderNumber, UserLogin: login, Status: model.StatusNew})
+		assert.ErrorIs(t, err, apperrors.ErrOrderUploadedByUser)
+
+		anotherLogin := newUser(t, repositories)
+		err = repositories.Order.Insert(ctx, model.Order{ID: uuid.NewString(), Number: orderNumber, UserLogin: anotherLogin, Status: model.StatusNew})
+		assert.ErrorIs(t, err, apperrors.ErrOrderUploadedByAnotherUser)
+	})
+
+	t.Run("select user orders newest first", func(t *testing.T) {
+		login := newUser(t, repositories)
+		first := newOrder(t, repositories, login)
+		time.Sleep(10 * time.Millisecond)
+		second := newOrder(t, repositories, login)
+		newOrder(t, repositories, newUser(t, repositories))
+
+		orders, err := repositories.Order.SelectAll(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, orders, 2)
+		assert.Equal(t, second, orders[0].Number)
+		assert.Equal(t, first, orders[1].Number)
+		assert.Equal(t, model.StatusNew, orders[0].Status)
+		assert.True(t, orders[0].Accrual.IsZero())
+
+		orders, err = repositories.Order.SelectAll(ctx, "contract-"+uuid.NewString())
+		require.NoError(t, err)
+		assert.Empty(t, orders)
+	})
+
+	t.Run("select order by number", func(t *testing.T) {
+		login := newUser(t, repositories)
+		orderNumber := newOrder(t, repositories, login)
+
+		order, err := repositories.Order.SelectByNumber(ctx, orderNumber, login)
+		require.NoError(t, err)
+		assert.Equal(t, orderNumber, order.Number)
+		assert.Nil(t, order.AccrualStartedAt)
+		require.Len(t, order.History, 1)
+		assert.Nil(t, order.History[0].FromStatus)
+		assert.Equal(t, model.StatusNew, order.History[0].ToStatus)
+		assert.Equal(t, model.SourceUpload, order.History[0].Source)
+
+		_, err = repositories.Order.SelectByNumber(ctx, orderNumber, newUser(t, repositories))
+		assert.ErrorIs(t, err, apperrors.ErrOrderNotFound)
+	})
+
+	t.Run("claim and release", func(t *testing.T) {
+		orderNumber := newOrder(t, repositories, newUser(t, repositories))
+
+		assert.Equal(t, []string{orderNumber}, claim(t, repositories, time.Minute, orderNumber))
+		assert.Empty(t, claim(t, repositories, time.Minute, orderNumber), "claimed order must not be claimed again")
+
+		leased, err := repositories.Order.SelectLeased(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, leasedNumbers(leased), orderNumber)
+
+		require.NoError(t, repositories.Order.ReleaseOrder(ctx, orderNumber))
+		assert.Equal(t, []string{orderNumber}, claim(t, repositories, time.Minute, orderNumber))
+		release(t, repositories, orderNumber)
+	})
+
+	t.Run("expired lease is reclaimed", func(t *testing.T) {
+		orderNumber := newOrder(t, repositories, newUser(t, repositories))
+
+		assert.Equal(t, []string{orderNumber}, claim(t, repositories, 0, orderNumber))
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, []string{orderNumber}, claim(t, repositories, time.Minute, orderNumber))
+		release(t, repositories, orderNumber)
+	})
+
+	t.Run("update order status", func(t *testing.T) {
+		login := newUser(t, repositories)
+		orderNumber := newOrder(t, repositories, login)
+		claim(t, repositories, time.Minute, orderNumber)
+
+		processing := model.Order{Number: orderNumber, UserLogin: login, Status: model.StatusProcessing}
+		require.NoError(t, updateOrder(repositories, processing, model.StatusNew, 0))
+
+		assert.ErrorIs(t, updateOrder(repositories, processing, model.StatusNew, 0), apperrors.ErrOrderStatusConflict)
+		assert.ErrorIs(t, updateOrder(repositories, model.Order{Number: orderNumber, UserLogin: login, Status: model.StatusNew}, model.StatusProcessing, 0),
+			apperrors.ErrIllegalStatusTransition)
+
+		processed := model.Order{Number: orderNumber, UserLogin: login, Status: model.StatusProcessed,
+			Accrual: decimal.NewFromInt(550), RawAccrual: decimal.NewFromInt(500)}
+		require.NoError(t, updateOrder(repositories, processed, model.StatusProcessing, 0))
+		assert.ErrorIs(t, updateOrder(repositories, processed, model.StatusProcessed, 0), apperrors.ErrIllegalStatusTransition)
+
+		order, err := repositories.Order.SelectByNumber(ctx, orderNumber, login)
+		require.NoError(t, err)
+		assert.Equal(t, model.StatusProcessed, order.Status)
+		assert.True(t, decimal.NewFromInt(550).Equal(order.Accrual))
+		assert.True(t, decimal.NewFromInt(500).Equal(order.RawAccrual))
+		assert.Equal(t, 2, order.AccrualCount)
+		assert.NotNil(t, order.AccrualFinishedAt)
+		require.Len(t, order.History, 3)
+		assert.Equal(t, model.StatusProcessing, order.History[1].ToStatus)
+		assert.Equal(t, model.StatusProcessed, order.History[2].ToStatus)
+		assert.Equal(t, model.SourceAccrual, order.History[2].Source)
+		assert.Equal(t, 2, order.History[2].Attempt)
+
+		assert.Empty(t, claim(t, repositories, time.Minute, orderNumber), "processed order must not be claimed")
+	})
+
+	t.Run("retry is delayed", func(t *testing.T) {
+		login := newUser(t, repositories)
+		orderNumber := newOrder(t, repositories, login)
+		claim(t, repositories, time.Minute, orderNumber)
+
+		require.NoError(t, repositories.Order.RetryOrder(ctx, orderNumber, time.Hour))
+		assert.Empty(t, claim(t, repositories, time.Minute, orderNumber), "order must not be claimed before its next attempt")
+
+		order, err := repositories.Order.SelectByNumber(ctx, orderNumber, login)
+		require.NoError(t, err)
+		assert.Equal(t, 1, order.AccrualCount)
+
+		anotherOrder := newOrder(t, repositories, login)
+		claim(t, repositories, time.Minute, anotherOrder)
+		require.NoError(t, repositories.Order.RetryOrder(ctx, anotherOrder, 0))
+		assert.Equal(t, []string{anotherOrder}, claim(t, repositories, time.Minute, anotherOrder))
+		release(t, repositories, anotherOrder)
+	})
+
+	t.Run("dead letter and requeue", func(t *testing.T) {
+		orderNumber := newOrder(t, repositories, newUser(t, repositories))
+		claim(t, repositories, time.Minute, orderNumber)
+
+		assert.ErrorIs(t, repositories.Order.Requeue(ctx, orderNumber), apperrors.ErrOrderNotFound)
+
+		require.NoError(t, repositories.Order.DeadLetterOrder(ctx, orderNumber))
+		assert.Empty(t, claim(t, repositories, time.Minute, orderNumber), "dead lettered order must not be claimed")
+
+		deadLettered, err := repositories.Order.SelectDeadLettered(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, deadLetteredNumbers(deadLettered), orderNumber)
+
+		require.NoError(t, repositories.Order.Requeue(ctx, orderNumber))
+		deadLettered, err = repositories.Order.SelectDeadLettered(ctx)
+		require.NoError(t, err)
+		assert.NotContains(t, deadLetteredNumbers(deadLettered), orderNumber)
+		assert.Equal(t, []string{orderNumber}, claim(t, repositories, time.Minute, orderNumber))
+		release(t, repositories, orderNumber)
+	})
+
+	t.Run("parallel claims never share an order", func(t *testing.T) {
+		const orders = 10
+		login := newUser(t, repositories)
+		numbers := make([]string, 0, orders)
+		for i := 0; i < orders; i++ {
+			numbers = append(numbers, newOrder(t, repositories, login))
+		}
+
+		claimed := make(chan []string, orders)
+		var wg sync.WaitGroup
+		for i := 0; i < orders; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				claimed <- claimNumbers(t, repositories, 1, time.Minute, numbers...)
+			}()
+		}
+		wg.Wait()
+		close(claimed)
+
+		seen := make(map[string]int)
+		for batch := range claimed {
+			for _, number := range batch {
+				seen[number]++
+			}
+		}
+		for _, number := range claim(t, repositories, time.Minute, numbers...) {
+			seen[number]++
+		}
+
+		for _, number := range numbers {
+			assert.Equal(t, 1, seen[number], "order %s must be claimed exactly once", number)
+		}
+		release(t, repositories, numbers...)
+	})
+}
+
+func updateOrder(repositories Repositories, order model.Order, previousStatus string, retryDelay time.Duration) error {
+	return repositories.TrManager.Do(context.Background(), func(ctx context.Context) error {
+		return repositories.Order.UpdateOrder(ctx, order, previousStatus, retryDelay)
+	})
+}
+
+// claim claims orders and returns those of ours that were claimed.
+func claim(t *testing.T, repositories Repositories, leaseTimeout time.Duration, ours ...string) []string {
+	t.Helper()
+	return claimNumbers(t, repositories, claimLimit, leaseTimeout, ours...)
+}
+
+// claimNumbers claims up to limit orders and returns those of ours that were claimed.
+// Orders of other tests sharing the storage are released right away.
+func claimNumbers(t *testing.T, repositories Repositories, limit int, leaseTimeout time.Duration, ours ...string) []string {
+	t.Helper()
+
+	orders, err := repositories.Order.ClaimOrders(context.Background(), limit, leaseTimeout)
+	if err != nil {
+		assert.ErrorIs(t, err, apperrors.ErrNoOrders)
+		return nil
+	}
+
+	claimed := make([]string, 0, len(orders))
+	for _, order := range orders {
+		if slices.Contains(ours, order.Number) {
+			claimed = append(claimed, order.Number)
+		} else {
+			release(t, repositories, order.Number)
+		}
+	}
+
+	return claimed
+}
+
+func release(t *testing.T, repositories Repositories, numbers ...string) {
+	t.Helper()
+
+	for _, number := range numbers {
+		assert.NoError(t, repositories.Order.ReleaseOrder(context.Background(), number))
+	}
+}
+
+func leasedNumbers(orders []model.LeasedOrder) []string {
+	numbers := make([]string, 0, len(orders))
+	for _, order := range orders {
+		numbers = append(numbers, order.Number)
+	}
+	return numbers
+}
+
+func deadLetteredNumbers(orders []model.DeadLetteredOrder) []string {
+	numbers := make([]string, 0, len(orders))
+	for _, order := range orders {
+		numbers = append(numbers, order.Number)
+	}
+	return numbers
+}
@@ -0,0 +1,49 @@
+package storagetest
+
+import (
+	"os"
+	"testing"
+
+	trmpgx "github.com/avito-tech/go-transaction-manager/pgxv5"
+	"github.com/avito-tech/go-transaction-manager/trm/manager"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	balanceRepository "github.com/msmkdenis/yap-gophermart/internal/balance/repository"
+	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	orderRepository "github.com/msmkdenis/yap-gophermart/internal/order/repository"
+	promoRepository "github.com/msmkdenis/yap-gophermart/internal/promo/repository"
+	referralRepository "github.com/msmkdenis/yap-gophermart/internal/referral/repository"
+	userRepository "github.com/msmkdenis/yap-gophermart/internal/user/repository"
+)
+
+// databaseURIEnv names the variable with the PostgreSQL connection string, the test is skipped when it is not set.
+const databaseURIEnv = "E2E_DATABASE_URI"
+
+func TestPostgresRepositories(t *testing.T) {
+	databaseURI := os.Getenv(databaseURIEnv)
+	if databaseURI == "" {
+		t.Skipf("%s is not set, skipping PostgreSQL repositories", databaseURIEnv)
+	}
+
+	logger := zap.NewNop()
+	postgresPool, err := db.NewPostgresPool(databaseURI, logger)
+	require.NoError(t, err)
+	t.Cleanup(postgresPool.DB.Close)
+
+	migrations, err := db.NewMigrations(databaseURI, logger)
+	require.NoError(t, err)
+	require.NoError(t, migrations.MigrateUp())
+
+	Run(t, func(t *testing.T) Repositories {
+		return Repositories{
+			User:      userRepository.NewPostgresUserRepository(postgresPool, logger),
+			Order:     orderRepository.NewPostgresOrderRepository(postgresPool, logger),
+			Balance:   balanceRepository.NewPostgresBalanceRepository(postgresPool, logger),
+			Promo:     promoRepository.NewPostgresPromoRepository(postgresPool, logger),
+			Referral:  referralRepository.NewPostgresReferralRepository(postgresPool, logger),
+			TrManager: manager.Must(trmpgx.NewDefaultFactory(postgresPool.DB)),
+		}
+	})
+}
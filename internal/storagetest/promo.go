@@ -0,0 +1,170 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	balanceModel "github.com/msmkdenis/yap-gophermart/internal/balance/model"
+	"github.com/msmkdenis/yap-gophermart/internal/promo/model"
+)
+
+func runPromoContract(t *testing.T, repositories Repositories) {
+	ctx := context.Background()
+
+	t.Run("insert promo code once", func(t *testing.T) {
+		promo := newPromoCode(decimal.NewFromInt(50), 10, 1)
+		require.NoError(t, repositories.Promo.Insert(ctx, &promo))
+		assert.False(t, promo.CreatedAt.IsZero())
+
+		duplicate := newPromoCode(decimal.NewFromInt(10), 1, 1)
+		duplicate.Code = promo.Code
+		assert.ErrorIs(t, repositories.Promo.Insert(ctx, &duplicate), apperrors.ErrPromoCodeAlreadyExists)
+
+		promoCodes, err := repositories.Promo.SelectAll(ctx)
+		require.NoError(t, err)
+		var found bool
+		for _, v := range promoCodes {
+			if v.Code != promo.Code {
+				continue
+			}
+			found = true
+			assert.Equal(t, promo.ID, v.ID)
+			assert.True(t, promo.Amount.Equal(v.Amount))
+			assert.Equal(t, 10, v.MaxRedemptions)
+			assert.Equal(t, 1, v.PerUserLimit)
+			assert.True(t, promo.ValidFrom.Equal(v.ValidFrom))
+			assert.True(t, promo.ValidUntil.Equal(v.ValidUntil))
+			assert.Zero(t, v.Redemptions)
+		}
+		assert.True(t, found, "promo code must be selected")
+	})
+
+	t.Run("unknown promo code", func(t *testing.T) {
+		_, err := repositories.Promo.BlockByCode(ctx, "CONTRACT-UNKNOWN")
+		assert.ErrorIs(t, err, apperrors.ErrPromoCodeNotFound)
+	})
+
+	t.Run("redeem promo code", func(t *testing.T) {
+		login := newUser(t, repositories)
+		promo := newPromoCode(decimal.NewFromInt(50), 10, 2)
+		require.NoError(t, repositories.Promo.Insert(ctx, &promo))
+
+		require.NoError(t, redeemPromoCode(repositories, promo.Code, login))
+		require.NoError(t, redeemPromoCode(repositories, promo.Code, login))
+		assert.ErrorIs(t, redeemPromoCode(repositories, promo.Code, login), apperrors.ErrPromoCodeAlreadyRedeemed)
+
+		assertBalance(t, repositories, login, decimal.NewFromInt(100), decimal.Zero)
+
+		blocked, err := repositories.Promo.BlockByCode(ctx, promo.Code)
+		require.NoError(t, err)
+		assert.Equal(t, 2, blocked.Redemptions)
+
+		redeemed, err := repositories.Promo.CountRedemptionsByUser(ctx, promo.ID, login)
+		require.NoError(t, err)
+		assert.Equal(t, 2, redeemed)
+
+		entries, err := repositories.Balance.SelectLedgerByUserLogin(ctx, login)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		for _, entry := range entries {
+			assert.Equal(t, balanceModel.ReasonPromo, entry.Reason)
+			assert.Equal(t, promo.Code, entry.Reference)
+		}
+	})
+
+	t.Run("parallel redemptions never exceed max redemptions", func(t *testing.T) {
+		const attempts = 10
+		promo := newPromoCode(decimal.NewFromInt(20), 3, 1)
+		require.NoError(t, repositories.Promo.Insert(ctx, &promo))
+
+		logins := make([]string, attempts)
+		for i := range logins {
+			logins[i] = newUser(t, repositories)
+		}
+
+		errs := make(chan error, attempts)
+		var wg sync.WaitGroup
+		for _, login := range logins {
+			wg.Add(1)
+			go func(login string) {
+				defer wg.Done()
+				errs <- redeemPromoCode(repositories, promo.Code, login)
+			}(login)
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, apperrors.ErrPromoCodeExhausted)
+		}
+		assert.Equal(t, 3, succeeded)
+
+		blocked, err := repositories.Promo.BlockByCode(ctx, promo.Code)
+		require.NoError(t, err)
+		assert.Equal(t, 3, blocked.Redemptions)
+		assert.True(t, blocked.Exhausted())
+	})
+}
+
+func newPromoCode(amount decimal.Decimal, maxRedemptions int, perUserLimit int) model.PromoCode {
+	now := time.Now().UTC().Truncate(time.Second)
+	return model.PromoCode{
+		ID:             uuid.NewString(),
+		Code:           "CONTRACT-" + uuid.NewString(),
+		Amount:         amount,
+		MaxRedemptions: maxRedemptions,
+		PerUserLimit:   perUserLimit,
+		ValidFrom:      now.Add(-time.Hour),
+		ValidUntil:     now.Add(time.Hour),
+	}
+}
+
+// redeemPromoCode redeems the promo code the way the promo service does: the limits are checked
+// against the promo code blocked for the transaction, then the amount is credited to the balance.
+func redeemPromoCode(repositories Repositories, code string, userLogin string) error {
+	return repositories.TrManager.Do(context.Background(), func(ctx context.Context) error {
+		promo, err := repositories.Promo.BlockByCode(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		redeemed, err := repositories.Promo.CountRedemptionsByUser(ctx, promo.ID, userLogin)
+		if err != nil {
+			return err
+		}
+
+		if redeemed >= promo.PerUserLimit {
+			return apperrors.ErrPromoCodeAlreadyRedeemed
+		}
+
+		if promo.Exhausted() {
+			return apperrors.ErrPromoCodeExhausted
+		}
+
+		err = repositories.Promo.InsertRedemption(ctx, &model.Redemption{
+			ID:          uuid.NewString(),
+			PromoCodeID: promo.ID,
+			Code:        promo.Code,
+			UserLogin:   userLogin,
+			Amount:      promo.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		return repositories.Balance.UpdateBalance(ctx, userLogin, promo.Amount, balanceModel.ReasonPromo, promo.Code)
+	})
+}
@@ -0,0 +1,186 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/referral/model"
+	userModel "github.com/msmkdenis/yap-gophermart/internal/user/model"
+)
+
+func runReferralContract(t *testing.T, repositories Repositories) {
+	ctx := context.Background()
+
+	t.Run("referral code", func(t *testing.T) {
+		login := newUser(t, repositories)
+
+		referralCode, err := repositories.Referral.SelectReferralCode(ctx, login)
+		require.NoError(t, err)
+		assert.NotEmpty(t, referralCode)
+
+		user, err := repositories.User.SelectByLogin(ctx, login)
+		require.NoError(t, err)
+		assert.Equal(t, referralCode, user.ReferralCode)
+
+		_, err = repositories.Referral.SelectReferralCode(ctx, "contract-unknown")
+		assert.ErrorIs(t, err, apperrors.ErrUserNotFound)
+
+		err = repositories.TrManager.Do(ctx, func(ctx context.Context) error {
+			_, errBlock := repositories.Referral.BlockReferrer(ctx, "CONTRACT-UNKNOWN")
+			return errBlock
+		})
+		assert.ErrorIs(t, err, apperrors.ErrReferralCodeNotFound)
+	})
+
+	t.Run("referrals", func(t *testing.T) {
+		referrer := newUser(t, repositories)
+
+		referrals, err := repositories.Referral.SelectByReferrer(ctx, referrer)
+		require.NoError(t, err)
+		assert.Empty(t, referrals)
+
+		first := newReferee(t, repositories, referrer)
+		second := newReferee(t, repositories, referrer)
+
+		count, err := repositories.Referral.CountByReferrer(ctx, referrer)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		referrals, err = repositories.Referral.SelectByReferrer(ctx, referrer)
+		require.NoError(t, err)
+		require.Len(t, referrals, 2)
+		assert.Equal(t, first, referrals[0].RefereeLogin)
+		assert.Equal(t, second, referrals[1].RefereeLogin)
+		for _, referral := range referrals {
+			assert.Equal(t, referrer, referral.ReferrerLogin)
+			assert.Equal(t, model.StatusPending, referral.Status)
+			assert.True(t, referral.Bonus.IsZero())
+			assert.False(t, referral.RegisteredAt.IsZero())
+			assert.Nil(t, referral.CreditedAt)
+		}
+
+		err = repositories.Referral.Insert(ctx, model.Referral{RefereeLogin: referrer, ReferrerLogin: referrer})
+		assert.ErrorIs(t, err, apperrors.ErrSelfReferral)
+	})
+
+	t.Run("credit referral once", func(t *testing.T) {
+		referrer := newUser(t, repositories)
+		referee := newReferee(t, repositories, referrer)
+		bonus := decimal.NewFromInt(100)
+
+		creditedReferrer, err := creditReferral(repositories, referee, bonus)
+		require.NoError(t, err)
+		assert.Equal(t, referrer, creditedReferrer)
+
+		_, err = creditReferral(repositories, referee, bonus)
+		assert.ErrorIs(t, err, apperrors.ErrNoPendingReferral)
+		_, err = creditReferral(repositories, referrer, bonus)
+		assert.ErrorIs(t, err, apperrors.ErrNoPendingReferral)
+
+		referrals, err := repositories.Referral.SelectByReferrer(ctx, referrer)
+		require.NoError(t, err)
+		require.Len(t, referrals, 1)
+		assert.Equal(t, model.StatusCredited, referrals[0].Status)
+		assert.True(t, bonus.Equal(referrals[0].Bonus))
+		assert.NotNil(t, referrals[0].CreditedAt)
+	})
+
+	t.Run("parallel registrations never exceed referral limit", func(t *testing.T) {
+		const (
+			attempts = 10
+			limit    = 3
+		)
+		referrer := newUser(t, repositories)
+		referralCode, err := repositories.Referral.SelectReferralCode(ctx, referrer)
+		require.NoError(t, err)
+
+		errs := make(chan error, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- registerReferee(repositories, "contract-"+uuid.NewString(), referralCode, limit)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, apperrors.ErrReferralLimitExceeded)
+		}
+		assert.Equal(t, limit, succeeded)
+
+		count, err := repositories.Referral.CountByReferrer(ctx, referrer)
+		require.NoError(t, err)
+		assert.Equal(t, limit, count)
+	})
+}
+
+func newReferee(t *testing.T, repositories Repositories, referrerLogin string) string {
+	t.Helper()
+
+	referralCode, err := repositories.Referral.SelectReferralCode(context.Background(), referrerLogin)
+	require.NoError(t, err)
+
+	login := "contract-" + uuid.NewString()
+	require.NoError(t, registerReferee(repositories, login, referralCode, 0))
+
+	return login
+}
+
+// registerReferee registers the user the way the user service does: the referral limit is checked
+// against the referrer blocked for the transaction, then the user and the referral are saved.
+func registerReferee(repositories Repositories, login string, referralCode string, limit int) error {
+	return repositories.TrManager.Do(context.Background(), func(ctx context.Context) error {
+		referrerLogin, err := repositories.Referral.BlockReferrer(ctx, referralCode)
+		if err != nil {
+			return err
+		}
+
+		if limit > 0 {
+			referrals, errCount := repositories.Referral.CountByReferrer(ctx, referrerLogin)
+			if errCount != nil {
+				return errCount
+			}
+
+			if referrals >= limit {
+				return apperrors.ErrReferralLimitExceeded
+			}
+		}
+
+		err = repositories.User.Insert(ctx, userModel.User{
+			ID:           uuid.NewString(),
+			Login:        login,
+			Password:     []byte("password-hash"),
+			ReferralCode: newReferralCode(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return repositories.Referral.Insert(ctx, model.Referral{RefereeLogin: login, ReferrerLogin: referrerLogin})
+	})
+}
+
+func creditReferral(repositories Repositories, refereeLogin string, bonus decimal.Decimal) (string, error) {
+	var referrerLogin string
+	err := repositories.TrManager.Do(context.Background(), func(ctx context.Context) error {
+		var err error
+		referrerLogin, err = repositories.Referral.Credit(ctx, refereeLogin, bonus)
+		return err
+	})
+	return referrerLogin, err
+}
@@ -0,0 +1,88 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	"github.com/msmkdenis/yap-gophermart/internal/user/model"
+)
+
+func runUserContract(t *testing.T, repositories Repositories) {
+	ctx := context.Background()
+
+	t.Run("insert and select", func(t *testing.T) {
+		user := model.User{ID: uuid.NewString(), Login: "contract-" + uuid.NewString(), Password: []byte("hash"), ReferralCode: newReferralCode()}
+		require.NoError(t, repositories.User.Insert(ctx, user))
+
+		selected, err := repositories.User.SelectByLogin(ctx, user.Login)
+		require.NoError(t, err)
+		assert.Equal(t, user, *selected)
+	})
+
+	t.Run("duplicate login", func(t *testing.T) {
+		login := newUser(t, repositories)
+
+		err := repositories.User.Insert(ctx, model.User{ID: uuid.NewString(), Login: login, Password: []byte("hash"), ReferralCode: newReferralCode()})
+		assert.ErrorIs(t, err, apperrors.ErrLoginAlreadyExists)
+	})
+
+	t.Run("duplicate referral code", func(t *testing.T) {
+		user := model.User{ID: uuid.NewString(), Login: "contract-" + uuid.NewString(), Password: []byte("hash"), ReferralCode: newReferralCode()}
+		require.NoError(t, repositories.User.Insert(ctx, user))
+
+		login := "contract-" + uuid.NewString()
+		err := repositories.User.Insert(ctx, model.User{ID: uuid.NewString(), Login: login, Password: []byte("hash"), ReferralCode: user.ReferralCode})
+		assert.ErrorIs(t, err, apperrors.ErrReferralCodeAlreadyExists)
+
+		_, err = repositories.User.SelectByLogin(ctx, login)
+		assert.ErrorIs(t, err, apperrors.ErrUserNotFound)
+	})
+
+	t.Run("unknown login", func(t *testing.T) {
+		_, err := repositories.User.SelectByLogin(ctx, "contract-"+uuid.NewString())
+		assert.ErrorIs(t, err, apperrors.ErrUserNotFound)
+	})
+
+	t.Run("new user has empty balance", func(t *testing.T) {
+		login := newUser(t, repositories)
+
+		balance, err := repositories.Balance.SelectByUserLogin(ctx, login)
+		require.NoError(t, err)
+		assert.Equal(t, login, balance.UserLogin)
+		assert.True(t, balance.Current.IsZero())
+		assert.True(t, balance.Withdrawn.IsZero())
+	})
+
+	t.Run("parallel registrations of one login", func(t *testing.T) {
+		const attempts = 10
+		login := "contract-" + uuid.NewString()
+
+		errs := make(chan error, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- repositories.User.Insert(ctx, model.User{ID: uuid.NewString(), Login: login, Password: []byte("hash"), ReferralCode: newReferralCode()})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, apperrors.ErrLoginAlreadyExists)
+		}
+		assert.Equal(t, 1, succeeded)
+	})
+}
@@ -1,8 +1,9 @@
 package dto
 
 type UserRegisterRequest struct {
-	Login    string `json:"login" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	Login        string `json:"login" validate:"required"`
+	Password     string `json:"password" validate:"required"`
+	ReferralCode string `json:"referral_code,omitempty" validate:"max=64"`
 }
 
 type UserLoginRequest struct {
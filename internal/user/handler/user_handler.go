@@ -42,13 +42,14 @@ func NewUserHandler(e *echo.Echo, service UserService, jwtManager *utils.JWTMana
 }
 
 // @Summary       User registration
-// @Description   User registration by login and password.
+// @Description   User registration by login and password. The optional referral code of another user makes the user a referee of its owner.
 // @Tags          User API
 // @Accept        json
-// @Param         user   body       dto.UserRegisterRequest   true   "User login and password."
+// @Param         user   body       dto.UserRegisterRequest   true   "User login, password and optional referral code."
 // @Success       200
 // @Failure       400
 // @Failure       409
+// @Failure       422
 // @Failure       500
 // @Router        /api/user/register [post]
 func (h *UserHandler) RegisterUser(c echo.Context) error {
@@ -78,6 +79,12 @@ func (h *UserHandler) RegisterUser(c echo.Context) error {
 		return c.NoContent(http.StatusConflict)
 	}
 
+	if errors.Is(err, apperrors.ErrReferralCodeNotFound) || errors.Is(err, apperrors.ErrSelfReferral) ||
+		errors.Is(err, apperrors.ErrReferralLimitExceeded) {
+		h.logger.Warn("Unprocessable entity: referral rejected", zap.Error(err))
+		return c.NoContent(http.StatusUnprocessableEntity)
+	}
+
 	if err != nil {
 		h.logger.Error("Unable to register user", zap.Error(err))
 		return c.NoContent(http.StatusInternalServerError)
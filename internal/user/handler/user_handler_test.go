@@ -62,9 +62,18 @@ func (s *UserHandlersSuite) TestRegisterUser() {
 		Password: "awesome_password",
 	}
 
+	referralRegisterRequest := dto.UserRegisterRequest{
+		Login:        "awesome_login",
+		Password:     "awesome_password",
+		ReferralCode: "AWESOMECODE1",
+	}
+
 	invalidRegisterRequestTaskJSON, err := json.Marshal(invalidRegisterRequest)
 	require.NoError(s.T(), err)
 
+	referralRegisterRequestTaskJSON, err := json.Marshal(referralRegisterRequest)
+	require.NoError(s.T(), err)
+
 	validRegisterRequestTaskJSON, err := json.Marshal(validRegisterRequest)
 	require.NoError(s.T(), err)
 
@@ -124,6 +133,44 @@ func (s *UserHandlersSuite) TestRegisterUser() {
 			expectedCode: http.StatusConflict,
 			expectedBody: "",
 		},
+		{
+			name:   "Success with referral code - 200 OK",
+			method: http.MethodPost,
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			body:   string(referralRegisterRequestTaskJSON),
+			path:   "http://localhost:8000/api/user/register",
+			prepare: func() {
+				s.userService.EXPECT().Register(gomock.Any(), referralRegisterRequest).Times(1).Return(nil)
+			},
+			expectedCode:       http.StatusOK,
+			expectedBody:       "",
+			expectedLogin:      referralRegisterRequest.Login,
+			expectedCookieName: cfgMock.TokenName,
+		},
+		{
+			name:   "Unknown referral code - 422 Unprocessable entity",
+			method: http.MethodPost,
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			body:   string(referralRegisterRequestTaskJSON),
+			path:   "http://localhost:8000/api/user/register",
+			prepare: func() {
+				s.userService.EXPECT().Register(gomock.Any(), referralRegisterRequest).Times(1).Return(apperrors.ErrReferralCodeNotFound)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: "",
+		},
+		{
+			name:   "Referral limit exceeded - 422 Unprocessable entity",
+			method: http.MethodPost,
+			header: map[string][]string{"Content-Type": {"application/json"}},
+			body:   string(referralRegisterRequestTaskJSON),
+			path:   "http://localhost:8000/api/user/register",
+			prepare: func() {
+				s.userService.EXPECT().Register(gomock.Any(), referralRegisterRequest).Times(1).Return(apperrors.ErrReferralLimitExceeded)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: "",
+		},
 	}
 
 	for _, test := range testCases {
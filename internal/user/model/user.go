@@ -1,7 +1,8 @@
 package model
 
 type User struct {
-	ID       string `db:"id"`
-	Login    string `db:"login"`
-	Password []byte `db:"password"`
+	ID           string `db:"id"`
+	Login        string `db:"login"`
+	Password     []byte `db:"password"`
+	ReferralCode string `db:"referral_code"`
 }
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+
+	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	balanceModel "github.com/msmkdenis/yap-gophermart/internal/balance/model"
+	db "github.com/msmkdenis/yap-gophermart/internal/database"
+	"github.com/msmkdenis/yap-gophermart/internal/user/model"
+	"github.com/msmkdenis/yap-gophermart/internal/utils"
+)
+
+type MemoryUserRepository struct {
+	storage  *db.MemoryStorage
+	users    *db.MemoryTable[string, model.User]
+	balances *db.MemoryTable[string, balanceModel.Balance]
+	logger   *zap.Logger
+}
+
+func NewMemoryUserRepository(storage *db.MemoryStorage, logger *zap.Logger) *MemoryUserRepository {
+	return &MemoryUserRepository{
+		storage:  storage,
+		users:    db.Table[string, model.User](storage, "user"),
+		balances: db.Table[string, balanceModel.Balance](storage, "balance"),
+		logger:   logger,
+	}
+}
+
+// Insert saves the user and creates an empty balance, as the create_balance trigger does in the database.
+// A collision of the generated referral code is reported as ErrReferralCodeAlreadyExists.
+func (r *MemoryUserRepository) Insert(ctx context.Context, user model.User) error {
+	defer r.storage.Lock(ctx)()
+
+	if _, ok := r.users.Get(user.Login); ok {
+		return apperrors.ErrLoginAlreadyExists
+	}
+
+	sameCode := r.users.Select(func(existing model.User) bool {
+		return existing.ReferralCode == user.ReferralCode
+	})
+	if len(sameCode) > 0 {
+		return apperrors.ErrReferralCodeAlreadyExists
+	}
+
+	r.users.Put(user.Login, user)
+	r.balances.Put(user.Login, balanceModel.Balance{
+		ID:        uuid.New().String(),
+		UserLogin: user.Login,
+		Current:   decimal.Zero,
+		Withdrawn: decimal.Zero,
+		Tier:      balanceModel.DefaultTier,
+	})
+
+	return nil
+}
+
+func (r *MemoryUserRepository) SelectByLogin(ctx context.Context, login string) (*model.User, error) {
+	defer r.storage.Lock(ctx)()
+
+	user, ok := r.users.Get(login)
+	if !ok {
+		return nil, apperrors.NewValueError("user not found", utils.Caller(), apperrors.ErrUserNotFound)
+	}
+
+	return &user, nil
+}
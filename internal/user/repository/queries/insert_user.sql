@@ -1,3 +1,3 @@
 insert into gophermart.user
-    (id, login, password)
-values ($1, $2, $3);
\ No newline at end of file
+    (id, login, password, referral_code)
+values ($1, $2, $3, $4);
\ No newline at end of file
@@ -1,3 +1,3 @@
-select id, login, password
+select id, login, password, referral_code
 from gophermart.user
 where login = $1;
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"errors"
 
+	trmpgx "github.com/avito-tech/go-transaction-manager/pgxv5"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -26,19 +27,28 @@ var selectUserByLogin string
 type PostgresUserRepository struct {
 	postgresPool *db.PostgresPool
 	logger       *zap.Logger
+	getter       *trmpgx.CtxGetter
 }
 
 func NewPostgresUserRepository(postgresPool *db.PostgresPool, logger *zap.Logger) *PostgresUserRepository {
 	return &PostgresUserRepository{
 		postgresPool: postgresPool,
 		logger:       logger,
+		getter:       trmpgx.DefaultCtxGetter,
 	}
 }
 
+// Insert saves the user, a collision of the generated referral code is reported as ErrReferralCodeAlreadyExists.
 func (r *PostgresUserRepository) Insert(ctx context.Context, user model.User) error {
-	_, err := r.postgresPool.DB.Exec(ctx, insertUser, user.ID, user.Login, user.Password)
+	conn := r.getter.DefaultTrOrDB(ctx, r.postgresPool.DB)
+
+	_, err := conn.Exec(ctx, insertUser, user.ID, user.Login, user.Password, user.ReferralCode)
 
 	var e *pgconn.PgError
+	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation && e.ConstraintName == "unique_referral_code" {
+		return apperrors.ErrReferralCodeAlreadyExists
+	}
+
 	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
 		return apperrors.ErrLoginAlreadyExists
 	}
@@ -48,7 +58,7 @@ func (r *PostgresUserRepository) Insert(ctx context.Context, user model.User) er
 
 func (r *PostgresUserRepository) SelectByLogin(ctx context.Context, login string) (*model.User, error) {
 	var user model.User
-	err := r.postgresPool.DB.QueryRow(ctx, selectUserByLogin, login).Scan(&user.ID, &user.Login, &user.Password)
+	err := r.postgresPool.DB.QueryRow(ctx, selectUserByLogin, login).Scan(&user.ID, &user.Login, &user.Password, &user.ReferralCode)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			err = apperrors.NewValueError("user not found", utils.Caller(), apperrors.ErrUserNotFound)
@@ -2,52 +2,137 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/avito-tech/go-transaction-manager/trm/manager"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/msmkdenis/yap-gophermart/internal/apperrors"
+	referralModel "github.com/msmkdenis/yap-gophermart/internal/referral/model"
 	"github.com/msmkdenis/yap-gophermart/internal/user/handler/dto"
 	"github.com/msmkdenis/yap-gophermart/internal/user/model"
 	"github.com/msmkdenis/yap-gophermart/internal/utils"
 )
 
+const (
+	referralCodeLength   = 12
+	referralCodeAttempts = 3
+)
+
 type UserRepository interface {
 	Insert(ctx context.Context, u model.User) error
 	SelectByLogin(ctx context.Context, login string) (*model.User, error)
 }
 
+type ReferralRepository interface {
+	BlockReferrer(ctx context.Context, referralCode string) (string, error)
+	CountByReferrer(ctx context.Context, referrerLogin string) (int, error)
+	Insert(ctx context.Context, referral referralModel.Referral) error
+}
+
 type UserUseCase struct {
-	repository UserRepository
-	logger     *zap.Logger
+	repository         UserRepository
+	referralRepository ReferralRepository
+	trManager          *manager.Manager
+	logger             *zap.Logger
+	referralLimit      int
 }
 
-func NewUserService(repository UserRepository, logger *zap.Logger) *UserUseCase {
+// NewUserService creates the user service, referralLimit caps the number of users referred by one user, 0 means no limit.
+func NewUserService(
+	repository UserRepository,
+	referralRepository ReferralRepository,
+	trManager *manager.Manager,
+	logger *zap.Logger,
+	referralLimit int,
+) *UserUseCase {
 	return &UserUseCase{
-		repository: repository,
-		logger:     logger,
+		repository:         repository,
+		referralRepository: referralRepository,
+		trManager:          trManager,
+		logger:             logger,
+		referralLimit:      referralLimit,
 	}
 }
 
+// Register saves the user with a new referral code. A code colliding with the code of another user
+// is generated again, at most referralCodeAttempts times.
 func (u *UserUseCase) Register(ctx context.Context, request dto.UserRegisterRequest) error {
 	passHash, errHash := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 	if errHash != nil {
 		return apperrors.NewValueError("unable to hash password", utils.Caller(), errHash)
 	}
 
+	for attempt := 1; ; attempt++ {
+		err := u.register(ctx, request, passHash)
+		if errors.Is(err, apperrors.ErrReferralCodeAlreadyExists) && attempt < referralCodeAttempts {
+			u.logger.Warn("Generated referral code already exists, retrying", zap.String("login", request.Login), zap.Int("attempt", attempt))
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s %w", utils.Caller(), err)
+		}
+
+		return nil
+	}
+}
+
+func (u *UserUseCase) register(ctx context.Context, request dto.UserRegisterRequest, passHash []byte) error {
+	referralCode, errCode := newReferralCode()
+	if errCode != nil {
+		return apperrors.NewValueError("unable to generate referral code", utils.Caller(), errCode)
+	}
+
 	userToSave := model.User{
-		ID:       uuid.New().String(),
-		Login:    request.Login,
-		Password: passHash,
+		ID:           uuid.New().String(),
+		Login:        request.Login,
+		Password:     passHash,
+		ReferralCode: referralCode,
 	}
 
-	if err := u.repository.Insert(ctx, userToSave); err != nil {
-		return fmt.Errorf("%s %w", utils.Caller(), err)
+	if request.ReferralCode == "" {
+		return u.repository.Insert(ctx, userToSave)
 	}
 
-	return nil
+	return u.registerReferee(ctx, userToSave, strings.ToUpper(strings.TrimSpace(request.ReferralCode)))
+}
+
+// registerReferee saves the user referred by the owner of the referral code. The referrer is blocked for the transaction,
+// so concurrent registrations can't exceed the referral limit.
+func (u *UserUseCase) registerReferee(ctx context.Context, user model.User, referralCode string) error {
+	return u.trManager.Do(ctx, func(ctx context.Context) error {
+		referrerLogin, err := u.referralRepository.BlockReferrer(ctx, referralCode)
+		if err != nil {
+			return err
+		}
+
+		if u.referralLimit > 0 {
+			referrals, errCount := u.referralRepository.CountByReferrer(ctx, referrerLogin)
+			if errCount != nil {
+				return errCount
+			}
+
+			if referrals >= u.referralLimit {
+				return apperrors.ErrReferralLimitExceeded
+			}
+		}
+
+		if err = u.repository.Insert(ctx, user); err != nil {
+			return err
+		}
+
+		return u.referralRepository.Insert(ctx, referralModel.Referral{
+			RefereeLogin:  user.Login,
+			ReferrerLogin: referrerLogin,
+		})
+	})
 }
 
 func (u *UserUseCase) Login(ctx context.Context, request dto.UserLoginRequest) error {
@@ -62,3 +147,12 @@ func (u *UserUseCase) Login(ctx context.Context, request dto.UserLoginRequest) e
 
 	return nil
 }
+
+func newReferralCode() (string, error) {
+	b := make([]byte, referralCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.EncodeToString(b)[:referralCodeLength], nil
+}